@@ -4,7 +4,15 @@ import (
 	"context"
 	"finance/config"
 	"finance/docs"
+	"finance/internal/auth"
+	"finance/internal/calendar"
+	"finance/internal/disbursement"
 	"finance/internal/handler"
+	"finance/internal/job"
+	"finance/internal/nik"
+	"finance/internal/outbox"
+	"finance/internal/phone"
+	"finance/internal/pricing"
 	"finance/internal/repository"
 	"finance/internal/services"
 	"finance/migrations"
@@ -34,6 +42,11 @@ import (
 
 // @BasePath        /
 // @schemes   http https
+
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 JWT bearer token, "Bearer <token>". Customers may only act on their own user_id, admin endpoints require the admin role.
 func main() {
 	cfg, err := config.NewConfig()
 	if err != nil {
@@ -58,36 +71,205 @@ func main() {
 	}
 
 	userRepo := repository.NewUserRepository(db.Pool)
+	kycRepo := repository.NewKYCRepository(db.Pool)
 	limitRepo := repository.NewLimitRepository(db.Pool)
+	holdRepo := repository.NewHoldRepository(db.Pool)
+	productRepo := repository.NewProductRepository(db.Pool)
+	limitChangeRepo := repository.NewLimitChangeRepository(db.Pool)
 	tenorRepo := repository.NewTenorRepository(db.Pool)
 	facilityRepo := repository.NewFacilityRepository(db.Pool)
 	detailRepo := repository.NewDetailRepository(db.Pool)
+	idempotencyRepo := repository.NewIdempotencyRepository(db.Pool)
+	applicationRepo := repository.NewApplicationRepository(db.Pool)
+	disbursementRepo := repository.NewDisbursementRepository(db.Pool)
+	outboxRepo := repository.NewOutboxRepository(db.Pool)
+	paymentRepo := repository.NewPaymentRepository(db.Pool)
+	payoffRepo := repository.NewPayoffRepository(db.Pool)
+	holidayRepo := repository.NewHolidayRepository(db.Pool)
 	trx := postgres.NewTransaction(db.Pool)
 
+	pricer, err := pricing.New(cfg.PricingMethod)
+	if err != nil {
+		l.Logger.Fatal("invalid pricing method", zap.Error(err))
+	}
+
+	rounding, err := pricing.NewRounding(cfg.InstallmentRemainder, cfg.InstallmentRoundingUnit)
+	if err != nil {
+		l.Logger.Fatal("invalid installment rounding", zap.Error(err))
+	}
+
 	svc := services.NewService(
 		userRepo,
+		kycRepo,
 		limitRepo,
+		holdRepo,
+		productRepo,
 		tenorRepo,
 		facilityRepo,
 		detailRepo,
+		idempotencyRepo,
+		applicationRepo,
+		disbursementRepo,
+		outboxRepo,
+		holidayRepo,
+		pricer,
+		rounding,
+		l,
+		trx,
+	)
+	paymentSvc := services.NewPaymentService(
+		facilityRepo,
+		detailRepo,
+		paymentRepo,
+		payoffRepo,
+		limitRepo,
+		outboxRepo,
+		disbursementRepo,
+		services.PayoffConfig{
+			RebatePolicy: cfg.PayoffRebatePolicy,
+			FeeRate:      cfg.PayoffFeeRate,
+			QuoteTTL:     cfg.PayoffQuoteTTL,
+		},
+		l,
+		trx,
+	)
+	tenorSvc := services.NewTenorService(tenorRepo, l)
+	holidaySvc := services.NewHolidayService(holidayRepo, l, trx)
+	limitSvc := services.NewLimitService(userRepo, limitRepo, l)
+	productSvc := services.NewProductService(productRepo, l)
+	userSvc := services.NewUserService(userRepo, l)
+	kycSvc := services.NewKYCService(userRepo, kycRepo, cfg.KYCValidityMonths, l)
+	limitTerms := services.LimitTerms{
+		ValidityMonths: cfg.LimitValidityMonths,
+		ReviewLeadDays: cfg.LimitReviewLeadDays,
+	}
+	limitChangeSvc := services.NewLimitChangeService(userRepo, limitRepo, productRepo, limitChangeRepo, limitTerms, l, trx)
+	holdSvc := services.NewHoldService(userRepo, limitRepo, holdRepo, cfg.LimitHoldTTL, l, trx)
+	disbursementSvc := services.NewDisbursementService(
+		disbursementRepo,
+		facilityRepo,
+		detailRepo,
+		applicationRepo,
+		limitRepo,
+		outboxRepo,
+		disbursement.NewFakeBank(cfg.FakeBankMaxAmount),
+		services.DisbursementConfig{
+			MaxAttempts: cfg.DisbursementMaxAttempts,
+			Backoff:     disbursement.Backoff{Base: cfg.DisbursementBackoff, Max: cfg.DisbursementMaxBackoff},
+		},
+		l,
+		trx,
+	)
+	outboxSvc := services.NewOutboxService(
+		outboxRepo,
+		outbox.NewLogPublisher(l),
+		services.OutboxConfig{
+			MaxAttempts: cfg.OutboxMaxAttempts,
+			Backoff:     disbursement.Backoff{Base: cfg.OutboxBackoff, Max: cfg.OutboxMaxBackoff},
+		},
 		l,
 		trx,
 	)
 
+	verifier, err := newVerifier(cfg)
+	if err != nil {
+		l.Logger.Fatal("invalid jwt configuration", zap.Error(err))
+	}
+
+	if cfg.HolidayFile != "" {
+		err = importHolidays(holidaySvc, cfg.HolidayFile)
+		if err != nil {
+			l.Logger.Fatal("failed to import holiday file", zap.String("file", cfg.HolidayFile), zap.Error(err))
+		}
+	}
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go job.Every(jobCtx, cfg.LimitReviewInterval, job.LimitReview(limitSvc, l))
+	go job.Every(jobCtx, cfg.LimitHoldSweepInterval, job.ExpireHolds(holdSvc, l))
+	go job.Every(jobCtx, cfg.KYCExpiryInterval, job.ExpireKYC(kycSvc, l))
+	go job.Every(jobCtx, cfg.DisbursementInterval, job.Disburse(disbursementSvc, l))
+	go job.Every(jobCtx, cfg.OutboxRelayInterval, job.RelayOutbox(outboxSvc, l))
+
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("notpast", validateDateNotPast)
+		v.RegisterValidation("idphone", validatePhone)
+		v.RegisterValidation("nik", validateNIK)
 	}
 
+	paymentHandler := handler.NewPaymentHandler(paymentSvc, l)
+	tenorHandler := handler.NewTenorHandler(tenorSvc, l)
+	holidayHandler := handler.NewHolidayHandler(holidaySvc, l)
+	limitHandler := handler.NewLimitHandler(limitSvc, l)
+	productHandler := handler.NewProductHandler(productSvc, l)
+	limitChangeHandler := handler.NewLimitChangeHandler(limitChangeSvc, l)
+	holdHandler := handler.NewHoldHandler(holdSvc, l)
+	userHandler := handler.NewUserHandler(userSvc, l)
+	kycHandler := handler.NewKYCHandler(kycSvc, l)
+	disbursementHandler := handler.NewDisbursementHandler(disbursementSvc, l)
+	authenticate := handler.Authenticate(verifier, l)
+	requireAdmin := handler.RequireRole(auth.RoleAdmin, l)
+	requireSelf := handler.RequireSelf("id", l)
 	handler := handler.NewHandler(svc, l)
 	r := gin.Default()
 
 	docs.SwaggerInfo.Host = fmt.Sprintf("%s:%d", cfg.AppHost, cfg.HttpPort)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	r.GET("/limits", handler.ListUserLimit)
-	r.GET("/tenors", handler.TenorList)
-	r.POST("/calculate-installments", handler.Installment)
-	r.POST("/submit-financing", handler.Submit)
+	api := r.Group("/", authenticate)
+	api.GET("/limits", handler.ListUserLimit)
+	api.GET("/tenors", handler.TenorList)
+	api.POST("/calculate-installments", handler.Installment)
+	api.POST("/submit-financing", requireAdmin, handler.Submit)
+	api.GET("/facilities", handler.ListFacilities)
+	api.GET("/facilities/:id", handler.GetFacility)
+	api.GET("/facilities/:id/recompute", requireAdmin, handler.RecomputeFacility)
+	api.POST("/users", requireAdmin, userHandler.Create)
+	api.GET("/users/:id", requireSelf, userHandler.Get)
+	api.PUT("/users/:id", requireSelf, userHandler.Update)
+	api.POST("/users/:id/deactivate", requireAdmin, userHandler.Deactivate)
+	api.GET("/users/:id/kyc", requireSelf, kycHandler.Get)
+	api.POST("/users/:id/kyc", requireSelf, kycHandler.Submit)
+	api.GET("/users/:id/facilities", requireSelf, handler.ListUserFacilities)
+	api.GET("/users/:id/limit/history", requireSelf, limitHandler.History)
+	api.POST("/limit-holds", holdHandler.Hold)
+	api.GET("/limit-holds/:id", holdHandler.Get)
+	api.POST("/limit-holds/:id/capture", handler.CaptureHold)
+	api.POST("/limit-holds/:id/release", holdHandler.Release)
+	api.POST("/applications", handler.CreateApplication)
+	api.GET("/applications", handler.ListApplications)
+	api.GET("/applications/:id", handler.GetApplication)
+	api.POST("/applications/:id/submit", handler.SubmitApplication)
+	api.POST("/applications/:id/cancel", handler.CancelApplication)
+	api.POST("/facilities/:id/payments", paymentHandler.Pay)
+	api.POST("/facilities/:id/payoff-quotes", paymentHandler.QuotePayoff)
+	api.POST("/facilities/:id/payoff", paymentHandler.Payoff)
+
+	admin := api.Group("/admin", requireAdmin)
+	admin.GET("/tenors", tenorHandler.List)
+	admin.POST("/tenors", tenorHandler.Create)
+	admin.PUT("/tenors/:id", tenorHandler.Update)
+	admin.DELETE("/tenors/:id", tenorHandler.Delete)
+	admin.GET("/holidays", holidayHandler.List)
+	admin.POST("/holidays", holidayHandler.Create)
+	admin.POST("/holidays/import", holidayHandler.Import)
+	admin.DELETE("/holidays/:id", holidayHandler.Delete)
+	admin.GET("/products", productHandler.List)
+	admin.POST("/products", productHandler.Create)
+	admin.PUT("/products/:id/pricing-method", productHandler.SetPricingMethod)
+	admin.GET("/limits/due-for-review", limitHandler.DueForReview)
+	admin.GET("/kyc", kycHandler.List)
+	admin.POST("/kyc/:user_id/verify", kycHandler.Verify)
+	admin.POST("/kyc/:user_id/reject", kycHandler.Reject)
+	admin.GET("/limit-changes", limitChangeHandler.List)
+	admin.POST("/limit-changes", limitChangeHandler.Propose)
+	admin.POST("/limit-changes/:id/approve", limitChangeHandler.Approve)
+	admin.POST("/limit-changes/:id/reject", limitChangeHandler.Reject)
+	admin.POST("/applications/:id/review", handler.StartReview)
+	admin.POST("/applications/:id/approve", handler.ApproveApplication)
+	admin.POST("/applications/:id/reject", handler.RejectApplication)
+	admin.GET("/disbursements", disbursementHandler.List)
+	admin.POST("/disbursements/:id/retry", disbursementHandler.Retry)
 
 	r.Run(fmt.Sprintf(":%d", cfg.HttpPort))
 
@@ -132,3 +314,47 @@ var validateDateNotPast validator.Func = func(fl validator.FieldLevel) bool {
 
 	return !inputDate.Before(today)
 }
+
+// validatePhone accepts Indonesian mobile numbers that phone.Normalize can
+// turn into E.164.
+var validatePhone validator.Func = func(fl validator.FieldLevel) bool {
+	_, err := phone.Normalize(fl.Field().String())
+	return err == nil
+}
+
+// validateNIK accepts national identity numbers that nik.Parse accepts.
+var validateNIK validator.Func = func(fl validator.FieldLevel) bool {
+	_, err := nik.Parse(fl.Field().String(), time.Now())
+	return err == nil
+}
+
+// newVerifier builds the bearer token verifier of the configured algorithm.
+func newVerifier(cfg *config.Config) (*auth.Verifier, error) {
+	if cfg.JWTAlgorithm == auth.RS256 {
+		key, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewRS256Verifier(key, cfg.JWTIssuer, cfg.JWTLeeway)
+	}
+
+	return auth.NewHS256Verifier([]byte(cfg.JWTSecret), cfg.JWTIssuer, cfg.JWTLeeway)
+}
+
+// importHolidays loads the holiday calendar file into the database, so the
+// calendar can be maintained as a file alongside the admin API.
+func importHolidays(svc services.HolidayService, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := calendar.ParseCSV(file)
+	if err != nil {
+		return err
+	}
+
+	_, err = svc.Import(context.Background(), entries)
+	return err
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
@@ -14,8 +17,50 @@ type Config struct {
 	LogLevel    string `env:"LOG_LEVEL"`
 	AppHost     string `env:"APP_HOST"`
 	HttpPort    int    `env:"HTTP_PORT"`
+
+	PricingMethod string `env:"PRICING_METHOD" envDefault:"flat"`
+
+	InstallmentRemainder    string          `env:"INSTALLMENT_REMAINDER" envDefault:"last"`
+	InstallmentRoundingUnit decimal.Decimal `env:"INSTALLMENT_ROUNDING_UNIT" envDefault:"100"`
+
+	HolidayFile string `env:"HOLIDAY_FILE"`
+
+	LimitValidityMonths int           `env:"LIMIT_VALIDITY_MONTHS" envDefault:"12"`
+	LimitReviewLeadDays int           `env:"LIMIT_REVIEW_LEAD_DAYS" envDefault:"30"`
+	LimitReviewInterval time.Duration `env:"LIMIT_REVIEW_INTERVAL" envDefault:"24h"`
+
+	LimitHoldTTL           time.Duration `env:"LIMIT_HOLD_TTL" envDefault:"15m"`
+	LimitHoldSweepInterval time.Duration `env:"LIMIT_HOLD_SWEEP_INTERVAL" envDefault:"1m"`
+
+	KYCValidityMonths int           `env:"KYC_VALIDITY_MONTHS" envDefault:"24"`
+	KYCExpiryInterval time.Duration `env:"KYC_EXPIRY_INTERVAL" envDefault:"1h"`
+
+	DisbursementInterval    time.Duration   `env:"DISBURSEMENT_INTERVAL" envDefault:"30s"`
+	DisbursementMaxAttempts int             `env:"DISBURSEMENT_MAX_ATTEMPTS" envDefault:"5"`
+	DisbursementBackoff     time.Duration   `env:"DISBURSEMENT_BACKOFF" envDefault:"30s"`
+	DisbursementMaxBackoff  time.Duration   `env:"DISBURSEMENT_MAX_BACKOFF" envDefault:"30m"`
+	FakeBankMaxAmount       decimal.Decimal `env:"FAKE_BANK_MAX_AMOUNT" envDefault:"0"`
+
+	OutboxRelayInterval time.Duration `env:"OUTBOX_RELAY_INTERVAL" envDefault:"5s"`
+	OutboxMaxAttempts   int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"20"`
+	OutboxBackoff       time.Duration `env:"OUTBOX_BACKOFF" envDefault:"5s"`
+	OutboxMaxBackoff    time.Duration `env:"OUTBOX_MAX_BACKOFF" envDefault:"1h"`
+
+	JWTAlgorithm     string        `env:"JWT_ALGORITHM" envDefault:"HS256"`
+	JWTSecret        string        `env:"JWT_SECRET"`
+	JWTPublicKeyFile string        `env:"JWT_PUBLIC_KEY_FILE"`
+	JWTIssuer        string        `env:"JWT_ISSUER"`
+	JWTLeeway        time.Duration `env:"JWT_LEEWAY" envDefault:"30s"`
+
+	PayoffRebatePolicy string          `env:"PAYOFF_REBATE_POLICY" envDefault:"pro-rata"`
+	PayoffFeeRate      decimal.Decimal `env:"PAYOFF_FEE_RATE" envDefault:"0"`
+	PayoffQuoteTTL     time.Duration   `env:"PAYOFF_QUOTE_TTL" envDefault:"24h"`
 }
 
+// jwtSecretPlaceholder is the example secret the repository once shipped; it
+// is public, so tokens signed with it prove nothing.
+const jwtSecretPlaceholder = "change-me-to-a-random-secret-of-32-bytes"
+
 func NewConfig() (*Config, error) {
 	cfg := Config{}
 
@@ -24,5 +69,37 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("read env error: %w", err)
 	}
 
+	switch cfg.PricingMethod {
+	case "flat", "annuity", "murabahah":
+	default:
+		return nil, fmt.Errorf("invalid PRICING_METHOD %q, use flat, annuity or murabahah", cfg.PricingMethod)
+	}
+
+	switch cfg.InstallmentRemainder {
+	case "last", "first":
+	case "unit":
+		if !cfg.InstallmentRoundingUnit.IsPositive() {
+			return nil, fmt.Errorf("invalid INSTALLMENT_ROUNDING_UNIT %s, use an amount greater than 0", cfg.InstallmentRoundingUnit)
+		}
+	default:
+		return nil, fmt.Errorf("invalid INSTALLMENT_REMAINDER %q, use last, first or unit", cfg.InstallmentRemainder)
+	}
+
+	switch cfg.PayoffRebatePolicy {
+	case "none", "pro-rata", "rule-of-78":
+	default:
+		return nil, fmt.Errorf("invalid PAYOFF_REBATE_POLICY %q, use none, pro-rata or rule-of-78", cfg.PayoffRebatePolicy)
+	}
+
+	switch cfg.JWTAlgorithm {
+	case "HS256", "RS256":
+	default:
+		return nil, fmt.Errorf("invalid JWT_ALGORITHM %q, use HS256 or RS256", cfg.JWTAlgorithm)
+	}
+
+	if cfg.JWTSecret == jwtSecretPlaceholder {
+		return nil, errors.New("JWT_SECRET is the example placeholder, set a random secret of at least 32 bytes")
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,229 @@
+// Package auth verifies JWT bearer tokens and carries the authenticated
+// principal through the request context.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+var (
+	// ErrInvalidToken is returned for a token that is malformed, signed with
+	// another key or algorithm, or carries claims this service does not accept.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned for a token past its exp or before its nbf.
+	ErrExpiredToken = errors.New("token is expired or not valid yet")
+)
+
+// Principal is the caller a token was issued to. UserID is set for customers
+// and is the only user they may act on; admins may act on every user.
+type Principal struct {
+	Subject string
+	UserID  int64
+	Role    string
+}
+
+// IsAdmin reports whether the principal has the admin role.
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// CanAccess reports whether the principal may see or act on the user.
+func (p *Principal) CanAccess(userID int64) bool {
+	return p.IsAdmin() || p.UserID == userID
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, if any. Background jobs
+// and other internal callers run without one.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// CanAccess reports whether the caller in ctx may see or act on the user.
+// A context without a principal is an internal caller and may access every
+// user; the HTTP routes always run with one.
+func CanAccess(ctx context.Context, userID int64) bool {
+	p, ok := FromContext(ctx)
+	return !ok || p.CanAccess(userID)
+}
+
+// Verifier checks the signature and claims of compact JWS tokens signed with
+// a single configured algorithm and key.
+type Verifier struct {
+	alg       string
+	secret    []byte
+	publicKey *rsa.PublicKey
+	issuer    string
+	leeway    time.Duration
+}
+
+// NewHS256Verifier verifies tokens signed with HMAC SHA-256 and the secret.
+func NewHS256Verifier(secret []byte, issuer string, leeway time.Duration) (*Verifier, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("hs256 secret must be at least 32 bytes")
+	}
+
+	return &Verifier{alg: HS256, secret: secret, issuer: issuer, leeway: leeway}, nil
+}
+
+// NewRS256Verifier verifies tokens signed with RSA PKCS #1 v1.5 SHA-256 by
+// the private key of the PEM encoded public key.
+func NewRS256Verifier(publicKeyPEM []byte, issuer string, leeway time.Duration) (*Verifier, error) {
+	key, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{alg: RS256, publicKey: key, issuer: issuer, leeway: leeway}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// Verify returns the principal of a token. The algorithm in the token header
+// must match the configured one, exp is required, and the role must be
+// customer or admin. A customer subject must be their user ID.
+func (v *Verifier) Verify(token string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	err := decodeSegment(parts[0], &h)
+	if err != nil || h.Alg != v.alg {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = v.verifySignature(parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var c claims
+	err = decodeSegment(parts[1], &c)
+	if err != nil || c.ExpiresAt == nil || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.leeway)) {
+		return nil, ErrExpiredToken
+	}
+	if c.NotBefore != nil && now.Add(v.leeway).Before(time.Unix(*c.NotBefore, 0)) {
+		return nil, ErrExpiredToken
+	}
+
+	p := &Principal{Subject: c.Subject, Role: c.Role}
+	switch c.Role {
+	case RoleAdmin:
+	case RoleCustomer:
+		p.UserID, err = strconv.ParseInt(c.Subject, 10, 64)
+		if err != nil || p.UserID <= 0 {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	return p, nil
+}
+
+func (v *Verifier) verifySignature(signingInput string, signature []byte) error {
+	switch v.alg {
+	case HS256:
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidToken
+		}
+		return nil
+	case RS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, digest[:], signature)
+	default:
+		return ErrInvalidToken
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// parseRSAPublicKey reads a PKIX ("PUBLIC KEY") or PKCS #1 ("RSA PUBLIC KEY")
+// PEM block.
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("rs256 public key is not PEM encoded")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("rs256 public key is not an RSA key")
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q for rs256 public key", block.Type)
+	}
+}
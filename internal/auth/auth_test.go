@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func sign(t *testing.T, alg string, claims map[string]any, key any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier_HS256(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	exp := now.Add(time.Hour).Unix()
+
+	v, err := NewHS256Verifier(secret, "finance-idp", time.Minute)
+	assert.NoError(t, err)
+
+	t.Run("customer", func(t *testing.T) {
+		token := sign(t, HS256, map[string]any{"sub": "7", "role": "customer", "iss": "finance-idp", "exp": exp}, secret)
+
+		p, err := v.Verify(token, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), p.UserID)
+		assert.False(t, p.IsAdmin())
+		assert.True(t, p.CanAccess(7))
+		assert.False(t, p.CanAccess(8))
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		token := sign(t, HS256, map[string]any{"sub": "ops@finance", "role": "admin", "iss": "finance-idp", "exp": exp}, secret)
+
+		p, err := v.Verify(token, now)
+		assert.NoError(t, err)
+		assert.Equal(t, "ops@finance", p.Subject)
+		assert.True(t, p.CanAccess(8))
+	})
+
+	t.Run("expired within leeway", func(t *testing.T) {
+		token := sign(t, HS256, map[string]any{"sub": "7", "role": "customer", "iss": "finance-idp", "exp": now.Add(-30 * time.Second).Unix()}, secret)
+
+		_, err := v.Verify(token, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := sign(t, HS256, map[string]any{"sub": "7", "role": "customer", "iss": "finance-idp", "exp": now.Add(-2 * time.Minute).Unix()}, secret)
+
+		_, err := v.Verify(token, now)
+		assert.ErrorIs(t, err, ErrExpiredToken)
+	})
+
+	t.Run("not valid yet", func(t *testing.T) {
+		token := sign(t, HS256, map[string]any{"sub": "7", "role": "customer", "iss": "finance-idp", "exp": exp, "nbf": now.Add(5 * time.Minute).Unix()}, secret)
+
+		_, err := v.Verify(token, now)
+		assert.ErrorIs(t, err, ErrExpiredToken)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		valid := map[string]any{"sub": "7", "role": "customer", "iss": "finance-idp", "exp": exp}
+		with := func(key string, value any) map[string]any {
+			c := map[string]any{}
+			for k, v := range valid {
+				c[k] = v
+			}
+			if value == nil {
+				delete(c, key)
+			} else {
+				c[key] = value
+			}
+			return c
+		}
+
+		for name, token := range map[string]string{
+			"malformed":         "not.a-token",
+			"wrong secret":      sign(t, HS256, valid, []byte("another secret that is long enough")),
+			"alg none":          sign(t, "none", valid, secret),
+			"wrong issuer":      sign(t, HS256, with("iss", "someone-else"), secret),
+			"no exp":            sign(t, HS256, with("exp", nil), secret),
+			"unknown role":      sign(t, HS256, with("role", "merchant"), secret),
+			"customer sub":      sign(t, HS256, with("sub", "budi"), secret),
+			"tampered payload":  sign(t, HS256, valid, secret)[:10] + "x" + sign(t, HS256, valid, secret)[11:],
+			"missing signature": sign(t, HS256, valid, secret) + ".extra",
+		} {
+			_, err := v.Verify(token, now)
+			assert.ErrorIs(t, err, ErrInvalidToken, name)
+		}
+	})
+
+	t.Run("short secret", func(t *testing.T) {
+		_, err := NewHS256Verifier([]byte("short"), "", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifier_RS256(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	claims := map[string]any{"sub": "7", "role": "customer", "exp": now.Add(time.Hour).Unix()}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"pkix":  {Type: "PUBLIC KEY", Bytes: pkix},
+		"pkcs1": {Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewRS256Verifier(pem.EncodeToMemory(block), "", 0)
+			assert.NoError(t, err)
+
+			p, err := v.Verify(sign(t, RS256, claims, key), now)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7), p.UserID)
+
+			_, err = v.Verify(sign(t, RS256, claims, other), now)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+
+			// an HS256 token signed with the public key must not pass
+			_, err = v.Verify(sign(t, HS256, claims, pem.EncodeToMemory(block)), now)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	t.Run("not a key", func(t *testing.T) {
+		_, err := NewRS256Verifier([]byte("not a key"), "", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestCanAccess(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, CanAccess(ctx, 7))
+
+	customer := WithPrincipal(ctx, &Principal{Subject: "7", UserID: 7, Role: RoleCustomer})
+	assert.True(t, CanAccess(customer, 7))
+	assert.False(t, CanAccess(customer, 8))
+
+	admin := WithPrincipal(ctx, &Principal{Subject: "ops", Role: RoleAdmin})
+	assert.True(t, CanAccess(admin, 8))
+}
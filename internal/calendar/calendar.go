@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Business day rules decide where a due date that falls on a weekend or
+// holiday is moved to.
+const (
+	RuleNone              = "none"
+	RuleFollowing         = "following"
+	RuleModifiedFollowing = "modified-following"
+	RulePreceding         = "preceding"
+)
+
+const dateLayout = "2006-01-02"
+
+// Calendar knows which days are not business days: Saturdays, Sundays and
+// the holidays it was built with.
+type Calendar struct {
+	holidays map[string]struct{}
+}
+
+func New(holidays []time.Time) *Calendar {
+	c := &Calendar{holidays: make(map[string]struct{}, len(holidays))}
+	for _, h := range holidays {
+		c.holidays[h.Format(dateLayout)] = struct{}{}
+	}
+
+	return c
+}
+
+func ValidRule(rule string) bool {
+	switch rule {
+	case RuleNone, RuleFollowing, RuleModifiedFollowing, RulePreceding:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	_, holiday := c.holidays[t.Format(dateLayout)]
+	return !holiday
+}
+
+// Adjust moves t to a business day according to rule. Following takes the
+// next business day, preceding the previous one, and modified-following takes
+// the next one unless that crosses into another month, in which case it takes
+// the previous one instead.
+func (c *Calendar) Adjust(t time.Time, rule string) time.Time {
+	switch rule {
+	case RuleFollowing:
+		return c.roll(t, 1)
+	case RulePreceding:
+		return c.roll(t, -1)
+	case RuleModifiedFollowing:
+		next := c.roll(t, 1)
+		if next.Month() != t.Month() {
+			return c.roll(t, -1)
+		}
+		return next
+	default:
+		return t
+	}
+}
+
+func (c *Calendar) roll(t time.Time, step int) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, step)
+	}
+
+	return t
+}
+
+// Entry is a holiday read from a calendar file.
+type Entry struct {
+	Date time.Time
+	Name string
+}
+
+// ParseCSV reads holidays from CSV rows of "YYYY-MM-DD,name". Blank lines and
+// lines starting with # are ignored.
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	entries := []Entry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("calendar: %w", err)
+		}
+
+		date, err := time.Parse(dateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("calendar: line %d: invalid date %q, use YYYY-MM-DD", line, record[0])
+		}
+
+		entries = append(entries, Entry{Date: date, Name: strings.TrimSpace(record[1])})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,57 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(dateLayout, s)
+	return t
+}
+
+func TestCalendar_Adjust(t *testing.T) {
+	// 2026-08-17 is a Monday holiday, 2026-10-31 is a Saturday
+	cal := New([]time.Time{date("2026-08-17")})
+
+	tests := []struct {
+		name string
+		day  string
+		rule string
+		want string
+	}{
+		{"business day unchanged", "2026-08-18", RuleFollowing, "2026-08-18"},
+		{"none keeps weekend", "2026-08-16", RuleNone, "2026-08-16"},
+		{"following skips weekend and holiday", "2026-08-15", RuleFollowing, "2026-08-18"},
+		{"preceding", "2026-08-17", RulePreceding, "2026-08-14"},
+		{"modified following stays in month", "2026-08-16", RuleModifiedFollowing, "2026-08-18"},
+		{"modified following rolls back at month end", "2026-10-31", RuleModifiedFollowing, "2026-10-30"},
+		{"following crosses month end", "2026-10-31", RuleFollowing, "2026-11-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cal.Adjust(date(tt.day), tt.rule).Format(dateLayout))
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		input := "# national holidays\n2026-01-01,Tahun Baru Masehi\n2026-08-17, Hari Kemerdekaan RI\n"
+
+		entries, err := ParseCSV(strings.NewReader(input))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "Hari Kemerdekaan RI", entries[1].Name)
+		assert.Equal(t, "2026-08-17", entries[1].Date.Format(dateLayout))
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		_, err := ParseCSV(strings.NewReader("17-08-2026,Hari Kemerdekaan RI\n"))
+		assert.Error(t, err)
+	})
+}
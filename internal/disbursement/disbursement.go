@@ -0,0 +1,106 @@
+// Package disbursement sends the money of a booked facility to the customer
+// through a bank.
+package disbursement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrRejected is wrapped by the errors of transfers the bank refused for
+// good, e.g. a closed account. Retrying them cannot succeed.
+var ErrRejected = errors.New("disbursement: transfer rejected by the bank")
+
+// ErrNotFound is wrapped by the errors of status lookups for a reference the
+// bank never received, so the transfer was not sent.
+var ErrNotFound = errors.New("disbursement: transfer not found at the bank")
+
+// Transfer is a payout to a customer. Reference is unique per disbursement.
+type Transfer struct {
+	Reference string
+	UserID    int64
+	Amount    decimal.Decimal
+}
+
+// Disburser sends transfers. A transfer with a reference that was already
+// sent returns the original bank reference instead of sending it twice, so a
+// transfer can be retried when it is unknown whether the last try went
+// through. An error wrapping ErrRejected is permanent, any other error may
+// succeed on a retry.
+//
+// Status looks up the transfer sent with a reference and returns its bank
+// reference once it went through. An error wrapping ErrNotFound means the bank
+// never received it, any other error leaves the outcome unknown.
+type Disburser interface {
+	Disburse(ctx context.Context, transfer Transfer) (string, error)
+	Status(ctx context.Context, reference string) (string, error)
+}
+
+// FakeBank is an in-memory Disburser for local runs and tests. It accepts
+// every transfer up to MaxAmount, or every transfer when MaxAmount is zero,
+// and rejects larger ones.
+type FakeBank struct {
+	mu        sync.Mutex
+	maxAmount decimal.Decimal
+	transfers map[string]string
+}
+
+func NewFakeBank(maxAmount decimal.Decimal) *FakeBank {
+	return &FakeBank{maxAmount: maxAmount, transfers: map[string]string{}}
+}
+
+func (b *FakeBank) Disburse(ctx context.Context, transfer Transfer) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ref, ok := b.transfers[transfer.Reference]; ok {
+		return ref, nil
+	}
+
+	if !transfer.Amount.IsPositive() {
+		return "", fmt.Errorf("%w: amount must be greater than 0", ErrRejected)
+	}
+	if b.maxAmount.IsPositive() && transfer.Amount.GreaterThan(b.maxAmount) {
+		return "", fmt.Errorf("%w: amount %s is over the transfer limit", ErrRejected, transfer.Amount)
+	}
+
+	ref := fmt.Sprintf("FAKE-%06d", len(b.transfers)+1)
+	b.transfers[transfer.Reference] = ref
+
+	return ref, nil
+}
+
+func (b *FakeBank) Status(ctx context.Context, reference string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ref, ok := b.transfers[reference]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, reference)
+	}
+
+	return ref, nil
+}
+
+// Backoff spaces out the retries of a failed transfer, or of any other
+// delivery tried again on a schedule: Base after the first attempt, doubling with every attempt after that, capped at Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns how long to wait after the given attempt, counted from 1,
+// before trying again.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+
+	return min(delay, b.Max)
+}
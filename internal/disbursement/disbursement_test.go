@@ -0,0 +1,53 @@
+package disbursement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeBank(t *testing.T) {
+	ctx := context.Background()
+	bank := NewFakeBank(decimal.NewFromInt(50000000))
+
+	ref, err := bank.Disburse(ctx, Transfer{Reference: "disbursement-1", UserID: 1, Amount: decimal.NewFromInt(10000000)})
+	assert.NoError(t, err)
+	assert.Equal(t, "FAKE-000001", ref)
+
+	// a retry of a transfer that went through is not sent again
+	again, err := bank.Disburse(ctx, Transfer{Reference: "disbursement-1", UserID: 1, Amount: decimal.NewFromInt(10000000)})
+	assert.NoError(t, err)
+	assert.Equal(t, ref, again)
+
+	_, err = bank.Disburse(ctx, Transfer{Reference: "disbursement-2", UserID: 1, Amount: decimal.NewFromInt(60000000)})
+	assert.ErrorIs(t, err, ErrRejected)
+
+	ref, err = bank.Disburse(ctx, Transfer{Reference: "disbursement-3", UserID: 2, Amount: decimal.NewFromInt(50000000)})
+	assert.NoError(t, err)
+	assert.Equal(t, "FAKE-000002", ref)
+
+	ref, err = bank.Status(ctx, "disbursement-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "FAKE-000001", ref)
+
+	_, err = bank.Status(ctx, "disbursement-2")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Base: 30 * time.Second, Max: 10 * time.Minute}
+
+	for attempt, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		5:  8 * time.Minute,
+		6:  10 * time.Minute,
+		40: 10 * time.Minute,
+	} {
+		assert.Equal(t, want, backoff.Delay(attempt), "attempt %d", attempt)
+	}
+}
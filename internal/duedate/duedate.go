@@ -0,0 +1,42 @@
+package duedate
+
+import "time"
+
+const (
+	// MaxBillingDay is the latest billing day a customer can pick, so the
+	// billing day exists in every month including February.
+	MaxBillingDay = 28
+	// MaxGracePeriod is the longest first-payment grace period, in months.
+	MaxGracePeriod = 3
+)
+
+// Generate returns the due date of each of the tenor installments of a
+// facility starting on start. Installment i falls i+gracePeriod months after
+// the start month, on billingDay when it is set or on the start day
+// otherwise. Days past the end of a short month are clamped to its last day,
+// so a Jan 31 start is due on Feb 28, Mar 31, Apr 30 instead of drifting.
+func Generate(start time.Time, tenor, billingDay, gracePeriod int) []time.Time {
+	day := billingDay
+	if day == 0 {
+		day = start.Day()
+	}
+
+	dates := make([]time.Time, 0, tenor)
+	for i := 1; i <= tenor; i++ {
+		dates = append(dates, monthDay(start, i+gracePeriod, day))
+	}
+
+	return dates
+}
+
+// monthDay returns the given day of the month that is months after t's
+// month, clamped to the last day of that month.
+func monthDay(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,47 @@
+package duedate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func formatDates(dates []time.Time) []string {
+	result := []string{}
+	for _, d := range dates {
+		result = append(result, d.Format("2006-01-02"))
+	}
+
+	return result
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("clamp to month end", func(t *testing.T) {
+		start := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+		dates := Generate(start, 4, 0, 0)
+		assert.Equal(t, []string{"2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"}, formatDates(dates))
+	})
+
+	t.Run("leap year", func(t *testing.T) {
+		start := time.Date(2027, 12, 30, 0, 0, 0, 0, time.UTC)
+
+		dates := Generate(start, 3, 0, 0)
+		assert.Equal(t, []string{"2028-01-30", "2028-02-29", "2028-03-30"}, formatDates(dates))
+	})
+
+	t.Run("billing day", func(t *testing.T) {
+		start := time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC)
+
+		dates := Generate(start, 3, 5, 0)
+		assert.Equal(t, []string{"2026-12-05", "2027-01-05", "2027-02-05"}, formatDates(dates))
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		start := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+		dates := Generate(start, 2, 25, 2)
+		assert.Equal(t, []string{"2026-04-25", "2026-05-25"}, formatDates(dates))
+	})
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateApplication godoc
+// @Summary      Create Financing Application
+// @Description  Draft a financing application. Nothing is held on the limit until it is submitted. A retry with the same Idempotency-Key and body returns the original draft instead of creating a second one.
+// @Tags         Applications
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key  header  string  false  "Unique key of this request, at most 255 characters"
+// @Param        request body      model.ApplicationRequest  true "Application Request"
+// @Success      201     {object}  model.ApplicationResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      422     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /applications [post]
+func (h *Handler) CreateApplication(c *gin.Context) {
+	var req model.ApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	resp, err := h.service.CreateApplication(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListApplications godoc
+// @Summary      List Financing Applications
+// @Description  List financing applications oldest first. Customers only see their own applications.
+// @Tags         Applications
+// @Accept       json
+// @Produce      json
+// @Param        user_id  query     int     false  "User ID"
+// @Param        status   query     string  false  "Application status"  Enums(draft, submitted, under_review, approved, rejected, cancelled, disbursed)
+// @Param        after    query     int     false  "Only applications after this application ID"
+// @Param        limit    query     int     false  "Page size, max 100"
+// @Success      200      {object}  model.ListApplicationsResponse
+// @Failure      400      {object}  model.ErrorResponse
+// @Failure      401      {object}  model.ErrorResponse
+// @Failure      500      {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /applications [get]
+func (h *Handler) ListApplications(c *gin.Context) {
+	var req model.ListApplicationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.ListApplications(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetApplication godoc
+// @Summary      Get Financing Application
+// @Description  Get a financing application with the time of each transition
+// @Tags         Applications
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Application ID"
+// @Success      200  {object}  model.ApplicationResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /applications/{id} [get]
+func (h *Handler) GetApplication(c *gin.Context) {
+	h.applicationByID(c, h.service.GetApplication)
+}
+
+// SubmitApplication godoc
+// @Summary      Submit Financing Application
+// @Description  Send a draft application for review and hold its amount on the limit. A retry with the same Idempotency-Key returns the original response instead of a conflict.
+// @Tags         Applications
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key  header  string  false  "Unique key of this request, at most 255 characters"
+// @Param        id   path      int  true  "Application ID"
+// @Success      200  {object}  model.ApplicationResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Failure      422  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /applications/{id}/submit [post]
+func (h *Handler) SubmitApplication(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	h.applicationByID(c, func(ctx context.Context, id int) (*model.ApplicationResponse, error) {
+		return h.service.SubmitApplication(ctx, id, key)
+	})
+}
+
+// CancelApplication godoc
+// @Summary      Cancel Financing Application
+// @Description  Withdraw an application that has not been decided yet and give back the amount it held on the limit
+// @Tags         Applications
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Application ID"
+// @Success      200  {object}  model.ApplicationResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /applications/{id}/cancel [post]
+func (h *Handler) CancelApplication(c *gin.Context) {
+	h.applicationByID(c, h.service.CancelApplication)
+}
+
+// StartReview godoc
+// @Summary      Start Application Review
+// @Description  Pick a submitted application up for review, recorded as reviewed by the subject of the token
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                             true "Application ID"
+// @Param        request body      model.ReviewApplicationRequest  false "Review Request"
+// @Success      200     {object}  model.ApplicationResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/applications/{id}/review [post]
+func (h *Handler) StartReview(c *gin.Context) {
+	h.review(c, h.service.StartReview)
+}
+
+// ApproveApplication godoc
+// @Summary      Approve Financing Application
+// @Description  Approve an application under review and book its facility, drawing down the held amount
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                             true "Application ID"
+// @Param        request body      model.ReviewApplicationRequest  false "Review Request"
+// @Success      200     {object}  model.ApplicationResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/applications/{id}/approve [post]
+func (h *Handler) ApproveApplication(c *gin.Context) {
+	h.review(c, h.service.ApproveApplication)
+}
+
+// RejectApplication godoc
+// @Summary      Reject Financing Application
+// @Description  Reject an application under review and give back the amount it held on the limit
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                             true "Application ID"
+// @Param        request body      model.ReviewApplicationRequest  false "Review Request"
+// @Success      200     {object}  model.ApplicationResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/applications/{id}/reject [post]
+func (h *Handler) RejectApplication(c *gin.Context) {
+	h.review(c, h.service.RejectApplication)
+}
+
+func (h *Handler) applicationByID(c *gin.Context, fn func(ctx context.Context, id int) (*model.ApplicationResponse, error)) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) review(c *gin.Context, fn func(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error)) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.ReviewApplicationRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
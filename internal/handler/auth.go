@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"finance/internal/auth"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate rejects requests without a valid bearer token and puts the
+// principal of the token on the request context for the handlers and
+// services behind it.
+func Authenticate(verifier *auth.Verifier, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			errorx.SendError(c, log.Logger, errorx.NewError(errorx.ErrTypeUnauthorized, "missing bearer token", nil))
+			c.Abort()
+			return
+		}
+
+		principal, err := verifier.Verify(token, time.Now())
+		if err != nil {
+			msg := "invalid bearer token"
+			if errors.Is(err, auth.ErrExpiredToken) {
+				msg = "bearer token is expired"
+			}
+			errorx.SendError(c, log.Logger, errorx.NewError(errorx.ErrTypeUnauthorized, msg, err))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireRole only lets principals with the role through.
+func RequireRole(role string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if !ok || principal.Role != role {
+			errorx.SendError(c, log.Logger, errorx.NewError(errorx.ErrTypeForbidden, "requires the "+role+" role", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSelf only lets a customer through when the user ID in the path
+// parameter is their own. Admins may act on every user.
+func RequireSelf(param string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := paramID(c, param)
+		if err != nil {
+			errorx.SendError(c, log.Logger, err)
+			c.Abort()
+			return
+		}
+
+		if !auth.CanAccess(c.Request.Context(), int64(id)) {
+			errorx.SendError(c, log.Logger, errorx.NewError(errorx.ErrTypeForbidden, "not allowed to access this user", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
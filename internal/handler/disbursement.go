@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DisbursementHandler struct {
+	service services.DisbursementService
+	log     *logger.Logger
+}
+
+func NewDisbursementHandler(service services.DisbursementService, log *logger.Logger) *DisbursementHandler {
+	return &DisbursementHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// List godoc
+// @Summary      List Disbursements
+// @Description  List the transfers of booked facilities to their customers, oldest first. A failed disbursement cancelled its facility, one in manual_review ran out of attempts without the bank knowing whether it was sent.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        status  query     string  false  "Disbursement status"  Enums(pending, succeeded, failed, manual_review)
+// @Success      200     {array}   model.DisbursementResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/disbursements [get]
+func (h *DisbursementHandler) List(c *gin.Context) {
+	var req model.ListDisbursementsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Retry godoc
+// @Summary      Retry Disbursement
+// @Description  Put a disbursement waiting in manual review back in the queue with a fresh round of attempts. The bank sends a transfer reference only once, so a transfer that did go through is settled instead of sent again.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Disbursement ID"
+// @Success      200  {object}  model.DisbursementResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/disbursements/{id}/retry [post]
+func (h *DisbursementHandler) Retry(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.Retry(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
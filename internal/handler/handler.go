@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"finance/internal/model"
+	"finance/internal/nik"
+	"finance/internal/phone"
 	"finance/internal/services"
 	"finance/pkg/errorx"
 	"finance/pkg/logger"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -25,15 +30,30 @@ func NewHandler(service services.Service, log *logger.Logger) *Handler {
 
 // ListUserLimit godoc
 // @Summary      Get User Limits
-// @Description  Get User Limits
+// @Description  List users with their limits using cursor pagination, a user without a limit is listed once with has_limit false. Customers only see their own limits.
 // @Tags         Finance
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   model.UserLimit
-// @Failure      500  {object}  model.ErrorResponse
+// @Param        q              query     string  false  "Search by name or phone number"
+// @Param        min_available  query     number  false  "Minimum available limit"
+// @Param        max_available  query     number  false  "Maximum available limit"
+// @Param        cursor         query     string  false  "Cursor from the previous page"
+// @Param        limit          query     int     false  "Page size, max 100"
+// @Success      200            {object}  model.ListUserLimitsResponse
+// @Failure      400            {object}  model.ErrorResponse
+// @Failure      401            {object}  model.ErrorResponse
+// @Failure      500            {object}  model.ErrorResponse
+// @Security     BearerAuth
 // @Router       /limits [get]
 func (h *Handler) ListUserLimit(c *gin.Context) {
-	resp, err := h.service.ListUserLimit(c.Request.Context())
+	var req model.ListUserLimitsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.ListUserLimit(c.Request.Context(), &req)
 	if err != nil {
 		errorx.SendError(c, h.log.Logger, err)
 		return
@@ -47,11 +67,22 @@ func (h *Handler) ListUserLimit(c *gin.Context) {
 // @Tags         Finance
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   model.ListTenor
-// @Failure      500  {object}  model.ErrorResponse
+// @Param        product_id  query     int  false  "Limit product ID, every product when empty"
+// @Success      200         {array}   model.ListTenor
+// @Failure      400         {object}  model.ErrorResponse
+// @Failure      401         {object}  model.ErrorResponse
+// @Failure      500         {object}  model.ErrorResponse
+// @Security     BearerAuth
 // @Router       /tenors [get]
 func (h *Handler) TenorList(c *gin.Context) {
-	resp, err := h.service.TenorList(c.Request.Context())
+	var req model.ListTenorsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.TenorList(c.Request.Context(), &req)
 	if err != nil {
 		errorx.SendError(c, h.log.Logger, err)
 		return
@@ -68,17 +99,19 @@ func (h *Handler) TenorList(c *gin.Context) {
 // @Param        request body      model.CalculateInstallmentsRequest true "Calculation Request"
 // @Success      200     {array}   model.InstallmentSimulation
 // @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
 // @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
 // @Router       /calculate-installments [post]
 func (h *Handler) Installment(c *gin.Context) {
 	var req model.CalculateInstallmentsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		fields := h.handleValidationError(err)
+		fields := handleValidationError(err)
 		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
 		return
 	}
 
-	resp, err := h.service.Installment(c.Request.Context(), req.Amount)
+	resp, err := h.service.Installment(c.Request.Context(), &req)
 	if err != nil {
 		errorx.SendError(c, h.log.Logger, err)
 		return
@@ -88,24 +121,31 @@ func (h *Handler) Installment(c *gin.Context) {
 
 // Submit godoc
 // @Summary      Submit Finance
-// @Description  Submit Finance
+// @Description  Submit Finance. Books the facility at once without the review of a financing application, so it is kept to admins for internal bookings and migrations; customers apply through /applications. A retry with the same Idempotency-Key and body returns the original response instead of booking a second facility.
 // @Tags         Finance
 // @Accept       json
 // @Produce      json
+// @Param        Idempotency-Key  header  string  false  "Unique key of this submission, at most 255 characters"
 // @Param        request body      model.SubmitFinancingRequest true "Submit Request"
 // @Success      200     {object}  model.SubmitFinancingResponse
 // @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
 // @Failure      422     {object}  model.ErrorResponse
 // @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
 // @Router       /submit-financing [post]
 func (h *Handler) Submit(c *gin.Context) {
 	var req model.SubmitFinancingRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		fields := h.handleValidationError(err)
+		fields := handleValidationError(err)
 		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
 		return
 	}
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
 
 	resp, err := h.service.Submit(c.Request.Context(), &req)
 	if err != nil {
@@ -116,7 +156,217 @@ func (h *Handler) Submit(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func (h *Handler) handleValidationError(err error) map[string]string {
+// CaptureHold godoc
+// @Summary      Capture Limit Hold
+// @Description  Turn an active hold into a submitted application once the merchant accepts the order. The held amount stays taken off the limit until the application is reviewed.
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                       true "Limit Hold ID"
+// @Param        request body      model.CaptureHoldRequest  true "Capture Request"
+// @Success      200     {object}  model.ApplicationResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /limit-holds/{id}/capture [post]
+func (h *Handler) CaptureHold(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.CaptureHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.CaptureHold(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetFacility godoc
+// @Summary      Get Facility
+// @Description  Get facility with its installment schedule
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User Facility ID"
+// @Success      200  {object}  model.FacilityResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities/{id} [get]
+func (h *Handler) GetFacility(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.GetFacility(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RecomputeFacility godoc
+// @Summary      Recompute Facility Pricing
+// @Description  Recompute a facility with its stored pricing inputs and report any drift from the booked figures
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User Facility ID"
+// @Success      200  {object}  model.RecomputeResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities/{id}/recompute [get]
+func (h *Handler) RecomputeFacility(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.RecomputeFacility(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListFacilities godoc
+// @Summary      List Facilities
+// @Description  List all facilities with cursor pagination, filters and sorting
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        status      query     string  false  "Facility status"  Enums(active, paid_off, cancelled)
+// @Param        tenor       query     int     false  "Tenor in months"
+// @Param        start_from  query     string  false  "Start date lower bound (YYYY-MM-DD)"
+// @Param        start_to    query     string  false  "Start date upper bound (YYYY-MM-DD)"
+// @Param        min_amount  query     number  false  "Minimum amount"
+// @Param        max_amount  query     number  false  "Maximum amount"
+// @Param        sort_by     query     string  false  "Sort column"  Enums(created_at, start_date, amount)
+// @Param        sort_order  query     string  false  "Sort order"   Enums(asc, desc)
+// @Param        cursor      query     string  false  "Cursor from the previous page, only valid with the same sort_by and sort_order"
+// @Param        limit       query     int     false  "Page size, max 100"
+// @Success      200         {object}  model.ListFacilitiesResponse
+// @Failure      400         {object}  model.ErrorResponse
+// @Failure      401         {object}  model.ErrorResponse
+// @Failure      500         {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities [get]
+func (h *Handler) ListFacilities(c *gin.Context) {
+	var req model.ListFacilitiesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.ListFacilities(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListUserFacilities godoc
+// @Summary      List User Facilities
+// @Description  List facilities of a user with cursor pagination, filters and sorting
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int     true   "User ID"
+// @Param        status      query     string  false  "Facility status"  Enums(active, paid_off, cancelled)
+// @Param        tenor       query     int     false  "Tenor in months"
+// @Param        start_from  query     string  false  "Start date lower bound (YYYY-MM-DD)"
+// @Param        start_to    query     string  false  "Start date upper bound (YYYY-MM-DD)"
+// @Param        min_amount  query     number  false  "Minimum amount"
+// @Param        max_amount  query     number  false  "Maximum amount"
+// @Param        sort_by     query     string  false  "Sort column"  Enums(created_at, start_date, amount)
+// @Param        sort_order  query     string  false  "Sort order"   Enums(asc, desc)
+// @Param        cursor      query     string  false  "Cursor from the previous page, only valid with the same sort_by and sort_order"
+// @Param        limit       query     int     false  "Page size, max 100"
+// @Success      200         {object}  model.ListFacilitiesResponse
+// @Failure      400         {object}  model.ErrorResponse
+// @Failure      401         {object}  model.ErrorResponse
+// @Failure      403         {object}  model.ErrorResponse
+// @Failure      404         {object}  model.ErrorResponse
+// @Failure      500         {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /users/{id}/facilities [get]
+func (h *Handler) ListUserFacilities(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.ListFacilitiesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+	req.UserID = int64(id)
+
+	resp, err := h.service.ListFacilities(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func paramID(c *gin.Context, name string) (int, error) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		return 0, errorx.NewValidationError(map[string]string{name: "must be a positive integer"})
+	}
+
+	return id, nil
+}
+
+// bindOptionalJSON binds the body of an endpoint that may also be called
+// without one, leaving req at its zero value for an empty body.
+func bindOptionalJSON(c *gin.Context, req any) error {
+	err := c.ShouldBindJSON(req)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+func handleValidationError(err error) map[string]string {
 	result := make(map[string]string)
 	validationErrors, ok := err.(validator.ValidationErrors)
 	if !ok {
@@ -133,12 +383,26 @@ func (h *Handler) handleValidationError(err error) map[string]string {
 			msg = "is required"
 		case "gt":
 			msg = "must be greater than " + e.Param()
+		case "gte":
+			msg = "must be greater than or equal to " + e.Param()
+		case "lte":
+			msg = "must be less than or equal to " + e.Param()
+		case "oneof":
+			msg = "must be one of: " + e.Param()
+		case "numeric":
+			msg = "must be a number"
 		case "notpast":
 			msg = "cannot be in the past"
 		case "datetime":
 			msg = "invalid date format, use YYYY-MM-DD"
 		case "email":
 			msg = "invalid email format"
+		case "max":
+			msg = "must be at most " + e.Param() + " characters long"
+		case "idphone":
+			msg = phone.Hint
+		case "nik":
+			msg = nik.Hint
 		default:
 			msg = "failed validation on tag " + e.Tag()
 		}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	service services.HoldService
+	log     *logger.Logger
+}
+
+func NewHoldHandler(service services.HoldService, log *logger.Logger) *HoldHandler {
+	return &HoldHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Hold godoc
+// @Summary      Hold Limit
+// @Description  Reserve part of a user's limit until the hold is captured, released or expires
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        request body      model.HoldRequest true "Hold Request"
+// @Success      201     {object}  model.HoldResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /limit-holds [post]
+func (h *HoldHandler) Hold(c *gin.Context) {
+	var req model.HoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Hold(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Get godoc
+// @Summary      Get Limit Hold
+// @Description  Get a limit hold and its status
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Limit Hold ID"
+// @Success      200  {object}  model.HoldResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /limit-holds/{id} [get]
+func (h *HoldHandler) Get(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Release godoc
+// @Summary      Release Limit Hold
+// @Description  Give an active hold back to the limit
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Limit Hold ID"
+// @Success      200  {object}  model.HoldResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      409  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /limit-holds/{id}/release [post]
+func (h *HoldHandler) Release(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.Release(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"finance/internal/calendar"
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HolidayHandler struct {
+	service services.HolidayService
+	log     *logger.Logger
+}
+
+func NewHolidayHandler(service services.HolidayService, log *logger.Logger) *HolidayHandler {
+	return &HolidayHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// List godoc
+// @Summary      List Holidays
+// @Description  List the holidays of a year used to move due dates to business days
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        year  query     int  false  "Year, defaults to the current year"
+// @Success      200   {array}   model.HolidayResponse
+// @Failure      400   {object}  model.ErrorResponse
+// @Failure      401   {object}  model.ErrorResponse
+// @Failure      403   {object}  model.ErrorResponse
+// @Failure      500   {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/holidays [get]
+func (h *HolidayHandler) List(c *gin.Context) {
+	var req model.ListHolidaysRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create godoc
+// @Summary      Create Holiday
+// @Description  Add a holiday to the business day calendar
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        request body      model.HolidayRequest true "Holiday Request"
+// @Success      201     {object}  model.HolidayResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/holidays [post]
+func (h *HolidayHandler) Create(c *gin.Context) {
+	var req model.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Import godoc
+// @Summary      Import Holidays
+// @Description  Import holidays from a CSV file of "YYYY-MM-DD,name" rows, existing dates are renamed
+// @Tags         Admin
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "Holiday CSV file"
+// @Success      200   {object}  model.ImportHolidaysResponse
+// @Failure      400   {object}  model.ErrorResponse
+// @Failure      401   {object}  model.ErrorResponse
+// @Failure      403   {object}  model.ErrorResponse
+// @Failure      500   {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/holidays/import [post]
+func (h *HolidayHandler) Import(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(map[string]string{"file": "is required"}))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, errorx.NewError(errorx.ErrTypeValidation, "cannot read uploaded file", err))
+		return
+	}
+	defer file.Close()
+
+	entries, err := calendar.ParseCSV(file)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, errorx.NewError(errorx.ErrTypeValidation, err.Error(), err))
+		return
+	}
+
+	resp, err := h.service.Import(c.Request.Context(), entries)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete godoc
+// @Summary      Delete Holiday
+// @Description  Remove a holiday from the business day calendar
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id   path  int  true  "Holiday ID"
+// @Success      204
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/holidays/{id} [delete]
+func (h *HolidayHandler) Delete(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	err = h.service.Delete(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KYCHandler struct {
+	service services.KYCService
+	log     *logger.Logger
+}
+
+func NewKYCHandler(service services.KYCService, log *logger.Logger) *KYCHandler {
+	return &KYCHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Get godoc
+// @Summary      Get User KYC
+// @Description  Get the identity verification of a user, the NIK is masked
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  model.KYCResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /users/{id}/kyc [get]
+func (h *KYCHandler) Get(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Submit godoc
+// @Summary      Submit User KYC
+// @Description  Submit the NIK of a user for verification, a rejected or expired verification can be submitted again
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int               true "User ID"
+// @Param        request body      model.KYCRequest  true "KYC Request"
+// @Success      201     {object}  model.KYCResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /users/{id}/kyc [post]
+func (h *KYCHandler) Submit(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.KYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Submit(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// List godoc
+// @Summary      List KYC
+// @Description  List identity verifications, oldest submission first
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        status  query     string  false  "KYC status"  Enums(pending, verified, rejected, expired)
+// @Success      200     {array}   model.KYCResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/kyc [get]
+func (h *KYCHandler) List(c *gin.Context) {
+	var req model.ListKYCRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Verify godoc
+// @Summary      Verify KYC
+// @Description  Approve the pending identity verification of a user, recorded as reviewed by the subject of the token
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path      int                     true "User ID"
+// @Param        request  body      model.ReviewKYCRequest  false "Review Request"
+// @Success      200      {object}  model.KYCResponse
+// @Failure      400      {object}  model.ErrorResponse
+// @Failure      401      {object}  model.ErrorResponse
+// @Failure      403      {object}  model.ErrorResponse
+// @Failure      404      {object}  model.ErrorResponse
+// @Failure      409      {object}  model.ErrorResponse
+// @Failure      500      {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/kyc/{user_id}/verify [post]
+func (h *KYCHandler) Verify(c *gin.Context) {
+	h.review(c, h.service.Verify)
+}
+
+// Reject godoc
+// @Summary      Reject KYC
+// @Description  Reject the pending identity verification of a user, a reason is required. Recorded as reviewed by the subject of the token
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path      int                     true "User ID"
+// @Param        request  body      model.ReviewKYCRequest  true "Review Request"
+// @Success      200      {object}  model.KYCResponse
+// @Failure      400      {object}  model.ErrorResponse
+// @Failure      401      {object}  model.ErrorResponse
+// @Failure      403      {object}  model.ErrorResponse
+// @Failure      404      {object}  model.ErrorResponse
+// @Failure      409      {object}  model.ErrorResponse
+// @Failure      500      {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/kyc/{user_id}/reject [post]
+func (h *KYCHandler) Reject(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+func (h *KYCHandler) review(c *gin.Context, fn func(ctx context.Context, userID int, req *model.ReviewKYCRequest) (*model.KYCResponse, error)) {
+	id, err := paramID(c, "user_id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.ReviewKYCRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LimitHandler struct {
+	service services.LimitService
+	log     *logger.Logger
+}
+
+func NewLimitHandler(service services.LimitService, log *logger.Logger) *LimitHandler {
+	return &LimitHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// History godoc
+// @Summary      Limit History
+// @Description  List every movement of a user's limit, newest first, with the cached and ledger-derived balance
+// @Tags         Finance
+// @Accept       json
+// @Produce      json
+// @Param        id                 path      int  true   "User ID"
+// @Param        facility_limit_id  query     int  false  "Facility limit ID, required when the user has more than one limit"
+// @Param        limit              query     int  false  "Page size, max 100"
+// @Param        before             query     int  false  "Only entries older than this entry id"
+// @Success      200                {object}  model.LimitHistoryResponse
+// @Failure      400                {object}  model.ErrorResponse
+// @Failure      401                {object}  model.ErrorResponse
+// @Failure      403                {object}  model.ErrorResponse
+// @Failure      404                {object}  model.ErrorResponse
+// @Failure      500                {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /users/{id}/limit/history [get]
+func (h *LimitHandler) History(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.LimitHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.History(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DueForReview godoc
+// @Summary      Limits Due For Review
+// @Description  List limits whose review date has been reached, including expired ones
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        date  query     string  false  "Review day (YYYY-MM-DD), today when empty"
+// @Success      200   {array}   model.LimitReviewResponse
+// @Failure      400   {object}  model.ErrorResponse
+// @Failure      401   {object}  model.ErrorResponse
+// @Failure      403   {object}  model.ErrorResponse
+// @Failure      500   {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/limits/due-for-review [get]
+func (h *LimitHandler) DueForReview(c *gin.Context) {
+	var req model.LimitReviewRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.DueForReview(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
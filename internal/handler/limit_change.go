@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LimitChangeHandler struct {
+	service services.LimitChangeService
+	log     *logger.Logger
+}
+
+func NewLimitChangeHandler(service services.LimitChangeService, log *logger.Logger) *LimitChangeHandler {
+	return &LimitChangeHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// List godoc
+// @Summary      List Limit Changes
+// @Description  List proposed limit lifecycle changes, oldest first
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        status  query     string  false  "Change status"  Enums(pending, approved, rejected)
+// @Success      200     {array}   model.LimitChangeResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/limit-changes [get]
+func (h *LimitChangeHandler) List(c *gin.Context) {
+	var req model.ListLimitChangesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Propose godoc
+// @Summary      Propose Limit Change
+// @Description  Propose to create, raise, lower, freeze, unfreeze, renew or close a limit, applied only after another operator approves it. The operator is the subject of the token
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        request body      model.LimitChangeRequest true "Limit Change Request"
+// @Success      201     {object}  model.LimitChangeResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/limit-changes [post]
+func (h *LimitChangeHandler) Propose(c *gin.Context) {
+	var req model.LimitChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Propose(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Approve godoc
+// @Summary      Approve Limit Change
+// @Description  Approve a pending limit change and apply it to the limit, the token must belong to another operator than the one who proposed it
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                             true "Limit Change ID"
+// @Param        request body      model.ReviewLimitChangeRequest  false "Review Request"
+// @Success      200     {object}  model.LimitChangeResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/limit-changes/{id}/approve [post]
+func (h *LimitChangeHandler) Approve(c *gin.Context) {
+	h.review(c, h.service.Approve)
+}
+
+// Reject godoc
+// @Summary      Reject Limit Change
+// @Description  Reject a pending limit change without applying it, the token must belong to another operator than the one who proposed it
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                             true "Limit Change ID"
+// @Param        request body      model.ReviewLimitChangeRequest  false "Review Request"
+// @Success      200     {object}  model.LimitChangeResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/limit-changes/{id}/reject [post]
+func (h *LimitChangeHandler) Reject(c *gin.Context) {
+	h.review(c, h.service.Reject)
+}
+
+func (h *LimitChangeHandler) review(c *gin.Context, fn func(ctx context.Context, id int, req *model.ReviewLimitChangeRequest) (*model.LimitChangeResponse, error)) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.ReviewLimitChangeRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	service services.PaymentService
+	log     *logger.Logger
+}
+
+func NewPaymentHandler(service services.PaymentService, log *logger.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Pay godoc
+// @Summary      Pay Installment
+// @Description  Pay the oldest unpaid installments of a facility and replenish the user limit
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                   true "User Facility ID"
+// @Param        request body      model.PaymentRequest  true "Payment Request"
+// @Success      200     {object}  model.PaymentResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities/{id}/payments [post]
+func (h *PaymentHandler) Pay(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.PaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Pay(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// QuotePayoff godoc
+// @Summary      Quote Early Settlement
+// @Description  Quote the amount needed to settle a facility early, valid until the quote expiry
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "User Facility ID"
+// @Success      200  {object}  model.PayoffQuoteResponse
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities/{id}/payoff-quotes [post]
+func (h *PaymentHandler) QuotePayoff(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	resp, err := h.service.QuotePayoff(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Payoff godoc
+// @Summary      Execute Early Settlement
+// @Description  Settle all open installments of a facility using a payoff quote and restore the limit
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                  true "User Facility ID"
+// @Param        request body      model.PayoffRequest  true "Payoff Request"
+// @Success      200     {object}  model.PaymentResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /facilities/{id}/payoff [post]
+func (h *PaymentHandler) Payoff(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.PayoffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Payoff(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductHandler struct {
+	service services.ProductService
+	log     *logger.Logger
+}
+
+func NewProductHandler(service services.ProductService, log *logger.Logger) *ProductHandler {
+	return &ProductHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// List godoc
+// @Summary      List Limit Products
+// @Description  List the limit products a user can hold a limit for
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   model.ProductResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/products [get]
+func (h *ProductHandler) List(c *gin.Context) {
+	resp, err := h.service.List(c.Request.Context())
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create godoc
+// @Summary      Create Limit Product
+// @Description  Create a limit product, an empty pricing_method uses the configured default
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        request body      model.ProductRequest true "Product Request"
+// @Success      201     {object}  model.ProductResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/products [post]
+func (h *ProductHandler) Create(c *gin.Context) {
+	var req model.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// SetPricingMethod godoc
+// @Summary      Set Product Pricing Method
+// @Description  Select the pricing method new quotes and bookings of a limit product use, an empty pricing_method uses the configured default. Booked facilities keep their own method.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                          true "Product ID"
+// @Param        request body      model.ProductPricingRequest  true "Pricing Request"
+// @Success      200     {object}  model.ProductResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/products/{id}/pricing-method [put]
+func (h *ProductHandler) SetPricingMethod(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.ProductPricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.SetPricingMethod(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenorHandler struct {
+	service services.TenorService
+	log     *logger.Logger
+}
+
+func NewTenorHandler(service services.TenorService, log *logger.Logger) *TenorHandler {
+	return &TenorHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// List godoc
+// @Summary      List Tenor Rates
+// @Description  List every tenor rate including past and future effective periods
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   model.TenorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/tenors [get]
+func (h *TenorHandler) List(c *gin.Context) {
+	resp, err := h.service.List(c.Request.Context())
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create godoc
+// @Summary      Create Tenor Rate
+// @Description  Create a tenor rate for an effective period
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        request body      model.TenorRequest true "Tenor Request"
+// @Success      201     {object}  model.TenorResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/tenors [post]
+func (h *TenorHandler) Create(c *gin.Context) {
+	var req model.TenorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Update godoc
+// @Summary      Update Tenor Rate
+// @Description  Update a tenor rate, rates already in effect can only change effective_to
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                 true "Tenor ID"
+// @Param        request body      model.TenorRequest  true "Tenor Request"
+// @Success      200     {object}  model.TenorResponse
+// @Failure      400     {object}  model.ErrorResponse
+// @Failure      401     {object}  model.ErrorResponse
+// @Failure      403     {object}  model.ErrorResponse
+// @Failure      404     {object}  model.ErrorResponse
+// @Failure      409     {object}  model.ErrorResponse
+// @Failure      500     {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/tenors/{id} [put]
+func (h *TenorHandler) Update(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	var req model.TenorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fields := handleValidationError(err)
+		errorx.SendError(c, h.log.Logger, errorx.NewValidationError(fields))
+		return
+	}
+
+	resp, err := h.service.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete godoc
+// @Summary      Delete Tenor Rate
+// @Description  Delete a tenor rate that is not yet in effect
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        id   path  int  true  "Tenor ID"
+// @Success      204
+// @Failure      400  {object}  model.ErrorResponse
+// @Failure      401  {object}  model.ErrorResponse
+// @Failure      403  {object}  model.ErrorResponse
+// @Failure      404  {object}  model.ErrorResponse
+// @Failure      500  {object}  model.ErrorResponse
+// @Security     BearerAuth
+// @Router       /admin/tenors/{id} [delete]
+func (h *TenorHandler) Delete(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	err = h.service.Delete(c.Request.Context(), id)
+	if err != nil {
+		errorx.SendError(c, h.log.Logger, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
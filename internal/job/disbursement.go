@@ -0,0 +1,27 @@
+package job
+
+import (
+	"context"
+	"finance/internal/services"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Disburse sends the money of booked facilities to their customers, retrying
+// the transfers that failed and cancelling the facilities that never can be
+// paid out.
+func Disburse(svc services.DisbursementService, log *logger.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		completed, err := svc.ProcessDue(ctx, time.Now())
+		if err != nil {
+			log.Error("disbursement run failed", zap.Error(err))
+			return
+		}
+
+		if completed > 0 {
+			log.Info("disbursement run finished", zap.Int("completed", completed))
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package job
+
+import (
+	"context"
+	"finance/internal/services"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ExpireHolds gives back the limit holds that passed their expiry without
+// being captured or released.
+func ExpireHolds(svc services.HoldService, log *logger.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		expired, err := svc.ExpireHolds(ctx, time.Now())
+		if err != nil {
+			log.Error("limit hold sweep failed", zap.Error(err))
+			return
+		}
+
+		if expired > 0 {
+			log.Info("limit hold sweep finished", zap.Int("expired", expired))
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package job runs periodic background work next to the HTTP server.
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// Every runs fn once right away and then on every tick of interval until ctx
+// is cancelled. Runs never overlap: a slow run delays the next one.
+func Every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		Every(ctx, time.Millisecond, func(ctx context.Context) {
+			if runs.Add(1) == 3 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after the context was cancelled")
+	}
+	assert.Equal(t, int32(3), runs.Load())
+}
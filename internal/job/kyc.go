@@ -0,0 +1,26 @@
+package job
+
+import (
+	"context"
+	"finance/internal/services"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ExpireKYC marks the identity verifications that passed their expiry, so
+// the users have to submit them again before drawing on their limits.
+func ExpireKYC(svc services.KYCService, log *logger.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		expired, err := svc.Expire(ctx, time.Now())
+		if err != nil {
+			log.Error("kyc expiry job failed", zap.Error(err))
+			return
+		}
+
+		if expired > 0 {
+			log.Info("kyc expiry job finished", zap.Int64("expired", expired))
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package job
+
+import (
+	"context"
+	"finance/internal/services"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RelayOutbox delivers the domain events written to the outbox downstream.
+func RelayOutbox(svc services.OutboxService, log *logger.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		published, err := svc.Relay(ctx, time.Now())
+		if err != nil {
+			log.Error("outbox relay failed", zap.Error(err))
+			return
+		}
+
+		if published > 0 {
+			log.Debug("outbox relay finished", zap.Int("published", published))
+		}
+	}
+}
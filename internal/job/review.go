@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/services"
+	"finance/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// LimitReview logs every limit whose review date has been reached, so credit
+// operations can pick them up before or right after they expire.
+func LimitReview(svc services.LimitService, log *logger.Logger) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		limits, err := svc.DueForReview(ctx, &model.LimitReviewRequest{})
+		if err != nil {
+			log.Error("limit review job failed", zap.Error(err))
+			return
+		}
+
+		for _, limit := range limits {
+			log.Warn("limit due for review",
+				zap.Int64("facility_limit_id", limit.FacilityLimitID),
+				zap.Int64("user_id", limit.UserID),
+				zap.String("next_review_date", limit.NextReviewDate),
+				zap.String("valid_until", limit.ValidUntil),
+				zap.Bool("expired", limit.Expired))
+		}
+		log.Info("limit review job finished", zap.Int("due", len(limits)))
+	}
+}
@@ -6,21 +6,324 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+
+	KYCStatusPending  = "pending"
+	KYCStatusVerified = "verified"
+	KYCStatusRejected = "rejected"
+	KYCStatusExpired  = "expired"
+
+	DetailStatusUnpaid = "unpaid"
+	DetailStatusPaid   = "paid"
+
+	FacilityStatusActive  = "active"
+	FacilityStatusPaidOff = "paid_off"
+	// FacilityStatusCancelled is a facility whose money could not be
+	// disbursed. Its drawdown was reversed and it cannot be paid.
+	FacilityStatusCancelled = "cancelled"
+
+	QuoteStatusOpen     = "open"
+	QuoteStatusExecuted = "executed"
+
+	RebatePolicyNone     = "none"
+	RebatePolicyProRata  = "pro-rata"
+	RebatePolicyRuleOf78 = "rule-of-78"
+
+	LedgerDrawdown   = "drawdown"
+	LedgerRepayment  = "repayment"
+	LedgerAdjustment = "adjustment"
+	LedgerReversal   = "reversal"
+	LedgerHold       = "hold"
+	LedgerRelease    = "release"
+
+	ReferenceFacility    = "user_facility"
+	ReferencePayment     = "payment"
+	ReferenceLimitChange = "limit_change"
+	ReferenceLimitHold   = "limit_hold"
+	ReferenceApplication = "financing_application"
+
+	LimitStatusActive = "active"
+	LimitStatusFrozen = "frozen"
+	LimitStatusClosed = "closed"
+
+	LimitActionCreate   = "create"
+	LimitActionRaise    = "raise"
+	LimitActionLower    = "lower"
+	LimitActionFreeze   = "freeze"
+	LimitActionUnfreeze = "unfreeze"
+	LimitActionRenew    = "renew"
+	LimitActionClose    = "close"
+
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+
+	ApplicationStatusDraft       = "draft"
+	ApplicationStatusSubmitted   = "submitted"
+	ApplicationStatusUnderReview = "under_review"
+	ApplicationStatusApproved    = "approved"
+	ApplicationStatusRejected    = "rejected"
+	ApplicationStatusCancelled   = "cancelled"
+	ApplicationStatusDisbursed   = "disbursed"
+
+	DisbursementStatusPending      = "pending"
+	DisbursementStatusSucceeded    = "succeeded"
+	DisbursementStatusFailed       = "failed"
+	DisbursementStatusManualReview = "manual_review"
+
+	AggregateFacility = "facility"
+
+	EventFacilityCreated = "facility.created"
+	EventFacilityPaid    = "facility.paid"
+	EventFacilityClosed  = "facility.closed"
+)
+
+// User is a customer. Phone is stored in E.164 form and is unique.
 type User struct {
-	UserID int64  `json:"user_id" db:"id"`
-	Name   string `json:"name" db:"name"`
-	Phone  string `json:"phone" db:"phone"`
+	UserID    int64      `json:"user_id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Phone     string     `json:"phone" db:"phone"`
+	Status    string     `json:"status" db:"status"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// KYC is the identity verification of a user. A user has at most one; a
+// rejected or expired verification is resubmitted in place. ExpiresAt is set
+// once it is verified.
+type KYC struct {
+	KYCID        int64      `json:"kyc_id" db:"id"`
+	UserID       int64      `json:"user_id" db:"user_id"`
+	NIK          string     `json:"nik" db:"nik"`
+	FullName     string     `json:"full_name" db:"full_name"`
+	BirthDate    time.Time  `json:"birth_date" db:"birth_date"`
+	Status       string     `json:"status" db:"status"`
+	RejectReason string     `json:"reject_reason" db:"reject_reason"`
+	SubmittedAt  time.Time  `json:"submitted_at" db:"submitted_at"`
+	ReviewedBy   *string    `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at" db:"reviewed_at"`
+	ExpiresAt    *time.Time `json:"expires_at" db:"expires_at"`
 }
 
 type UserFacilityLimit struct {
 	FacilityLimitID int64           `json:"facility_limit_id" db:"id"`
 	UserID          int64           `json:"user_id" db:"user_id"`
+	ProductID       int64           `json:"product_id" db:"product_id"`
 	LimitAmount     decimal.Decimal `json:"limit_amount" db:"limit_amount"`
+	Status          string          `json:"status" db:"status"`
+	ValidFrom       time.Time       `json:"valid_from" db:"valid_from"`
+	ValidUntil      time.Time       `json:"valid_until" db:"valid_until"`
+	NextReviewDate  time.Time       `json:"next_review_date" db:"next_review_date"`
+}
+
+// LimitChange is a lifecycle change of a limit proposed by one operator. It
+// is only applied once a different operator approves it. FacilityLimitID is
+// nil for a create until it is approved.
+type LimitChange struct {
+	ChangeID        int64            `json:"change_id" db:"id"`
+	Action          string           `json:"action" db:"action"`
+	UserID          int64            `json:"user_id" db:"user_id"`
+	ProductID       int64            `json:"product_id" db:"product_id"`
+	FacilityLimitID *int64           `json:"facility_limit_id" db:"facility_limit_id"`
+	Amount          *decimal.Decimal `json:"amount" db:"amount"`
+	Reason          string           `json:"reason" db:"reason"`
+	Status          string           `json:"status" db:"status"`
+	RequestedBy     string           `json:"requested_by" db:"requested_by"`
+	ReviewedBy      *string          `json:"reviewed_by" db:"reviewed_by"`
+	ReviewNote      string           `json:"review_note" db:"review_note"`
+	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
+	ReviewedAt      *time.Time       `json:"reviewed_at" db:"reviewed_at"`
+}
+
+// IdempotencyRecord is the outcome of a request made with an idempotency key:
+// a submission, which booked UserFacilityID, or a request to the application
+// endpoints, which created or submitted ApplicationID. A retry with the same
+// key and body gets Response back instead of making the change again.
+type IdempotencyRecord struct {
+	ID             int64     `json:"id" db:"id"`
+	UserID         int64     `json:"user_id" db:"user_id"`
+	Key            string    `json:"key" db:"key"`
+	RequestHash    string    `json:"request_hash" db:"request_hash"`
+	Response       []byte    `json:"-" db:"response"`
+	UserFacilityID *int64    `json:"user_facility_id" db:"user_facility_id"`
+	ApplicationID  *int64    `json:"application_id" db:"application_id"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// LimitHold reserves part of a limit while a checkout waits for the merchant.
+// The amount is taken off the limit balance when the hold is placed and given
+// back when it is released or expires. A captured hold turns into the
+// submitted application ApplicationID, which keeps the amount held until it
+// is reviewed. Holds captured before applications existed were drawn down
+// straight into UserFacilityID.
+type LimitHold struct {
+	HoldID          int64           `json:"hold_id" db:"id"`
+	FacilityLimitID int64           `json:"facility_limit_id" db:"facility_limit_id"`
+	UserID          int64           `json:"user_id" db:"user_id"`
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	Reference       string          `json:"reference" db:"reference"`
+	Status          string          `json:"status" db:"status"`
+	UserFacilityID  *int64          `json:"user_facility_id" db:"user_facility_id"`
+	ApplicationID   *int64          `json:"application_id" db:"application_id"`
+	ExpiresAt       time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	ResolvedAt      *time.Time      `json:"resolved_at" db:"resolved_at"`
+}
+
+// FinancingApplication is a request for a facility that goes through review
+// before it is booked. The amount is held on the limit while it is submitted
+// or under review, and only drawn down when it is approved into
+// UserFacilityID. A nil StartDate starts the facility on the approval date.
+// Each transition stamps its own timestamp.
+type FinancingApplication struct {
+	ApplicationID   int64           `json:"application_id" db:"id"`
+	UserID          int64           `json:"user_id" db:"user_id"`
+	FacilityLimitID int64           `json:"facility_limit_id" db:"facility_limit_id"`
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	Tenor           int             `json:"tenor" db:"tenor"`
+	StartDate       *time.Time      `json:"start_date" db:"start_date"`
+	BillingDay      int             `json:"billing_day" db:"billing_day"`
+	GracePeriod     int             `json:"grace_period" db:"grace_period"`
+	Status          string          `json:"status" db:"status"`
+	UserFacilityID  *int64          `json:"user_facility_id" db:"user_facility_id"`
+	ReviewedBy      *string         `json:"reviewed_by" db:"reviewed_by"`
+	ReviewNote      string          `json:"review_note" db:"review_note"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	SubmittedAt     *time.Time      `json:"submitted_at" db:"submitted_at"`
+	ReviewStartedAt *time.Time      `json:"review_started_at" db:"review_started_at"`
+	ApprovedAt      *time.Time      `json:"approved_at" db:"approved_at"`
+	RejectedAt      *time.Time      `json:"rejected_at" db:"rejected_at"`
+	CancelledAt     *time.Time      `json:"cancelled_at" db:"cancelled_at"`
+	DisbursedAt     *time.Time      `json:"disbursed_at" db:"disbursed_at"`
+}
+
+// Disbursement sends the amount of a facility to the customer. It is created
+// pending in the transaction that books the facility and retried with
+// backoff from NextAttemptAt until the bank accepts it, or it fails for good
+// and the facility is cancelled. One whose outcome the bank cannot tell after
+// the last attempt waits in manual review.
+type Disbursement struct {
+	DisbursementID int64           `json:"disbursement_id" db:"id"`
+	UserFacilityID int64           `json:"user_facility_id" db:"user_facility_id"`
+	UserID         int64           `json:"user_id" db:"user_id"`
+	Amount         decimal.Decimal `json:"amount" db:"amount"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      string          `json:"last_error" db:"last_error"`
+	BankReference  *string         `json:"bank_reference" db:"bank_reference"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at" db:"completed_at"`
+}
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes and relayed downstream after that transaction commits. It stays
+// unpublished until the publisher accepts it, so it is delivered at least
+// once; EventID lets consumers drop repeats. One the publisher keeps failing
+// is given up on and stamped with FailedAt, and holds back the later events
+// of its aggregate.
+type OutboxEvent struct {
+	ID            int64      `json:"id" db:"id"`
+	EventID       string     `json:"event_id" db:"event_id"`
+	EventType     string     `json:"event_type" db:"event_type"`
+	AggregateType string     `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   int64      `json:"aggregate_id" db:"aggregate_id"`
+	Payload       []byte     `json:"-" db:"payload"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string     `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time `json:"published_at" db:"published_at"`
+	FailedAt      *time.Time `json:"failed_at" db:"failed_at"`
+}
+
+// FacilityCreatedEvent is the payload of EventFacilityCreated.
+type FacilityCreatedEvent struct {
+	UserFacilityID     int64           `json:"user_facility_id"`
+	UserID             int64           `json:"user_id"`
+	FacilityLimitID    int64           `json:"facility_limit_id"`
+	Amount             decimal.Decimal `json:"amount"`
+	Tenor              int             `json:"tenor"`
+	StartDate          string          `json:"start_date"`
+	MonthlyInstallment decimal.Decimal `json:"monthly_installment"`
+	TotalPayment       decimal.Decimal `json:"total_payment"`
+}
+
+// FacilityPaidEvent is the payload of EventFacilityPaid, one per payment.
+type FacilityPaidEvent struct {
+	UserFacilityID  int64           `json:"user_facility_id"`
+	PaymentID       int64           `json:"payment_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	PrincipalAmount decimal.Decimal `json:"principal_amount"`
+	PaidAt          string          `json:"paid_at"`
+}
+
+// FacilityClosedEvent is the payload of EventFacilityClosed, sent when a
+// facility is paid off or cancelled.
+type FacilityClosedEvent struct {
+	UserFacilityID int64  `json:"user_facility_id"`
+	Status         string `json:"status"`
+	ClosedAt       string `json:"closed_at"`
+}
+
+// ApplicationFilter narrows the application listing. Zero values are not
+// filtered on; AfterID pages through the applications oldest first.
+type ApplicationFilter struct {
+	UserID  int64
+	Status  string
+	AfterID int64
+	Limit   int
+}
+
+// LimitProduct is a named kind of limit (e.g. BNPL, cash loan) with its own
+// tenors. A nil PricingMethod prices with the configured default method.
+type LimitProduct struct {
+	ProductID     int64     `json:"product_id" db:"id"`
+	Code          string    `json:"code" db:"code"`
+	Name          string    `json:"name" db:"name"`
+	PricingMethod *string   `json:"pricing_method" db:"pricing_method"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// LimitLedgerEntry is one movement of a limit. Amount is signed: drawdowns
+// are negative, repayments positive. BalanceAfter is the cached limit_amount
+// right after the movement was applied.
+type LimitLedgerEntry struct {
+	EntryID         int64           `json:"entry_id" db:"id"`
+	FacilityLimitID int64           `json:"facility_limit_id" db:"facility_limit_id"`
+	EntryType       string          `json:"entry_type" db:"entry_type"`
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	BalanceAfter    decimal.Decimal `json:"balance_after" db:"balance_after"`
+	ReferenceType   *string         `json:"reference_type" db:"reference_type"`
+	ReferenceID     *int64          `json:"reference_id" db:"reference_id"`
+	Note            string          `json:"note" db:"note"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
 }
 
 type Tenor struct {
-	TenorID    int64 `json:"tenor_id" db:"id"`
-	TenorValue int   `json:"tenor_value" db:"tenor_value"`
+	TenorID         int64            `json:"tenor_id" db:"id"`
+	ProductID       int64            `json:"product_id" db:"product_id"`
+	TenorValue      int              `json:"tenor_value" db:"tenor_value"`
+	Rate            decimal.Decimal  `json:"rate" db:"rate"`
+	MinAmount       *decimal.Decimal `json:"min_amount" db:"min_amount"`
+	MaxAmount       *decimal.Decimal `json:"max_amount" db:"max_amount"`
+	EffectiveFrom   time.Time        `json:"effective_from" db:"effective_from"`
+	EffectiveTo     *time.Time       `json:"effective_to" db:"effective_to"`
+	BusinessDayRule string           `json:"business_day_rule" db:"business_day_rule"`
+}
+
+type Holiday struct {
+	HolidayID   int64     `json:"holiday_id" db:"id"`
+	HolidayDate time.Time `json:"holiday_date" db:"holiday_date"`
+	Name        string    `json:"name" db:"name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
 type UserFacility struct {
@@ -30,28 +333,98 @@ type UserFacility struct {
 	Amount             decimal.Decimal `json:"amount" db:"amount"`
 	Tenor              int             `json:"tenor" db:"tenor"`
 	StartDate          time.Time       `json:"start_date" db:"start_date"`
+	BillingDay         int             `json:"billing_day" db:"billing_day"`
+	GracePeriod        int             `json:"grace_period" db:"grace_period"`
 	MonthlyInstallment decimal.Decimal `json:"monthly_installment" db:"monthly_installment"`
 	TotalMargin        decimal.Decimal `json:"total_margin" db:"total_margin"`
 	TotalPayment       decimal.Decimal `json:"total_payment" db:"total_payment"`
+	Rate               decimal.Decimal `json:"rate" db:"rate"`
+	PricingMethod      string          `json:"pricing_method" db:"pricing_method"`
+	RemainderStrategy  string          `json:"remainder_strategy" db:"remainder_strategy"`
+	RoundingUnit       decimal.Decimal `json:"rounding_unit" db:"rounding_unit"`
+	EngineVersion      string          `json:"engine_version" db:"engine_version"`
+	Status             string          `json:"status" db:"status"`
 	CreatedAt          time.Time       `json:"created_at" db:"created_at"`
 }
 
+type FacilityFilter struct {
+	UserID    int64
+	Status    string
+	Tenor     int
+	StartFrom *time.Time
+	StartTo   *time.Time
+	MinAmount *decimal.Decimal
+	MaxAmount *decimal.Decimal
+	SortBy    string
+	SortDesc  bool
+	Cursor    *FacilityCursor
+	Limit     int
+}
+
+// FacilityCursor points at the last row of a page. It carries the sort it
+// was made for, since Value only makes sense for that column and direction.
+type FacilityCursor struct {
+	SortBy   string `json:"s"`
+	SortDesc bool   `json:"d"`
+	Value    string `json:"v"`
+	ID       int64  `json:"id"`
+}
+
 type UserFacilityDetail struct {
-	DetailID          int64           `json:"user_facility_detail_id" db:"id"`
-	UserFacilityID    int64           `json:"user_facility_id" db:"user_facility_id"`
-	DueDate           time.Time       `json:"due_date" db:"due_date"`
-	InstallmentAmount decimal.Decimal `json:"installment_amount" db:"installment_amount"`
+	DetailID             int64           `json:"user_facility_detail_id" db:"id"`
+	UserFacilityID       int64           `json:"user_facility_id" db:"user_facility_id"`
+	DueDate              time.Time       `json:"due_date" db:"due_date"`
+	InstallmentAmount    decimal.Decimal `json:"installment_amount" db:"installment_amount"`
+	PrincipalAmount      decimal.Decimal `json:"principal_amount" db:"principal_amount"`
+	MarginAmount         decimal.Decimal `json:"margin_amount" db:"margin_amount"`
+	OutstandingPrincipal decimal.Decimal `json:"outstanding_principal" db:"outstanding_principal"`
+	Status               string          `json:"status" db:"status"`
+	PaymentID            *int64          `json:"payment_id" db:"payment_id"`
+	PaidAt               *time.Time      `json:"paid_at" db:"paid_at"`
+}
+
+type Payment struct {
+	PaymentID       int64           `json:"payment_id" db:"id"`
+	UserFacilityID  int64           `json:"user_facility_id" db:"user_facility_id"`
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	PrincipalAmount decimal.Decimal `json:"principal_amount" db:"principal_amount"`
+	PaidAt          time.Time       `json:"paid_at" db:"paid_at"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
+type PayoffQuote struct {
+	QuoteID            int64           `json:"quote_id" db:"id"`
+	UserFacilityID     int64           `json:"user_facility_id" db:"user_facility_id"`
+	RemainingPrincipal decimal.Decimal `json:"remaining_principal" db:"remaining_principal"`
+	OutstandingAmount  decimal.Decimal `json:"outstanding_amount" db:"outstanding_amount"`
+	RebatePolicy       string          `json:"rebate_policy" db:"rebate_policy"`
+	Rebate             decimal.Decimal `json:"rebate" db:"rebate"`
+	Fee                decimal.Decimal `json:"fee" db:"fee"`
+	PayoffAmount       decimal.Decimal `json:"payoff_amount" db:"payoff_amount"`
+	Status             string          `json:"status" db:"status"`
+	PaymentID          *int64          `json:"payment_id" db:"payment_id"`
+	ExpiresAt          time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt          time.Time       `json:"created_at" db:"created_at"`
 }
 
 type CalculateInstallmentsRequest struct {
-	Amount int64 `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	ProductID   int64  `json:"product_id" binding:"omitempty,gt=0"`
+	Amount      int64  `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	StartDate   string `json:"start_date" binding:"omitempty,datetime=2006-01-02,notpast"`
+	BillingDay  int    `json:"billing_day" binding:"omitempty,gte=1,lte=28"`
+	GracePeriod int    `json:"grace_period" binding:"omitempty,gte=0,lte=3"`
 }
 
 type InstallmentSimulation struct {
-	Tenor              int             `json:"tenor"`
-	MonthlyInstallment decimal.Decimal `json:"monthly_installment" swaggertype:"number"`
-	TotalMargin        decimal.Decimal `json:"total_margin" swaggertype:"number"`
-	TotalPayment       decimal.Decimal `json:"total_payment" swaggertype:"number"`
+	ProductID          int64            `json:"product_id,omitempty"`
+	Tenor              int              `json:"tenor"`
+	MonthlyInstallment decimal.Decimal  `json:"monthly_installment" swaggertype:"number"`
+	FirstInstallment   *decimal.Decimal `json:"first_installment,omitempty" swaggertype:"number"`
+	LastInstallment    *decimal.Decimal `json:"last_installment,omitempty" swaggertype:"number"`
+	TotalMargin        decimal.Decimal  `json:"total_margin" swaggertype:"number"`
+	TotalPayment       decimal.Decimal  `json:"total_payment" swaggertype:"number"`
+	FirstDueDate       string           `json:"first_due_date,omitempty"`
+	LastDueDate        string           `json:"last_due_date,omitempty"`
 }
 
 type SubmitFinancingRequest struct {
@@ -60,6 +433,11 @@ type SubmitFinancingRequest struct {
 	Amount          int64  `json:"amount" binding:"required,gt=0" swaggertype:"number"`
 	Tenor           int    `json:"tenor" binding:"required"`
 	StartDate       string `json:"start_date" binding:"required,datetime=2006-01-02,notpast"`
+	BillingDay      int    `json:"billing_day" binding:"omitempty,gte=1,lte=28"`
+	GracePeriod     int    `json:"grace_period" binding:"omitempty,gte=0,lte=3"`
+	// IdempotencyKey is taken from the Idempotency-Key header. It is left out
+	// of the request hash.
+	IdempotencyKey string `json:"-"`
 }
 
 type SubmitFinancingResponse struct {
@@ -76,20 +454,438 @@ type SubmitFinancingResponse struct {
 }
 
 type ScheduleDetail struct {
-	DueDate           string          `json:"due_date"`
-	InstallmentAmount decimal.Decimal `json:"installment_amount" swaggertype:"number"`
+	DueDate              string          `json:"due_date"`
+	InstallmentAmount    decimal.Decimal `json:"installment_amount" swaggertype:"number"`
+	PrincipalAmount      decimal.Decimal `json:"principal_amount" swaggertype:"number"`
+	MarginAmount         decimal.Decimal `json:"margin_amount" swaggertype:"number"`
+	OutstandingPrincipal decimal.Decimal `json:"outstanding_principal" swaggertype:"number"`
+	Status               string          `json:"status"`
+	PaidAt               string          `json:"paid_at,omitempty"`
+}
+
+type FacilityResponse struct {
+	UserFacilityID     int64            `json:"user_facility_id"`
+	UserID             int64            `json:"user_id"`
+	FacilityLimitID    int64            `json:"facility_limit_id"`
+	Amount             decimal.Decimal  `json:"amount" swaggertype:"number"`
+	Tenor              int              `json:"tenor"`
+	StartDate          string           `json:"start_date"`
+	BillingDay         int              `json:"billing_day"`
+	GracePeriod        int              `json:"grace_period"`
+	MonthlyInstallment decimal.Decimal  `json:"monthly_installment" swaggertype:"number"`
+	TotalMargin        decimal.Decimal  `json:"total_margin" swaggertype:"number"`
+	TotalPayment       decimal.Decimal  `json:"total_payment" swaggertype:"number"`
+	Rate               decimal.Decimal  `json:"rate" swaggertype:"number"`
+	PricingMethod      string           `json:"pricing_method"`
+	EngineVersion      string           `json:"engine_version"`
+	Status             string           `json:"status"`
+	CreatedAt          string           `json:"created_at"`
+	Schedule           []ScheduleDetail `json:"schedule,omitempty"`
+}
+
+type RecomputeResponse struct {
+	UserFacilityID       int64                 `json:"user_facility_id"`
+	PricingMethod        string                `json:"pricing_method"`
+	RemainderStrategy    string                `json:"remainder_strategy"`
+	RoundingUnit         decimal.Decimal       `json:"rounding_unit" swaggertype:"number"`
+	Rate                 decimal.Decimal       `json:"rate" swaggertype:"number"`
+	StoredEngineVersion  string                `json:"stored_engine_version"`
+	CurrentEngineVersion string                `json:"current_engine_version"`
+	Stored               InstallmentSimulation `json:"stored"`
+	Recomputed           InstallmentSimulation `json:"recomputed"`
+	Drift                InstallmentSimulation `json:"drift"`
+	HasDrift             bool                  `json:"has_drift"`
+}
+
+type ListFacilitiesRequest struct {
+	UserID    int64  `form:"-"`
+	Status    string `form:"status" binding:"omitempty,oneof=active paid_off cancelled"`
+	Tenor     int    `form:"tenor" binding:"omitempty,gt=0"`
+	StartFrom string `form:"start_from" binding:"omitempty,datetime=2006-01-02"`
+	StartTo   string `form:"start_to" binding:"omitempty,datetime=2006-01-02"`
+	MinAmount string `form:"min_amount" binding:"omitempty,numeric"`
+	MaxAmount string `form:"max_amount" binding:"omitempty,numeric"`
+	SortBy    string `form:"sort_by" binding:"omitempty,oneof=created_at start_date amount"`
+	SortOrder string `form:"sort_order" binding:"omitempty,oneof=asc desc"`
+	Cursor    string `form:"cursor"`
+	Limit     int    `form:"limit" binding:"omitempty,gt=0,lte=100"`
+}
+
+type ListFacilitiesResponse struct {
+	Data       []*FacilityResponse `json:"data"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+type PaymentRequest struct {
+	Amount decimal.Decimal `json:"amount" swaggertype:"number"`
+}
+
+type PaymentResponse struct {
+	PaymentID        int64            `json:"payment_id"`
+	UserFacilityID   int64            `json:"user_facility_id"`
+	Amount           decimal.Decimal  `json:"amount" swaggertype:"number"`
+	PrincipalAmount  decimal.Decimal  `json:"principal_amount" swaggertype:"number"`
+	PaidAt           string           `json:"paid_at"`
+	PaidInstallments []ScheduleDetail `json:"paid_installments"`
+}
+
+type PayoffQuoteResponse struct {
+	QuoteID            int64           `json:"quote_id"`
+	UserFacilityID     int64           `json:"user_facility_id"`
+	RemainingPrincipal decimal.Decimal `json:"remaining_principal" swaggertype:"number"`
+	OutstandingAmount  decimal.Decimal `json:"outstanding_amount" swaggertype:"number"`
+	RebatePolicy       string          `json:"rebate_policy"`
+	Rebate             decimal.Decimal `json:"rebate" swaggertype:"number"`
+	Fee                decimal.Decimal `json:"fee" swaggertype:"number"`
+	PayoffAmount       decimal.Decimal `json:"payoff_amount" swaggertype:"number"`
+	ExpiresAt          string          `json:"expires_at"`
+}
+
+type PayoffRequest struct {
+	QuoteID int64 `json:"quote_id" binding:"required"`
 }
 
+// UserLimit is one limit of a user. A user without any limit is listed once
+// with HasLimit false and the limit fields left empty.
 type UserLimit struct {
 	UserID      int64           `json:"id"`
 	Name        string          `json:"name"`
 	Phone       string          `json:"phone"`
-	LimitId     int64           `json:"limit_id"`
+	HasLimit    bool            `json:"has_limit"`
+	LimitId     *int64          `json:"limit_id"`
+	ProductID   *int64          `json:"product_id"`
 	LimitAmount decimal.Decimal `json:"limit_amount" swaggertype:"number"`
+	HeldAmount  decimal.Decimal `json:"held_amount" swaggertype:"number"`
+	Status      string          `json:"status,omitempty"`
+	ValidUntil  string          `json:"valid_until,omitempty"`
+}
+
+// UserLimitRow is a user joined with one of their limits and its active
+// holds. The limit columns are nil for a user without a limit.
+type UserLimitRow struct {
+	UserID      int64            `db:"user_id"`
+	Name        string           `db:"name"`
+	Phone       string           `db:"phone"`
+	LimitID     *int64           `db:"limit_id"`
+	ProductID   *int64           `db:"product_id"`
+	LimitAmount *decimal.Decimal `db:"limit_amount"`
+	HeldAmount  decimal.Decimal  `db:"held_amount"`
+	Status      *string          `db:"status"`
+	ValidUntil  *time.Time       `db:"valid_until"`
+}
+
+// UserLimitFilter narrows the user limit listing. Search matches the name,
+// Phone matches part of the phone number, and the available range applies
+// to the limit amount, which is zero for a user without a limit.
+type UserLimitFilter struct {
+	UserID       int64
+	Search       string
+	Phone        string
+	MinAvailable *decimal.Decimal
+	MaxAvailable *decimal.Decimal
+	Cursor       *UserLimitCursor
+	Limit        int
+}
+
+// UserLimitCursor points at the last row of a page. LimitID is zero for a
+// user without a limit.
+type UserLimitCursor struct {
+	UserID  int64 `json:"u"`
+	LimitID int64 `json:"l"`
+}
+
+type ListUserLimitsRequest struct {
+	UserID       int64  `form:"-"`
+	Search       string `form:"q" binding:"omitempty,max=100"`
+	MinAvailable string `form:"min_available" binding:"omitempty,numeric"`
+	MaxAvailable string `form:"max_available" binding:"omitempty,numeric"`
+	Cursor       string `form:"cursor"`
+	Limit        int    `form:"limit" binding:"omitempty,gt=0,lte=100"`
+}
+
+type ListUserLimitsResponse struct {
+	Data       []*UserLimit `json:"data"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type ListTenorsRequest struct {
+	ProductID int64 `form:"product_id" binding:"omitempty,gt=0"`
 }
 
 type ListTenor struct {
-	TenorValue int `json:"tenor_value" db:"tenor_value"`
+	ProductID  int64            `json:"product_id"`
+	TenorValue int              `json:"tenor_value" db:"tenor_value"`
+	Rate       decimal.Decimal  `json:"rate" swaggertype:"number"`
+	MinAmount  *decimal.Decimal `json:"min_amount,omitempty" swaggertype:"number"`
+	MaxAmount  *decimal.Decimal `json:"max_amount,omitempty" swaggertype:"number"`
+}
+
+type TenorRequest struct {
+	ProductID       int64            `json:"product_id" binding:"required,gt=0"`
+	TenorValue      int              `json:"tenor_value" binding:"required,gt=0"`
+	Rate            decimal.Decimal  `json:"rate" swaggertype:"number"`
+	MinAmount       *decimal.Decimal `json:"min_amount" swaggertype:"number"`
+	MaxAmount       *decimal.Decimal `json:"max_amount" swaggertype:"number"`
+	EffectiveFrom   string           `json:"effective_from" binding:"required,datetime=2006-01-02"`
+	EffectiveTo     string           `json:"effective_to" binding:"omitempty,datetime=2006-01-02"`
+	BusinessDayRule string           `json:"business_day_rule" binding:"omitempty,oneof=none following modified-following preceding"`
+}
+
+type TenorResponse struct {
+	TenorID         int64            `json:"tenor_id"`
+	ProductID       int64            `json:"product_id"`
+	TenorValue      int              `json:"tenor_value"`
+	Rate            decimal.Decimal  `json:"rate" swaggertype:"number"`
+	MinAmount       *decimal.Decimal `json:"min_amount,omitempty" swaggertype:"number"`
+	MaxAmount       *decimal.Decimal `json:"max_amount,omitempty" swaggertype:"number"`
+	EffectiveFrom   string           `json:"effective_from"`
+	EffectiveTo     string           `json:"effective_to,omitempty"`
+	BusinessDayRule string           `json:"business_day_rule"`
+}
+
+type LimitChangeRequest struct {
+	Action          string           `json:"action" binding:"required,oneof=create raise lower freeze unfreeze renew close"`
+	UserID          int64            `json:"user_id" binding:"omitempty,gt=0"`
+	ProductID       int64            `json:"product_id" binding:"omitempty,gt=0"`
+	FacilityLimitID int64            `json:"facility_limit_id" binding:"omitempty,gt=0"`
+	Amount          *decimal.Decimal `json:"amount" swaggertype:"number"`
+	Reason          string           `json:"reason" binding:"required,max=255"`
+}
+
+type ReviewLimitChangeRequest struct {
+	Note string `json:"note" binding:"omitempty,max=255"`
+}
+
+type ListLimitChangesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type LimitChangeResponse struct {
+	ChangeID        int64            `json:"change_id"`
+	Action          string           `json:"action"`
+	UserID          int64            `json:"user_id"`
+	ProductID       int64            `json:"product_id"`
+	FacilityLimitID *int64           `json:"facility_limit_id,omitempty"`
+	Amount          *decimal.Decimal `json:"amount,omitempty" swaggertype:"number"`
+	Reason          string           `json:"reason"`
+	Status          string           `json:"status"`
+	RequestedBy     string           `json:"requested_by"`
+	ReviewedBy      string           `json:"reviewed_by,omitempty"`
+	ReviewNote      string           `json:"review_note,omitempty"`
+	CreatedAt       string           `json:"created_at"`
+	ReviewedAt      string           `json:"reviewed_at,omitempty"`
+}
+
+type LimitReviewRequest struct {
+	Date string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type LimitReviewResponse struct {
+	FacilityLimitID int64           `json:"facility_limit_id"`
+	UserID          int64           `json:"user_id"`
+	ProductID       int64           `json:"product_id"`
+	LimitAmount     decimal.Decimal `json:"limit_amount" swaggertype:"number"`
+	Status          string          `json:"status"`
+	ValidUntil      string          `json:"valid_until"`
+	NextReviewDate  string          `json:"next_review_date"`
+	Expired         bool            `json:"expired"`
+}
+
+type UserRequest struct {
+	Name  string `json:"name" binding:"required,max=30"`
+	Phone string `json:"phone" binding:"required,idphone"`
+}
+
+type UserResponse struct {
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type KYCRequest struct {
+	NIK      string `json:"nik" binding:"required,nik"`
+	FullName string `json:"full_name" binding:"required,max=100"`
+}
+
+type ReviewKYCRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=255"`
+}
+
+type ListKYCRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending verified rejected expired"`
+}
+
+type KYCResponse struct {
+	UserID       int64  `json:"user_id"`
+	NIK          string `json:"nik"`
+	FullName     string `json:"full_name"`
+	Status       string `json:"status"`
+	RejectReason string `json:"reject_reason,omitempty"`
+	SubmittedAt  string `json:"submitted_at"`
+	ReviewedBy   string `json:"reviewed_by,omitempty"`
+	ReviewedAt   string `json:"reviewed_at,omitempty"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+}
+
+type ApplicationRequest struct {
+	UserID          int64  `json:"user_id" binding:"required"`
+	FacilityLimitID int64  `json:"facility_limit_id" binding:"required"`
+	Amount          int64  `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	Tenor           int    `json:"tenor" binding:"required"`
+	StartDate       string `json:"start_date" binding:"omitempty,datetime=2006-01-02,notpast"`
+	BillingDay      int    `json:"billing_day" binding:"omitempty,gte=1,lte=28"`
+	GracePeriod     int    `json:"grace_period" binding:"omitempty,gte=0,lte=3"`
+	// IdempotencyKey is taken from the Idempotency-Key header. It is left out
+	// of the request hash.
+	IdempotencyKey string `json:"-"`
+}
+
+type ReviewApplicationRequest struct {
+	Note string `json:"note" binding:"omitempty,max=255"`
+}
+
+type ListApplicationsRequest struct {
+	UserID int64  `form:"user_id" binding:"omitempty,gt=0"`
+	Status string `form:"status" binding:"omitempty,oneof=draft submitted under_review approved rejected cancelled disbursed"`
+	After  int64  `form:"after" binding:"omitempty,gt=0"`
+	Limit  int    `form:"limit" binding:"omitempty,gt=0,lte=100"`
+}
+
+type ApplicationResponse struct {
+	ApplicationID   int64           `json:"application_id"`
+	UserID          int64           `json:"user_id"`
+	FacilityLimitID int64           `json:"facility_limit_id"`
+	Amount          decimal.Decimal `json:"amount" swaggertype:"number"`
+	Tenor           int             `json:"tenor"`
+	StartDate       string          `json:"start_date,omitempty"`
+	BillingDay      int             `json:"billing_day"`
+	GracePeriod     int             `json:"grace_period"`
+	Status          string          `json:"status"`
+	UserFacilityID  *int64          `json:"user_facility_id,omitempty"`
+	ReviewedBy      string          `json:"reviewed_by,omitempty"`
+	ReviewNote      string          `json:"review_note,omitempty"`
+	CreatedAt       string          `json:"created_at"`
+	SubmittedAt     string          `json:"submitted_at,omitempty"`
+	ReviewStartedAt string          `json:"review_started_at,omitempty"`
+	ApprovedAt      string          `json:"approved_at,omitempty"`
+	RejectedAt      string          `json:"rejected_at,omitempty"`
+	CancelledAt     string          `json:"cancelled_at,omitempty"`
+	DisbursedAt     string          `json:"disbursed_at,omitempty"`
+}
+
+type ListApplicationsResponse struct {
+	Data      []*ApplicationResponse `json:"data"`
+	NextAfter int64                  `json:"next_after,omitempty"`
+}
+
+type ListDisbursementsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed manual_review"`
+}
+
+type DisbursementResponse struct {
+	DisbursementID int64           `json:"disbursement_id"`
+	UserFacilityID int64           `json:"user_facility_id"`
+	UserID         int64           `json:"user_id"`
+	Amount         decimal.Decimal `json:"amount" swaggertype:"number"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	BankReference  string          `json:"bank_reference,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	CompletedAt    string          `json:"completed_at,omitempty"`
+}
+
+type HoldRequest struct {
+	UserID          int64  `json:"user_id" binding:"required"`
+	FacilityLimitID int64  `json:"facility_limit_id" binding:"required"`
+	Amount          int64  `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	Reference       string `json:"reference" binding:"omitempty,max=100"`
+	TTLSeconds      int    `json:"ttl_seconds" binding:"omitempty,gte=60,lte=86400"`
+}
+
+type CaptureHoldRequest struct {
+	Tenor       int    `json:"tenor" binding:"required"`
+	StartDate   string `json:"start_date" binding:"required,datetime=2006-01-02,notpast"`
+	BillingDay  int    `json:"billing_day" binding:"omitempty,gte=1,lte=28"`
+	GracePeriod int    `json:"grace_period" binding:"omitempty,gte=0,lte=3"`
+}
+
+type HoldResponse struct {
+	HoldID          int64           `json:"hold_id"`
+	UserID          int64           `json:"user_id"`
+	FacilityLimitID int64           `json:"facility_limit_id"`
+	Amount          decimal.Decimal `json:"amount" swaggertype:"number"`
+	Reference       string          `json:"reference,omitempty"`
+	Status          string          `json:"status"`
+	UserFacilityID  *int64          `json:"user_facility_id,omitempty"`
+	ApplicationID   *int64          `json:"application_id,omitempty"`
+	ExpiresAt       string          `json:"expires_at"`
+}
+
+type ProductRequest struct {
+	Code          string `json:"code" binding:"required,max=30"`
+	Name          string `json:"name" binding:"required,max=100"`
+	PricingMethod string `json:"pricing_method" binding:"omitempty,oneof=flat annuity murabahah"`
+}
+
+type ProductPricingRequest struct {
+	PricingMethod string `json:"pricing_method" binding:"omitempty,oneof=flat annuity murabahah"`
+}
+
+type ProductResponse struct {
+	ProductID     int64  `json:"product_id"`
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	PricingMethod string `json:"pricing_method,omitempty"`
+}
+
+type HolidayRequest struct {
+	HolidayDate string `json:"holiday_date" binding:"required,datetime=2006-01-02"`
+	Name        string `json:"name" binding:"required,max=100"`
+}
+
+type HolidayResponse struct {
+	HolidayID   int64  `json:"holiday_id"`
+	HolidayDate string `json:"holiday_date"`
+	Name        string `json:"name"`
+}
+
+type ListHolidaysRequest struct {
+	Year int `form:"year" binding:"omitempty,gte=2000,lte=2100"`
+}
+
+type ImportHolidaysResponse struct {
+	Imported int `json:"imported"`
+}
+
+type LimitHistoryRequest struct {
+	FacilityLimitID int64 `form:"facility_limit_id" binding:"omitempty,gt=0"`
+	Limit           int   `form:"limit" binding:"omitempty,gt=0,lte=100"`
+	Before          int64 `form:"before" binding:"omitempty,gt=0"`
+}
+
+type LimitLedgerResponse struct {
+	EntryID       int64           `json:"entry_id"`
+	EntryType     string          `json:"entry_type"`
+	Amount        decimal.Decimal `json:"amount" swaggertype:"number"`
+	BalanceAfter  decimal.Decimal `json:"balance_after" swaggertype:"number"`
+	ReferenceType string          `json:"reference_type,omitempty"`
+	ReferenceID   *int64          `json:"reference_id,omitempty"`
+	Note          string          `json:"note,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+type LimitHistoryResponse struct {
+	FacilityLimitID int64                 `json:"facility_limit_id"`
+	UserID          int64                 `json:"user_id"`
+	Balance         decimal.Decimal       `json:"balance" swaggertype:"number"`
+	LedgerBalance   decimal.Decimal       `json:"ledger_balance" swaggertype:"number"`
+	Entries         []LimitLedgerResponse `json:"entries"`
+	NextBefore      int64                 `json:"next_before,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -0,0 +1,99 @@
+// Package nik validates the Indonesian national identity number (Nomor Induk
+// Kependudukan).
+package nik
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrInvalid is returned for a number that is not a well-formed NIK.
+var ErrInvalid = errors.New("invalid nik")
+
+// Hint is the validation message shown for a number Parse rejects.
+const Hint = "must be a valid 16 digit NIK"
+
+// provinces are the codes of the provinces a NIK can be issued in.
+var provinces = map[int]bool{
+	11: true, 12: true, 13: true, 14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 21: true,
+	31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	51: true, 52: true, 53: true,
+	61: true, 62: true, 63: true, 64: true, 65: true,
+	71: true, 72: true, 73: true, 74: true, 75: true, 76: true,
+	81: true, 82: true,
+	91: true, 92: true, 93: true, 94: true, 95: true, 96: true,
+}
+
+// NIK is a parsed national identity number.
+type NIK struct {
+	Number    string
+	Province  int
+	BirthDate time.Time
+	Female    bool
+}
+
+// Parse checks the structure of a NIK: 16 digits made of a province, regency
+// and district code, the birth date as DDMMYY with 40 added to the day for
+// women, and a non-zero serial. A NIK has no check digit, so this is as far
+// as it can be verified offline. The two digit birth year is read as the most
+// recent year that is not after now.
+func Parse(number string, now time.Time) (NIK, error) {
+	if len(number) != 16 {
+		return NIK{}, ErrInvalid
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return NIK{}, ErrInvalid
+		}
+	}
+
+	province := digits(number, 0)
+	regency := digits(number, 2)
+	district := digits(number, 4)
+	day := digits(number, 6)
+	month := digits(number, 8)
+	year := digits(number, 10)
+	serial, _ := strconv.Atoi(number[12:])
+
+	if !provinces[province] || regency == 0 || district == 0 || serial == 0 {
+		return NIK{}, ErrInvalid
+	}
+
+	female := day > 40
+	if female {
+		day -= 40
+	}
+
+	year += now.Year() / 100 * 100
+	if year > now.Year() {
+		year -= 100
+	}
+
+	birthDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if birthDate.Day() != day || int(birthDate.Month()) != month || birthDate.After(now) {
+		return NIK{}, ErrInvalid
+	}
+
+	return NIK{
+		Number:    number,
+		Province:  province,
+		BirthDate: birthDate,
+		Female:    female,
+	}, nil
+}
+
+// Mask hides the birth date and serial of a NIK, keeping the region codes
+// and the last two digits.
+func Mask(number string) string {
+	if len(number) != 16 {
+		return number
+	}
+
+	return number[:6] + "********" + number[14:]
+}
+
+func digits(number string, at int) int {
+	value, _ := strconv.Atoi(number[at : at+2])
+	return value
+}
@@ -0,0 +1,57 @@
+package nik
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	t.Run("male", func(t *testing.T) {
+		id, err := Parse("3201011708900001", now)
+		assert.NoError(t, err)
+		assert.Equal(t, 32, id.Province)
+		assert.Equal(t, "1990-08-17", id.BirthDate.Format("2006-01-02"))
+		assert.False(t, id.Female)
+	})
+
+	t.Run("female adds 40 to the day", func(t *testing.T) {
+		id, err := Parse("3171015203050002", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "2005-03-12", id.BirthDate.Format("2006-01-02"))
+		assert.True(t, id.Female)
+	})
+
+	t.Run("two digit year in the future belongs to the last century", func(t *testing.T) {
+		id, err := Parse("3201011708300001", now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1930, id.BirthDate.Year())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, number := range []string{
+			"",
+			"320101170890000",   // too short
+			"32010117089000011", // too long
+			"320101170890000a",  // not a digit
+			"9901011708900001",  // unknown province
+			"3200011708900001",  // no regency
+			"3201001708900001",  // no district
+			"3201013002900001",  // 30 February
+			"3201011713900001",  // month 13
+			"3201017208900001",  // female day 32
+			"3201011708900000",  // no serial
+		} {
+			_, err := Parse(number, now)
+			assert.ErrorIs(t, err, ErrInvalid, number)
+		}
+	})
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "320101********01", Mask("3201011708900001"))
+	assert.Equal(t, "123", Mask("123"))
+}
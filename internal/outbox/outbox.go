@@ -0,0 +1,63 @@
+// Package outbox delivers the domain events written to the outbox table to
+// the systems downstream.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"finance/pkg/logger"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is an event on its way downstream. ID is unique per event and is
+// the same on every delivery of it, so consumers can drop the duplicates an
+// at-least-once delivery produces.
+type Message struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Publisher hands messages to a broker or another system. A message counts
+// as delivered once Publish returns nil; any error has it sent again later.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// LogPublisher writes every message to the log. It stands in for a broker in
+// local runs.
+type LogPublisher struct {
+	log *logger.Logger
+}
+
+func NewLogPublisher(log *logger.Logger) *LogPublisher {
+	return &LogPublisher{log: log}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, msg Message) error {
+	p.log.Info("outbox event published",
+		zap.String("event_id", msg.ID),
+		zap.String("event_type", msg.Type),
+		zap.String("aggregate_type", msg.AggregateType),
+		zap.Int64("aggregate_id", msg.AggregateID),
+		zap.ByteString("payload", msg.Payload))
+
+	return nil
+}
+
+// NewEventID returns a random version 4 UUID.
+func NewEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,20 @@
+package outbox
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEventID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := map[string]bool{}
+	for range 100 {
+		id := NewEventID()
+		assert.Regexp(t, uuid, id)
+		assert.False(t, seen[id], "duplicate event id %s", id)
+		seen[id] = true
+	}
+}
@@ -0,0 +1,57 @@
+// Package phone normalizes Indonesian mobile numbers to E.164.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for a number that is not an Indonesian mobile
+// number in one of the accepted forms.
+var ErrInvalid = errors.New("invalid indonesian mobile number")
+
+// Hint is the validation message shown for a number Normalize rejects.
+const Hint = "must be an Indonesian mobile number such as 08123456789, 628123456789 or +628123456789"
+
+const (
+	countryCode = "62"
+	// a mobile subscriber number starts with 8 and has 9 to 12 digits
+	minSubscriber = 9
+	maxSubscriber = 12
+)
+
+// Normalize returns the E.164 form (+628xx) of an Indonesian mobile number
+// written as 08xx, +628xx or 628xx. Spaces, dashes, dots and parentheses
+// between the digits are ignored.
+func Normalize(raw string) (string, error) {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	var subscriber string
+	switch {
+	case strings.HasPrefix(number, "+"+countryCode):
+		subscriber = number[len(countryCode)+1:]
+	case strings.HasPrefix(number, countryCode):
+		subscriber = number[len(countryCode):]
+	case strings.HasPrefix(number, "0"):
+		subscriber = number[1:]
+	default:
+		return "", ErrInvalid
+	}
+
+	if len(subscriber) < minSubscriber || len(subscriber) > maxSubscriber || subscriber[0] != '8' {
+		return "", ErrInvalid
+	}
+	for _, r := range subscriber {
+		if r < '0' || r > '9' {
+			return "", ErrInvalid
+		}
+	}
+
+	return "+" + countryCode + subscriber, nil
+}
@@ -0,0 +1,43 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("accepted forms", func(t *testing.T) {
+		for _, raw := range []string{"08123456789", "+628123456789", "628123456789", "0812-3456-789", " +62 812 3456 789 "} {
+			number, err := Normalize(raw)
+			assert.NoError(t, err, raw)
+			assert.Equal(t, "+628123456789", number, raw)
+		}
+	})
+
+	t.Run("subscriber length", func(t *testing.T) {
+		number, err := Normalize("0812345678")
+		assert.NoError(t, err)
+		assert.Equal(t, "+62812345678", number)
+
+		number, err = Normalize("0812345678901")
+		assert.NoError(t, err)
+		assert.Equal(t, "+62812345678901", number)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, raw := range []string{
+			"",
+			"8123456789",     // no prefix
+			"+6581234567",    // other country
+			"0212345678",     // landline
+			"08123456",       // too short
+			"08123456789012", // too long
+			"0812345678a",    // not a digit
+			"+62+8123456789", // misplaced plus
+		} {
+			_, err := Normalize(raw)
+			assert.ErrorIs(t, err, ErrInvalid, raw)
+		}
+	})
+}
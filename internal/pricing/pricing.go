@@ -0,0 +1,149 @@
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Version identifies the engine revision stored with every booked facility so
+// a contract can be recomputed and explained after pricing changes.
+const Version = "1.0.0"
+
+const (
+	MethodFlat      = "flat"
+	MethodAnnuity   = "annuity"
+	MethodMurabahah = "murabahah"
+)
+
+// scale is the number of decimal places kept for intermediate rates before
+// the results are rounded to currency precision.
+const scale = 16
+
+var (
+	monthsInYear = decimal.NewFromInt(12)
+	one          = decimal.NewFromInt(1)
+)
+
+type Quote struct {
+	MonthlyInstallment decimal.Decimal
+	TotalMargin        decimal.Decimal
+	TotalPayment       decimal.Decimal
+}
+
+// Pricer computes the installment, margin and total payment of a facility.
+// The meaning of rate depends on the method: an annual rate for flat and
+// annuity, the contract margin for murabahah.
+type Pricer interface {
+	Method() string
+	Price(amount decimal.Decimal, tenor int, rate decimal.Decimal) Quote
+	Schedule(amount decimal.Decimal, tenor int, rate decimal.Decimal) []Installment
+}
+
+func New(method string) (Pricer, error) {
+	switch method {
+	case MethodFlat:
+		return NewFlat(), nil
+	case MethodAnnuity:
+		return NewAnnuity(), nil
+	case MethodMurabahah:
+		return NewMurabahah(), nil
+	default:
+		return nil, fmt.Errorf("pricing: unknown method %q", method)
+	}
+}
+
+type flat struct{}
+
+// NewFlat prices the margin on the original amount for the whole tenor.
+func NewFlat() Pricer {
+	return flat{}
+}
+
+func (flat) Method() string {
+	return MethodFlat
+}
+
+func (flat) Price(amount decimal.Decimal, tenor int, rate decimal.Decimal) Quote {
+	tenorDec := decimal.NewFromInt(int64(tenor))
+
+	totalMargin := amount.Mul(rate).Mul(tenorDec).Div(monthsInYear).Round(2)
+	totalPayment := amount.Add(totalMargin)
+
+	return Quote{
+		MonthlyInstallment: totalPayment.DivRound(tenorDec, 2),
+		TotalMargin:        totalMargin,
+		TotalPayment:       totalPayment,
+	}
+}
+
+func (f flat) Schedule(amount decimal.Decimal, tenor int, rate decimal.Decimal) []Installment {
+	return equalPrincipal(amount, tenor, f.Price(amount, tenor, rate))
+}
+
+type annuity struct{}
+
+// NewAnnuity prices on the reducing balance with equal monthly installments.
+func NewAnnuity() Pricer {
+	return annuity{}
+}
+
+func (annuity) Method() string {
+	return MethodAnnuity
+}
+
+func (annuity) Price(amount decimal.Decimal, tenor int, rate decimal.Decimal) Quote {
+	tenorDec := decimal.NewFromInt(int64(tenor))
+
+	monthlyRate := rate.DivRound(monthsInYear, scale)
+	if monthlyRate.IsZero() {
+		monthly := amount.DivRound(tenorDec, 2)
+		return Quote{MonthlyInstallment: monthly, TotalMargin: decimal.Zero, TotalPayment: amount}
+	}
+
+	growth, _ := one.Add(monthlyRate).PowInt32(int32(tenor))
+	growth = growth.Round(scale)
+
+	monthly := amount.Mul(monthlyRate).Mul(growth).DivRound(growth.Sub(one), 2)
+	totalPayment := monthly.Mul(tenorDec)
+
+	return Quote{
+		MonthlyInstallment: monthly,
+		TotalMargin:        totalPayment.Sub(amount),
+		TotalPayment:       totalPayment,
+	}
+}
+
+func (a annuity) Schedule(amount decimal.Decimal, tenor int, rate decimal.Decimal) []Installment {
+	monthlyRate := rate.DivRound(monthsInYear, scale)
+	return reducingBalance(amount, tenor, monthlyRate, a.Price(amount, tenor, rate))
+}
+
+type murabahah struct{}
+
+// NewMurabahah prices a fixed margin agreed on the cost price, independent of
+// the tenor.
+func NewMurabahah() Pricer {
+	return murabahah{}
+}
+
+func (murabahah) Method() string {
+	return MethodMurabahah
+}
+
+func (murabahah) Price(amount decimal.Decimal, tenor int, rate decimal.Decimal) Quote {
+	tenorDec := decimal.NewFromInt(int64(tenor))
+
+	totalMargin := amount.Mul(rate).Round(2)
+	totalPayment := amount.Add(totalMargin)
+
+	return Quote{
+		MonthlyInstallment: totalPayment.DivRound(tenorDec, 2),
+		TotalMargin:        totalMargin,
+		TotalPayment:       totalPayment,
+	}
+}
+
+func (m murabahah) Schedule(amount decimal.Decimal, tenor int, rate decimal.Decimal) []Installment {
+	return equalPrincipal(amount, tenor, m.Price(amount, tenor, rate))
+}
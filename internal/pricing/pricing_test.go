@@ -0,0 +1,54 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	for _, method := range []string{MethodFlat, MethodAnnuity, MethodMurabahah} {
+		p, err := New(method)
+		assert.NoError(t, err)
+		assert.Equal(t, method, p.Method())
+	}
+
+	p, err := New("balloon")
+	assert.Error(t, err)
+	assert.Nil(t, p)
+}
+
+func TestFlat_Price(t *testing.T) {
+	q := NewFlat().Price(decimal.NewFromInt(10000000), 12, decimal.RequireFromString("0.20"))
+
+	assert.Equal(t, "2000000", q.TotalMargin.String())
+	assert.Equal(t, "12000000", q.TotalPayment.String())
+	assert.Equal(t, "1000000", q.MonthlyInstallment.String())
+}
+
+func TestAnnuity_Price(t *testing.T) {
+	t.Run("reducing balance", func(t *testing.T) {
+		q := NewAnnuity().Price(decimal.NewFromInt(10000000), 12, decimal.RequireFromString("0.12"))
+
+		// PMT(1%, 12, 10,000,000) = 888,487.89
+		assert.Equal(t, "888487.89", q.MonthlyInstallment.String())
+		assert.Equal(t, "10661854.68", q.TotalPayment.String())
+		assert.Equal(t, "661854.68", q.TotalMargin.String())
+	})
+
+	t.Run("zero rate", func(t *testing.T) {
+		q := NewAnnuity().Price(decimal.NewFromInt(1200000), 12, decimal.Zero)
+
+		assert.Equal(t, "100000", q.MonthlyInstallment.String())
+		assert.True(t, q.TotalMargin.IsZero())
+	})
+}
+
+func TestMurabahah_Price(t *testing.T) {
+	q := NewMurabahah().Price(decimal.NewFromInt(6000000), 6, decimal.RequireFromString("0.10"))
+
+	assert.Equal(t, "600000", q.TotalMargin.String())
+	assert.Equal(t, "6600000", q.TotalPayment.String())
+	assert.Equal(t, "1100000", q.MonthlyInstallment.String())
+}
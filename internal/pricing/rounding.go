@@ -0,0 +1,152 @@
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Remainder strategies decide which installment absorbs the difference
+// between the rounded monthly installment and the total payment.
+const (
+	RemainderLast  = "last"
+	RemainderFirst = "first"
+	RemainderUnit  = "unit"
+)
+
+// Rounding reconciles a schedule so its installments add up to the total
+// payment of the quote. With RemainderUnit the regular installment is rounded
+// up to a multiple of Unit (e.g. IDR 100) and the last installment is reduced
+// to compensate.
+type Rounding struct {
+	Strategy string
+	Unit     decimal.Decimal
+}
+
+func NewRounding(strategy string, unit decimal.Decimal) (Rounding, error) {
+	switch strategy {
+	case RemainderLast, RemainderFirst:
+	case RemainderUnit:
+		if !unit.IsPositive() {
+			return Rounding{}, fmt.Errorf("pricing: rounding unit must be greater than 0, got %s", unit)
+		}
+	default:
+		return Rounding{}, fmt.Errorf("pricing: unknown remainder strategy %q", strategy)
+	}
+
+	return Rounding{Strategy: strategy, Unit: unit}, nil
+}
+
+// Apply sets the amount of every installment so the schedule adds up to
+// quote.TotalPayment, and returns the quote with the regular installment the
+// customer pays on every other month. Every installment but the last keeps the
+// margin of the pricing method and the rounding difference moves its
+// principal, so the last installment repays the principal still owed and
+// carries the margin left over. This keeps margin from going negative when the
+// installment is rounded up on a small or zero margin. When rounding up to
+// Unit would leave the adjusted installment nothing to pay, as on a small
+// amount over a long tenor, the installment is not rounded to Unit.
+func (r Rounding) Apply(quote Quote, schedule []Installment) (Quote, []Installment) {
+	n := len(schedule)
+	if n == 0 {
+		return quote, schedule
+	}
+
+	amount := decimal.Zero
+	for _, row := range schedule {
+		amount = amount.Add(row.Principal)
+	}
+
+	regular := quote.MonthlyInstallment
+	if r.Strategy == RemainderUnit {
+		rounded := regular.Div(r.Unit).Ceil().Mul(r.Unit)
+		reconciled := r.reconcile(schedule, amount, rounded, quote.TotalPayment)
+		if CheckSchedule(reconciled, amount, quote.TotalPayment) == nil {
+			return withRegular(quote, rounded, reconciled), reconciled
+		}
+	}
+
+	reconciled := r.reconcile(schedule, amount, regular, quote.TotalPayment)
+	return withRegular(quote, regular, reconciled), reconciled
+}
+
+// reconcile sets every installment to regular but the adjusted one, which
+// takes what is left of totalPayment.
+func (r Rounding) reconcile(schedule []Installment, amount, regular, totalPayment decimal.Decimal) []Installment {
+	n := len(schedule)
+	remainder := totalPayment.Sub(regular.Mul(decimal.NewFromInt(int64(n - 1))))
+
+	adjusted := n - 1
+	if r.Strategy == RemainderFirst {
+		adjusted = 0
+	}
+
+	outstanding := amount
+	reconciled := make([]Installment, n)
+	for i, row := range schedule {
+		row.Amount = regular
+		if i == adjusted {
+			row.Amount = remainder
+		}
+		if i == n-1 {
+			row.Principal = outstanding
+			row.Margin = row.Amount.Sub(outstanding)
+		} else {
+			row.Margin = decimal.Min(decimal.Max(row.Margin, decimal.Zero), row.Amount)
+			row.Principal = row.Amount.Sub(row.Margin)
+		}
+		outstanding = outstanding.Sub(row.Principal)
+		row.Outstanding = outstanding
+		reconciled[i] = row
+	}
+
+	return reconciled
+}
+
+// withRegular returns the quote with the regular installment of a reconciled
+// schedule; a single installment is its own regular one.
+func withRegular(quote Quote, regular decimal.Decimal, reconciled []Installment) Quote {
+	quote.MonthlyInstallment = regular
+	if len(reconciled) == 1 {
+		quote.MonthlyInstallment = reconciled[0].Amount
+	}
+
+	return quote
+}
+
+// CheckSchedule verifies the invariants of a reconciled schedule: installments
+// add up to the total payment, principal adds up to the amount and is fully
+// repaid by the last installment, no installment is zero or negative and no
+// installment has a negative principal or margin.
+func CheckSchedule(schedule []Installment, amount, totalPayment decimal.Decimal) error {
+	if len(schedule) == 0 {
+		return fmt.Errorf("pricing: empty schedule")
+	}
+
+	sumAmount := decimal.Zero
+	sumPrincipal := decimal.Zero
+	for _, row := range schedule {
+		if !row.Amount.IsPositive() {
+			return fmt.Errorf("pricing: installment %d has non-positive amount %s", row.Seq, row.Amount)
+		}
+		if row.Principal.IsNegative() {
+			return fmt.Errorf("pricing: installment %d has negative principal %s", row.Seq, row.Principal)
+		}
+		if row.Margin.IsNegative() {
+			return fmt.Errorf("pricing: installment %d has negative margin %s", row.Seq, row.Margin)
+		}
+		sumAmount = sumAmount.Add(row.Amount)
+		sumPrincipal = sumPrincipal.Add(row.Principal)
+	}
+
+	switch {
+	case !sumAmount.Equal(totalPayment):
+		return fmt.Errorf("pricing: installments add up to %s, total payment is %s", sumAmount, totalPayment)
+	case !sumPrincipal.Equal(amount):
+		return fmt.Errorf("pricing: principal adds up to %s, amount is %s", sumPrincipal, amount)
+	case !schedule[len(schedule)-1].Outstanding.IsZero():
+		return fmt.Errorf("pricing: %s principal left after the last installment", schedule[len(schedule)-1].Outstanding)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRounding(t *testing.T) {
+	_, err := NewRounding(RemainderLast, decimal.Zero)
+	assert.NoError(t, err)
+
+	_, err = NewRounding(RemainderUnit, decimal.Zero)
+	assert.Error(t, err)
+
+	_, err = NewRounding("middle", decimal.Zero)
+	assert.Error(t, err)
+}
+
+func TestRounding_Apply(t *testing.T) {
+	amount := decimal.NewFromInt(1000000)
+	rate := decimal.RequireFromString("0.10")
+	p := NewFlat()
+
+	// 1,000,000 + 25,000 margin over 3 months leaves a 0.01 remainder
+	quote := p.Price(amount, 3, rate)
+	assert.Equal(t, "341666.67", quote.MonthlyInstallment.String())
+
+	t.Run("adjust last", func(t *testing.T) {
+		q, schedule := Rounding{Strategy: RemainderLast}.Apply(quote, p.Schedule(amount, 3, rate))
+
+		assert.Equal(t, "341666.67", q.MonthlyInstallment.String())
+		assert.Equal(t, "341666.66", schedule[2].Amount.String())
+		assert.NoError(t, CheckSchedule(schedule, amount, quote.TotalPayment))
+	})
+
+	t.Run("adjust first", func(t *testing.T) {
+		q, schedule := Rounding{Strategy: RemainderFirst}.Apply(quote, p.Schedule(amount, 3, rate))
+
+		assert.Equal(t, "341666.67", q.MonthlyInstallment.String())
+		assert.Equal(t, "341666.66", schedule[0].Amount.String())
+		assert.NoError(t, CheckSchedule(schedule, amount, quote.TotalPayment))
+	})
+
+	t.Run("round to currency unit", func(t *testing.T) {
+		rounding := Rounding{Strategy: RemainderUnit, Unit: decimal.NewFromInt(100)}
+		q, schedule := rounding.Apply(quote, p.Schedule(amount, 3, rate))
+
+		assert.Equal(t, "341700", q.MonthlyInstallment.String())
+		assert.Equal(t, "341700", schedule[0].Amount.String())
+		assert.Equal(t, "341600", schedule[2].Amount.String())
+		assert.True(t, schedule[2].Principal.Add(schedule[2].Margin).Equal(schedule[2].Amount))
+		assert.NoError(t, CheckSchedule(schedule, amount, quote.TotalPayment))
+	})
+
+	t.Run("round to currency unit without margin", func(t *testing.T) {
+		// 1,000,000 at 0% over 3 months rounds 333,333.33 up to 333,400,
+		// which repays principal early instead of charging negative margin
+		zero := p.Price(amount, 3, decimal.Zero)
+		rounding := Rounding{Strategy: RemainderUnit, Unit: decimal.NewFromInt(100)}
+		q, schedule := rounding.Apply(zero, p.Schedule(amount, 3, decimal.Zero))
+
+		assert.Equal(t, "333400", q.MonthlyInstallment.String())
+		assert.Equal(t, "333400", schedule[0].Principal.String())
+		assert.Equal(t, "333200", schedule[2].Amount.String())
+		assert.Equal(t, "333200", schedule[2].Principal.String())
+		for _, row := range schedule {
+			assert.True(t, row.Margin.IsZero(), "installment %d has margin %s", row.Seq, row.Margin)
+		}
+		assert.NoError(t, CheckSchedule(schedule, amount, zero.TotalPayment))
+	})
+
+	t.Run("small amount over a long tenor is not rounded to the unit", func(t *testing.T) {
+		// 1,000 over 12 months rounds up to 100 a month, leaving nothing for
+		// the last installment, so the installment keeps its unrounded amount
+		small := decimal.NewFromInt(1000)
+		q := p.Price(small, 12, rate)
+		rounding := Rounding{Strategy: RemainderUnit, Unit: decimal.NewFromInt(100)}
+		reconciled, schedule := rounding.Apply(q, p.Schedule(small, 12, rate))
+
+		assert.Equal(t, q.MonthlyInstallment.String(), reconciled.MonthlyInstallment.String())
+		assert.NoError(t, CheckSchedule(schedule, small, q.TotalPayment))
+	})
+}
+
+func TestCheckSchedule(t *testing.T) {
+	amount := decimal.NewFromInt(1000000)
+	rate := decimal.RequireFromString("0.10")
+	quote := NewFlat().Price(amount, 3, rate)
+
+	// the unreconciled schedule overshoots the total payment by 0.01
+	err := CheckSchedule(NewFlat().Schedule(amount, 3, rate), amount, quote.TotalPayment)
+	assert.Error(t, err)
+
+	assert.Error(t, CheckSchedule(nil, amount, quote.TotalPayment))
+
+	// principal moved into the last installment leaves it a negative margin
+	negative := []Installment{
+		{Seq: 1, Amount: decimal.NewFromInt(600), Principal: decimal.NewFromInt(500), Margin: decimal.NewFromInt(100), Outstanding: decimal.NewFromInt(500)},
+		{Seq: 2, Amount: decimal.NewFromInt(400), Principal: decimal.NewFromInt(500), Margin: decimal.NewFromInt(-100)},
+	}
+	err = CheckSchedule(negative, decimal.NewFromInt(1000), decimal.NewFromInt(1000))
+	assert.EqualError(t, err, "pricing: installment 2 has negative margin -100")
+}
@@ -0,0 +1,68 @@
+package pricing
+
+import "github.com/shopspring/decimal"
+
+// Installment is one row of an amortization schedule. Principal and Margin
+// add up to Amount, and Outstanding is the principal still owed once the
+// installment has been paid.
+type Installment struct {
+	Seq         int
+	Amount      decimal.Decimal
+	Principal   decimal.Decimal
+	Margin      decimal.Decimal
+	Outstanding decimal.Decimal
+}
+
+// equalPrincipal splits the amount into tenor equal principal portions and
+// the margin into whatever is left of each installment. The last row absorbs
+// the rounding remainder so the principal always adds up to the amount.
+func equalPrincipal(amount decimal.Decimal, tenor int, quote Quote) []Installment {
+	base := amount.DivRound(decimal.NewFromInt(int64(tenor)), 2)
+
+	schedule := make([]Installment, 0, tenor)
+	outstanding := amount
+	for seq := 1; seq <= tenor; seq++ {
+		principal := base
+		if seq == tenor {
+			principal = outstanding
+		}
+		outstanding = outstanding.Sub(principal)
+
+		schedule = append(schedule, Installment{
+			Seq:         seq,
+			Amount:      quote.MonthlyInstallment,
+			Principal:   principal,
+			Margin:      quote.MonthlyInstallment.Sub(principal),
+			Outstanding: outstanding,
+		})
+	}
+
+	return schedule
+}
+
+// reducingBalance charges the monthly rate on the principal still owed and
+// lets the rest of each installment repay principal. The last row clears
+// whatever balance is left after rounding.
+func reducingBalance(amount decimal.Decimal, tenor int, monthlyRate decimal.Decimal, quote Quote) []Installment {
+	schedule := make([]Installment, 0, tenor)
+	outstanding := amount
+	for seq := 1; seq <= tenor; seq++ {
+		margin := outstanding.Mul(monthlyRate).Round(2)
+		principal := quote.MonthlyInstallment.Sub(margin)
+		if seq == tenor {
+			principal = outstanding
+			margin = quote.MonthlyInstallment.Sub(principal)
+		}
+		outstanding = outstanding.Sub(principal)
+
+		schedule = append(schedule, Installment{
+			Seq:         seq,
+			Amount:      quote.MonthlyInstallment,
+			Principal:   principal,
+			Margin:      margin,
+			Outstanding: outstanding,
+		})
+	}
+
+	return schedule
+}
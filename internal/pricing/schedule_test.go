@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func sumSchedule(schedule []Installment) (principal, margin decimal.Decimal) {
+	principal, margin = decimal.Zero, decimal.Zero
+	for _, row := range schedule {
+		principal = principal.Add(row.Principal)
+		margin = margin.Add(row.Margin)
+	}
+
+	return principal, margin
+}
+
+func TestFlat_Schedule(t *testing.T) {
+	amount := decimal.NewFromInt(1000000)
+	schedule := NewFlat().Schedule(amount, 3, decimal.RequireFromString("0.12"))
+
+	assert.Len(t, schedule, 3)
+	assert.Equal(t, "333333.33", schedule[0].Principal.String())
+	assert.Equal(t, "10000", schedule[0].Margin.String())
+	assert.Equal(t, "666666.67", schedule[0].Outstanding.String())
+	assert.Equal(t, "333333.34", schedule[2].Principal.String())
+	assert.True(t, schedule[2].Outstanding.IsZero())
+
+	principal, _ := sumSchedule(schedule)
+	assert.True(t, principal.Equal(amount))
+	for _, row := range schedule {
+		assert.True(t, row.Principal.Add(row.Margin).Equal(row.Amount))
+	}
+}
+
+func TestAnnuity_Schedule(t *testing.T) {
+	amount := decimal.NewFromInt(10000000)
+	schedule := NewAnnuity().Schedule(amount, 12, decimal.RequireFromString("0.12"))
+
+	assert.Len(t, schedule, 12)
+	// first month margin is 1% of the full amount
+	assert.Equal(t, "100000", schedule[0].Margin.String())
+	assert.Equal(t, "788487.89", schedule[0].Principal.String())
+	assert.True(t, schedule[11].Margin.LessThan(schedule[0].Margin))
+	assert.True(t, schedule[11].Outstanding.IsZero())
+
+	principal, margin := sumSchedule(schedule)
+	assert.True(t, principal.Equal(amount))
+	assert.Equal(t, "661854.68", margin.String())
+}
+
+func TestMurabahah_Schedule(t *testing.T) {
+	amount := decimal.NewFromInt(6000000)
+	schedule := NewMurabahah().Schedule(amount, 6, decimal.RequireFromString("0.10"))
+
+	assert.Len(t, schedule, 6)
+	assert.Equal(t, "1000000", schedule[0].Principal.String())
+	assert.Equal(t, "100000", schedule[0].Margin.String())
+	assert.Equal(t, "5000000", schedule[0].Outstanding.String())
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ApplicationRepository interface {
+	Add(ctx context.Context, app *model.FinancingApplication) (int, error)
+	Get(ctx context.Context, id int) (*model.FinancingApplication, error)
+	GetByFacility(ctx context.Context, facilityID int64) (*model.FinancingApplication, error)
+	List(ctx context.Context, filter *model.ApplicationFilter) ([]*model.FinancingApplication, error)
+	Transition(ctx context.Context, app *model.FinancingApplication, from string) error
+}
+
+type applicationRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewApplicationRepository(db postgres.PgxExecutor) ApplicationRepository {
+	return &applicationRepository{db: db}
+}
+
+func (r *applicationRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *applicationRepository) Add(ctx context.Context, app *model.FinancingApplication) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO financing_applications (user_id, facility_limit_id, amount, tenor, start_date, billing_day, grace_period, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, app.UserID, app.FacilityLimitID, app.Amount, app.Tenor, app.StartDate,
+		app.BillingDay, app.GracePeriod, app.Status, app.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *applicationRepository) Get(ctx context.Context, id int) (*model.FinancingApplication, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM financing_applications WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	app, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.FinancingApplication])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return app, nil
+}
+
+// GetByFacility returns the application that booked the facility, or a not
+// found error for a facility submitted directly.
+func (r *applicationRepository) GetByFacility(ctx context.Context, facilityID int64) (*model.FinancingApplication, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM financing_applications WHERE user_facility_id = $1`
+	rows, err := db.Query(ctx, query, facilityID)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	app, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.FinancingApplication])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return app, nil
+}
+
+// List returns the applications matching the filter, oldest first, so a
+// reviewer works through the queue in the order it was submitted.
+func (r *applicationRepository) List(ctx context.Context, filter *model.ApplicationFilter) ([]*model.FinancingApplication, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		SELECT * FROM financing_applications
+		WHERE ($1::bigint = 0 OR user_id = $1) AND ($2 = '' OR status = $2) AND id > $3
+		ORDER BY id LIMIT $4`
+	rows, err := db.Query(ctx, query, filter.UserID, filter.Status, filter.AfterID, filter.Limit)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	apps, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.FinancingApplication])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return apps, nil
+}
+
+// Transition stores the new status of an application with its review and
+// transition timestamps. It fails when the application left the from status
+// in the meantime, so two transitions can never both apply.
+func (r *applicationRepository) Transition(ctx context.Context, app *model.FinancingApplication, from string) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE financing_applications
+		SET status = $1, user_facility_id = $2, reviewed_by = $3, review_note = $4, submitted_at = $5,
+			review_started_at = $6, approved_at = $7, rejected_at = $8, cancelled_at = $9, disbursed_at = $10
+		WHERE id = $11 AND status = $12`
+	cmd, err := db.Exec(ctx, query, app.Status, app.UserFacilityID, app.ReviewedBy, app.ReviewNote, app.SubmittedAt,
+		app.ReviewStartedAt, app.ApprovedAt, app.RejectedAt, app.CancelledAt, app.DisbursedAt, app.ApplicationID, from)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "application is no longer "+from, nil)
+	}
+
+	return nil
+}
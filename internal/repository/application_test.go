@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var applicationColumns = []string{"id", "user_id", "facility_limit_id", "amount", "tenor", "start_date", "billing_day", "grace_period", "status",
+	"user_facility_id", "reviewed_by", "review_note", "created_at", "submitted_at", "review_started_at", "approved_at", "rejected_at",
+	"cancelled_at", "disbursed_at"}
+
+func TestApplicationRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewApplicationRepository(mock)
+	now := time.Now()
+
+	rows := pgxmock.NewRows(applicationColumns).
+		AddRow(int64(5), int64(1), int64(10), decimal.NewFromInt(3000000), 6, nil, 0, 0, model.ApplicationStatusSubmitted,
+			nil, nil, "", now, &now, nil, nil, nil, nil, nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE ($1::bigint = 0 OR user_id = $1) AND ($2 = '' OR status = $2) AND id > $3 ORDER BY id LIMIT $4")).
+		WithArgs(int64(0), model.ApplicationStatusSubmitted, int64(4), 21).
+		WillReturnRows(rows)
+
+	res, err := repo.List(context.Background(), &model.ApplicationFilter{Status: model.ApplicationStatusSubmitted, AfterID: 4, Limit: 21})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(5), res[0].ApplicationID)
+	assert.Nil(t, res[0].StartDate)
+	assert.NotNil(t, res[0].SubmittedAt)
+}
+
+func TestApplicationRepository_Transition(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewApplicationRepository(mock)
+	query := regexp.QuoteMeta("WHERE id = $11 AND status = $12")
+	now := time.Now()
+	reviewer := "checker"
+	app := &model.FinancingApplication{ApplicationID: 5, Status: model.ApplicationStatusUnderReview, ReviewedBy: &reviewer,
+		SubmittedAt: &now, ReviewStartedAt: &now}
+	args := []any{app.Status, app.UserFacilityID, app.ReviewedBy, app.ReviewNote, app.SubmittedAt, app.ReviewStartedAt,
+		app.ApprovedAt, app.RejectedAt, app.CancelledAt, app.DisbursedAt, app.ApplicationID, model.ApplicationStatusSubmitted}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(args...).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Transition(context.Background(), app, model.ApplicationStatusSubmitted)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Changed Meanwhile", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(args...).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Transition(context.Background(), app, model.ApplicationStatusSubmitted)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: application is no longer submitted", err.Error())
+	})
+}
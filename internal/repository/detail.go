@@ -7,6 +7,7 @@ import (
 	"finance/pkg/errorx"
 	"finance/pkg/postgres"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -14,6 +15,9 @@ import (
 type DetailRepository interface {
 	Add(ctx context.Context, details []*model.UserFacilityDetail) error
 	Get(ctx context.Context, id int) (*model.UserFacilityDetail, error)
+	ListByFacility(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error)
+	ListUnpaid(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error)
+	MarkPaid(ctx context.Context, ids []int64, paymentID int64, paidAt time.Time) error
 }
 
 type detailRepository struct {
@@ -42,13 +46,16 @@ func (r *detailRepository) Add(ctx context.Context, details []*model.UserFacilit
 			d.UserFacilityID,
 			d.DueDate,
 			d.InstallmentAmount,
+			d.PrincipalAmount,
+			d.MarginAmount,
+			d.OutstandingPrincipal,
 		})
 	}
 
 	count, err := db.CopyFrom(
 		ctx,
 		pgx.Identifier{"user_facility_details"},
-		[]string{"user_facility_id", "due_date", "installment_amount"},
+		[]string{"user_facility_id", "due_date", "installment_amount", "principal_amount", "margin_amount", "outstanding_principal"},
 		pgx.CopyFromRows(rows),
 	)
 	if err != nil {
@@ -82,3 +89,52 @@ func (r *detailRepository) Get(ctx context.Context, id int) (*model.UserFacility
 
 	return detail, nil
 }
+
+func (r *detailRepository) ListByFacility(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM user_facility_details WHERE user_facility_id = $1 ORDER BY due_date`
+	rows, err := db.Query(ctx, query, facilityID)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	details, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserFacilityDetail])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return details, nil
+}
+
+func (r *detailRepository) ListUnpaid(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM user_facility_details WHERE user_facility_id = $1 AND status = $2 ORDER BY due_date FOR UPDATE`
+	rows, err := db.Query(ctx, query, facilityID, model.DetailStatusUnpaid)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	details, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserFacilityDetail])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return details, nil
+}
+
+func (r *detailRepository) MarkPaid(ctx context.Context, ids []int64, paymentID int64, paidAt time.Time) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE user_facility_details SET status = $1, payment_id = $2, paid_at = $3 WHERE id = ANY($4) AND status = $5`
+	cmd, err := db.Exec(ctx, query, model.DetailStatusPaid, paymentID, paidAt, ids, model.DetailStatusUnpaid)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if int(cmd.RowsAffected()) != len(ids) {
+		return errorx.DbError(fmt.Errorf("mark paid count mismatch, expected %d got %d", len(ids), cmd.RowsAffected()))
+	}
+
+	return nil
+}
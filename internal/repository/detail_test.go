@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"finance/internal/model"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
@@ -26,7 +28,7 @@ func TestDetailRepository_Add(t *testing.T) {
 
 		mock.ExpectCopyFrom(
 			pgx.Identifier{"user_facility_details"},
-			[]string{"user_facility_id", "due_date", "installment_amount"},
+			[]string{"user_facility_id", "due_date", "installment_amount", "principal_amount", "margin_amount", "outstanding_principal"},
 		).WillReturnResult(2)
 
 		err := repo.Add(context.Background(), details)
@@ -38,10 +40,86 @@ func TestDetailRepository_Add(t *testing.T) {
 
 		mock.ExpectCopyFrom(
 			pgx.Identifier{"user_facility_details"},
-			[]string{"user_facility_id", "due_date", "installment_amount"},
+			[]string{"user_facility_id", "due_date", "installment_amount", "principal_amount", "margin_amount", "outstanding_principal"},
 		).WillReturnError(errors.New("db error"))
 
 		err := repo.Add(context.Background(), details)
 		assert.Error(t, err)
 	})
 }
+
+func TestDetailRepository_ListByFacility(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	repo := NewDetailRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		paidAt := time.Now()
+		paymentID := int64(3)
+		rows := pgxmock.NewRows([]string{"id", "user_facility_id", "due_date", "installment_amount", "principal_amount", "margin_amount", "outstanding_principal", "status", "payment_id", "paid_at"}).
+			AddRow(int64(1), int64(7), time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(90), decimal.NewFromInt(10), decimal.NewFromInt(90), model.DetailStatusPaid, &paymentID, &paidAt).
+			AddRow(int64(2), int64(7), time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(90), decimal.NewFromInt(10), decimal.Zero, model.DetailStatusUnpaid, nil, nil)
+
+		query := regexp.QuoteMeta("SELECT * FROM user_facility_details WHERE user_facility_id = $1 ORDER BY due_date")
+		mock.ExpectQuery(query).
+			WithArgs(7).
+			WillReturnRows(rows)
+
+		res, err := repo.ListByFacility(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(3), *res[0].PaymentID)
+		assert.Nil(t, res[1].PaidAt)
+	})
+}
+
+func TestDetailRepository_ListUnpaid(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	repo := NewDetailRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows([]string{"id", "user_facility_id", "due_date", "installment_amount", "principal_amount", "margin_amount", "outstanding_principal", "status", "payment_id", "paid_at"}).
+			AddRow(int64(1), int64(7), time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(90), decimal.NewFromInt(10), decimal.NewFromInt(90), model.DetailStatusUnpaid, nil, nil).
+			AddRow(int64(2), int64(7), time.Now(), decimal.NewFromInt(100), decimal.NewFromInt(90), decimal.NewFromInt(10), decimal.Zero, model.DetailStatusUnpaid, nil, nil)
+
+		query := regexp.QuoteMeta("SELECT * FROM user_facility_details WHERE user_facility_id = $1 AND status = $2 ORDER BY due_date FOR UPDATE")
+		mock.ExpectQuery(query).
+			WithArgs(7, model.DetailStatusUnpaid).
+			WillReturnRows(rows)
+
+		res, err := repo.ListUnpaid(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Nil(t, res[0].PaidAt)
+	})
+}
+
+func TestDetailRepository_MarkPaid(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	repo := NewDetailRepository(mock)
+
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE user_facility_details").
+			WithArgs(model.DetailStatusPaid, int64(5), now, []int64{1, 2}, model.DetailStatusUnpaid).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+		err := repo.MarkPaid(context.Background(), []int64{1, 2}, 5, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Already Paid", func(t *testing.T) {
+		mock.ExpectExec("UPDATE user_facility_details").
+			WithArgs(model.DetailStatusPaid, int64(5), now, []int64{1, 2}, model.DetailStatusUnpaid).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.MarkPaid(context.Background(), []int64{1, 2}, 5, now)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type DisbursementRepository interface {
+	Add(ctx context.Context, disbursement *model.Disbursement) (int, error)
+	GetByFacility(ctx context.Context, facilityID int64) (*model.Disbursement, error)
+	List(ctx context.Context, status string) ([]*model.Disbursement, error)
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Disbursement, error)
+	Complete(ctx context.Context, disbursement *model.Disbursement) error
+	Reschedule(ctx context.Context, disbursement *model.Disbursement) error
+	Requeue(ctx context.Context, id int, now time.Time) (*model.Disbursement, error)
+}
+
+type disbursementRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewDisbursementRepository(db postgres.PgxExecutor) DisbursementRepository {
+	return &disbursementRepository{db: db}
+}
+
+func (r *disbursementRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *disbursementRepository) Add(ctx context.Context, disbursement *model.Disbursement) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO disbursements (user_facility_id, user_id, amount, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, disbursement.UserFacilityID, disbursement.UserID, disbursement.Amount,
+		disbursement.Status, disbursement.NextAttemptAt, disbursement.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *disbursementRepository) GetByFacility(ctx context.Context, facilityID int64) (*model.Disbursement, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM disbursements WHERE user_facility_id = $1`
+	rows, err := db.Query(ctx, query, facilityID)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	disbursement, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.Disbursement])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return disbursement, nil
+}
+
+func (r *disbursementRepository) List(ctx context.Context, status string) ([]*model.Disbursement, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM disbursements WHERE ($1 = '' OR status = $1) ORDER BY id`
+	rows, err := db.Query(ctx, query, status)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	disbursements, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.Disbursement])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return disbursements, nil
+}
+
+// ClaimDue takes at most limit pending disbursements that are due at or
+// before now, counts the attempt and pushes their next attempt out to
+// leaseUntil. Rows claimed by another worker are skipped, and a worker that
+// dies mid-transfer leaves its rows to be picked up again once the lease
+// runs out.
+func (r *disbursementRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Disbursement, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE disbursements SET attempts = attempts + 1, next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM disbursements
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at, id LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`
+	rows, err := db.Query(ctx, query, model.DisbursementStatusPending, now, leaseUntil, limit)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	disbursements, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.Disbursement])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return disbursements, nil
+}
+
+// Complete moves a pending disbursement to its final status, or to manual
+// review. It fails when the disbursement was completed in the meantime, so a
+// facility is only settled or compensated once.
+func (r *disbursementRepository) Complete(ctx context.Context, disbursement *model.Disbursement) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE disbursements
+		SET status = $1, bank_reference = $2, last_error = $3, completed_at = $4
+		WHERE id = $5 AND status = $6`
+	cmd, err := db.Exec(ctx, query, disbursement.Status, disbursement.BankReference, disbursement.LastError,
+		disbursement.CompletedAt, disbursement.DisbursementID, model.DisbursementStatusPending)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "disbursement is no longer pending", nil)
+	}
+
+	return nil
+}
+
+// Reschedule stores the error of a failed attempt and when to try again.
+func (r *disbursementRepository) Reschedule(ctx context.Context, disbursement *model.Disbursement) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE disbursements SET next_attempt_at = $1, last_error = $2
+		WHERE id = $3 AND status = $4`
+	cmd, err := db.Exec(ctx, query, disbursement.NextAttemptAt, disbursement.LastError,
+		disbursement.DisbursementID, model.DisbursementStatusPending)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "disbursement is no longer pending", nil)
+	}
+
+	return nil
+}
+
+// Requeue puts a disbursement waiting in manual review back to pending with
+// a fresh round of attempts, due at now.
+func (r *disbursementRepository) Requeue(ctx context.Context, id int, now time.Time) (*model.Disbursement, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE disbursements SET status = $1, attempts = 0, next_attempt_at = $2, last_error = ''
+		WHERE id = $3 AND status = $4
+		RETURNING *`
+	rows, err := db.Query(ctx, query, model.DisbursementStatusPending, now, id, model.DisbursementStatusManualReview)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	disbursement, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.Disbursement])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errorx.NewError(errorx.ErrTypeConflict, "disbursement is not waiting for manual review", nil)
+	}
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return disbursement, nil
+}
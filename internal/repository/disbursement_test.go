@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var disbursementColumns = []string{"id", "user_facility_id", "user_id", "amount", "status", "attempts", "next_attempt_at", "last_error",
+	"bank_reference", "created_at", "completed_at"}
+
+func TestDisbursementRepository_ClaimDue(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewDisbursementRepository(mock)
+	now := time.Now()
+	leaseUntil := now.Add(5 * time.Minute)
+
+	rows := pgxmock.NewRows(disbursementColumns).
+		AddRow(int64(1), int64(7), int64(1), decimal.NewFromInt(3000000), model.DisbursementStatusPending, 2, leaseUntil, "bank timeout",
+			nil, now.Add(-time.Hour), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(model.DisbursementStatusPending, now, leaseUntil, 50).
+		WillReturnRows(rows)
+
+	res, err := repo.ClaimDue(context.Background(), now, leaseUntil, 50)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, int64(7), res[0].UserFacilityID)
+	assert.Equal(t, 2, res[0].Attempts)
+	assert.Nil(t, res[0].BankReference)
+}
+
+func TestDisbursementRepository_Complete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewDisbursementRepository(mock)
+	query := regexp.QuoteMeta("WHERE id = $5 AND status = $6")
+	now := time.Now()
+	ref := "FAKE-000001"
+	disbursement := &model.Disbursement{DisbursementID: 1, Status: model.DisbursementStatusSucceeded, BankReference: &ref, CompletedAt: &now}
+	args := []any{disbursement.Status, disbursement.BankReference, disbursement.LastError, disbursement.CompletedAt,
+		disbursement.DisbursementID, model.DisbursementStatusPending}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(args...).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Complete(context.Background(), disbursement)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Already Completed", func(t *testing.T) {
+		mock.ExpectExec(query).WithArgs(args...).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Complete(context.Background(), disbursement)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: disbursement is no longer pending", err.Error())
+	})
+}
+
+func TestDisbursementRepository_Requeue(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewDisbursementRepository(mock)
+	query := regexp.QuoteMeta("WHERE id = $3 AND status = $4")
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows(disbursementColumns).
+			AddRow(int64(1), int64(7), int64(1), decimal.NewFromInt(3000000), model.DisbursementStatusPending, 0, now, "",
+				nil, now.Add(-time.Hour), nil)
+		mock.ExpectQuery(query).
+			WithArgs(model.DisbursementStatusPending, now, 1, model.DisbursementStatusManualReview).
+			WillReturnRows(rows)
+
+		res, err := repo.Requeue(context.Background(), 1, now)
+		assert.NoError(t, err)
+		assert.Equal(t, model.DisbursementStatusPending, res.Status)
+		assert.Equal(t, 0, res.Attempts)
+	})
+
+	t.Run("Not In Manual Review", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(model.DisbursementStatusPending, now, 1, model.DisbursementStatusManualReview).
+			WillReturnRows(pgxmock.NewRows(disbursementColumns))
+
+		res, err := repo.Requeue(context.Background(), 1, now)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: disbursement is not waiting for manual review", err.Error())
+	})
+}
+
+func TestDisbursementRepository_GetByFacility(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewDisbursementRepository(mock)
+	query := regexp.QuoteMeta("SELECT * FROM disbursements WHERE user_facility_id = $1")
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		ref := "FAKE-000001"
+		rows := pgxmock.NewRows(disbursementColumns).
+			AddRow(int64(1), int64(7), int64(1), decimal.NewFromInt(3000000), model.DisbursementStatusSucceeded, 1, now, "",
+				&ref, now.Add(-time.Hour), &now)
+		mock.ExpectQuery(query).WithArgs(int64(7)).WillReturnRows(rows)
+
+		res, err := repo.GetByFacility(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Equal(t, model.DisbursementStatusSucceeded, res.Status)
+		assert.Equal(t, "FAKE-000001", *res.BankReference)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(int64(8)).WillReturnRows(pgxmock.NewRows(disbursementColumns))
+
+		res, err := repo.GetByFacility(context.Background(), 8)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
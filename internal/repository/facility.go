@@ -6,6 +6,8 @@ import (
 	"finance/internal/model"
 	"finance/pkg/errorx"
 	"finance/pkg/postgres"
+	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,6 +15,16 @@ import (
 type FacilityRepository interface {
 	Add(ctx context.Context, facility *model.UserFacility) (int, error)
 	Get(ctx context.Context, id int) (*model.UserFacility, error)
+	List(ctx context.Context, filter *model.FacilityFilter) ([]*model.UserFacility, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+}
+
+// facilitySortColumns maps the sortable columns to the type their cursor
+// value is cast to when building the keyset condition.
+var facilitySortColumns = map[string]string{
+	"created_at": "timestamp",
+	"start_date": "date",
+	"amount":     "numeric",
 }
 
 type facilityRepository struct {
@@ -38,10 +50,10 @@ func (r *facilityRepository) Add(ctx context.Context, facility *model.UserFacili
 	var id int
 
 	query := `
-		INSERT INTO user_facilities (user_id, facility_limit_id, amount, tenor, start_date, monthly_installment, total_margin, total_payment, created_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+		INSERT INTO user_facilities (user_id, facility_limit_id, amount, tenor, start_date, billing_day, grace_period, monthly_installment, total_margin, total_payment, rate, pricing_method, remainder_strategy, rounding_unit, engine_version, created_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) 
 		RETURNING id`
-	err := db.QueryRow(ctx, query, facility.UserID, facility.FacilityLimitID, facility.Amount, facility.Tenor, facility.StartDate, facility.MonthlyInstallment, facility.TotalMargin, facility.TotalPayment, facility.CreatedAt).Scan(&id)
+	err := db.QueryRow(ctx, query, facility.UserID, facility.FacilityLimitID, facility.Amount, facility.Tenor, facility.StartDate, facility.BillingDay, facility.GracePeriod, facility.MonthlyInstallment, facility.TotalMargin, facility.TotalPayment, facility.Rate, facility.PricingMethod, facility.RemainderStrategy, facility.RoundingUnit, facility.EngineVersion, facility.CreatedAt).Scan(&id)
 	if err != nil {
 		return 0, errorx.DbError(err)
 	}
@@ -67,3 +79,86 @@ func (r *facilityRepository) Get(ctx context.Context, id int) (*model.UserFacili
 	}
 	return facility, nil
 }
+
+func (r *facilityRepository) List(ctx context.Context, filter *model.FacilityFilter) ([]*model.UserFacility, error) {
+	db := r.getExecutor(ctx)
+
+	sortBy := filter.SortBy
+	castType, ok := facilitySortColumns[sortBy]
+	if !ok {
+		sortBy, castType = "created_at", facilitySortColumns["created_at"]
+	}
+
+	direction, comparator := "ASC", ">"
+	if filter.SortDesc {
+		direction, comparator = "DESC", "<"
+	}
+
+	conds := []string{}
+	args := []any{}
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCond("user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCond("status = $%d", filter.Status)
+	}
+	if filter.Tenor != 0 {
+		addCond("tenor = $%d", filter.Tenor)
+	}
+	if filter.StartFrom != nil {
+		addCond("start_date >= $%d", *filter.StartFrom)
+	}
+	if filter.StartTo != nil {
+		addCond("start_date <= $%d", *filter.StartTo)
+	}
+	if filter.MinAmount != nil {
+		addCond("amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCond("amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.Value, filter.Cursor.ID)
+		conds = append(conds, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", sortBy, comparator, len(args)-1, castType, len(args)))
+	}
+
+	query := `SELECT * FROM user_facilities`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", sortBy, direction, direction, len(args))
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	facilities, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserFacility])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return facilities, nil
+}
+
+func (r *facilityRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE user_facilities SET status = $1 WHERE id = $2`
+	cmd, err := db.Exec(ctx, query, status, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(errors.New("no rows updated"))
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"finance/internal/model"
+	"regexp"
 	"testing"
 	"time"
 
@@ -26,9 +27,15 @@ func TestFacilityRepository_Add(t *testing.T) {
 			Amount:             decimal.NewFromInt(1000000),
 			Tenor:              6,
 			StartDate:          now,
+			BillingDay:         5,
 			MonthlyInstallment: decimal.NewFromInt(1000000),
 			TotalMargin:        decimal.NewFromInt(1000000),
 			TotalPayment:       decimal.NewFromInt(1000000),
+			Rate:               decimal.RequireFromString("0.20"),
+			PricingMethod:      "flat",
+			RemainderStrategy:  "unit",
+			RoundingUnit:       decimal.NewFromInt(100),
+			EngineVersion:      "1.0.0",
 			CreatedAt:          now,
 		}
 
@@ -40,9 +47,16 @@ func TestFacilityRepository_Add(t *testing.T) {
 				facility.Amount,
 				facility.Tenor,
 				facility.StartDate,
+				facility.BillingDay,
+				facility.GracePeriod,
 				facility.MonthlyInstallment,
 				facility.TotalMargin,
 				facility.TotalPayment,
+				facility.Rate,
+				facility.PricingMethod,
+				facility.RemainderStrategy,
+				facility.RoundingUnit,
+				facility.EngineVersion,
 				facility.CreatedAt).
 			WillReturnRows(rows)
 
@@ -51,3 +65,68 @@ func TestFacilityRepository_Add(t *testing.T) {
 		assert.Equal(t, 10, id)
 	})
 }
+
+func TestFacilityRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewFacilityRepository(mock)
+
+	columns := []string{"id", "user_id", "facility_limit_id", "amount", "tenor", "start_date", "billing_day", "grace_period", "monthly_installment", "total_margin", "total_payment", "rate", "pricing_method", "remainder_strategy", "rounding_unit", "engine_version", "status", "created_at"}
+
+	t.Run("Success With Filters And Cursor", func(t *testing.T) {
+		now := time.Now()
+		minAmount := decimal.NewFromInt(1000000)
+		filter := &model.FacilityFilter{
+			UserID:    1,
+			Status:    model.FacilityStatusActive,
+			MinAmount: &minAmount,
+			SortBy:    "amount",
+			SortDesc:  true,
+			Cursor:    &model.FacilityCursor{Value: "5000000", ID: 9},
+			Limit:     11,
+		}
+
+		rows := pgxmock.NewRows(columns).
+			AddRow(int64(8), int64(1), int64(1), decimal.NewFromInt(4000000), 6, now, 0, 0, decimal.NewFromInt(1), decimal.NewFromInt(1), decimal.NewFromInt(1), decimal.RequireFromString("0.20"), "flat", "unit", decimal.NewFromInt(100), "1.0.0", model.FacilityStatusActive, now)
+
+		query := regexp.QuoteMeta("SELECT * FROM user_facilities WHERE user_id = $1 AND status = $2 AND amount >= $3 AND (amount, id) < ($4::numeric, $5) ORDER BY amount DESC, id DESC LIMIT $6")
+		mock.ExpectQuery(query).
+			WithArgs(int64(1), model.FacilityStatusActive, minAmount, "5000000", int64(9), 11).
+			WillReturnRows(rows)
+
+		res, err := repo.List(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, int64(8), res[0].UserFacilityID)
+	})
+
+	t.Run("Default Sort Without Filters", func(t *testing.T) {
+		query := regexp.QuoteMeta("SELECT * FROM user_facilities ORDER BY created_at ASC, id ASC LIMIT $1")
+		mock.ExpectQuery(query).
+			WithArgs(21).
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		res, err := repo.List(context.Background(), &model.FacilityFilter{SortBy: "unknown; drop table users", Limit: 21})
+		assert.NoError(t, err)
+		assert.Len(t, res, 0)
+	})
+}
+
+func TestFacilityRepository_UpdateStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewFacilityRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE user_facilities").
+			WithArgs(model.FacilityStatusPaidOff, 7).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.UpdateStatus(context.Background(), 7, model.FacilityStatusPaidOff)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type HoldRepository interface {
+	Add(ctx context.Context, hold *model.LimitHold) (int, error)
+	Get(ctx context.Context, id int) (*model.LimitHold, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.LimitHold, error)
+	Resolve(ctx context.Context, hold *model.LimitHold) error
+}
+
+type holdRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewHoldRepository(db postgres.PgxExecutor) HoldRepository {
+	return &holdRepository{db: db}
+}
+
+func (r *holdRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *holdRepository) Add(ctx context.Context, hold *model.LimitHold) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO limit_holds (facility_limit_id, user_id, amount, reference, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, hold.FacilityLimitID, hold.UserID, hold.Amount, hold.Reference, hold.Status, hold.ExpiresAt, hold.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *holdRepository) Get(ctx context.Context, id int) (*model.LimitHold, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM limit_holds WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	hold, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.LimitHold])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return hold, nil
+}
+
+// ListExpired returns at most limit active holds that expired at or before
+// now, the oldest first.
+func (r *holdRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.LimitHold, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		SELECT * FROM limit_holds
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at, id LIMIT $3`
+	rows, err := db.Query(ctx, query, model.HoldStatusActive, now, limit)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	holds, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.LimitHold])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return holds, nil
+}
+
+// Resolve moves an active hold to its final status. It fails when the hold
+// was resolved in the meantime, so a hold is only captured or given back once.
+func (r *holdRepository) Resolve(ctx context.Context, hold *model.LimitHold) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE limit_holds
+		SET status = $1, user_facility_id = $2, application_id = $3, resolved_at = $4
+		WHERE id = $5 AND status = $6`
+	cmd, err := db.Exec(ctx, query, hold.Status, hold.UserFacilityID, hold.ApplicationID, hold.ResolvedAt, hold.HoldID, model.HoldStatusActive)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "limit hold is no longer active", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var holdColumns = []string{"id", "facility_limit_id", "user_id", "amount", "reference", "status", "user_facility_id", "application_id", "expires_at", "created_at", "resolved_at"}
+
+func TestHoldRepository_ListExpired(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHoldRepository(mock)
+	now := time.Now()
+
+	rows := pgxmock.NewRows(holdColumns).
+		AddRow(int64(1), int64(10), int64(1), decimal.NewFromInt(3000000), "order-1", model.HoldStatusActive, nil, nil, now.Add(-time.Minute), now.Add(-16*time.Minute), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE status = $1 AND expires_at <= $2")).
+		WithArgs(model.HoldStatusActive, now, 100).
+		WillReturnRows(rows)
+
+	res, err := repo.ListExpired(context.Background(), now, 100)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "order-1", res[0].Reference)
+	assert.Nil(t, res[0].UserFacilityID)
+}
+
+func TestHoldRepository_Resolve(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHoldRepository(mock)
+	query := regexp.QuoteMeta("WHERE id = $5 AND status = $6")
+	now := time.Now()
+	hold := &model.LimitHold{HoldID: 1, Status: model.HoldStatusReleased, ResolvedAt: &now}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(hold.Status, hold.UserFacilityID, hold.ApplicationID, hold.ResolvedAt, hold.HoldID, model.HoldStatusActive).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.Resolve(context.Background(), hold)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Already Resolved", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(hold.Status, hold.UserFacilityID, hold.ApplicationID, hold.ResolvedAt, hold.HoldID, model.HoldStatusActive).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.Resolve(context.Background(), hold)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: limit hold is no longer active", err.Error())
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type HolidayRepository interface {
+	List(ctx context.Context, from, to time.Time) ([]*model.Holiday, error)
+	Add(ctx context.Context, holiday *model.Holiday) (int, error)
+	Upsert(ctx context.Context, holiday *model.Holiday) error
+	Delete(ctx context.Context, id int) error
+}
+
+type holidayRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewHolidayRepository(db postgres.PgxExecutor) HolidayRepository {
+	return &holidayRepository{db: db}
+}
+
+func (r *holidayRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+// List returns the holidays between from and to, both inclusive.
+func (r *holidayRepository) List(ctx context.Context, from, to time.Time) ([]*model.Holiday, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM holidays WHERE holiday_date BETWEEN $1 AND $2 ORDER BY holiday_date`
+	rows, err := db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	holidays, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.Holiday])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return holidays, nil
+}
+
+func (r *holidayRepository) Add(ctx context.Context, holiday *model.Holiday) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO holidays (holiday_date, name, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, holiday.HolidayDate, holiday.Name, holiday.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+// Upsert inserts the holiday or renames the one already stored on its date.
+func (r *holidayRepository) Upsert(ctx context.Context, holiday *model.Holiday) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO holidays (holiday_date, name, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (holiday_date) DO UPDATE SET name = EXCLUDED.name`
+	_, err := db.Exec(ctx, query, holiday.HolidayDate, holiday.Name, holiday.CreatedAt)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
+
+func (r *holidayRepository) Delete(ctx context.Context, id int) error {
+	db := r.getExecutor(ctx)
+
+	query := `DELETE FROM holidays WHERE id = $1`
+	cmd, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
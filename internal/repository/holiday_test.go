@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHolidayRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHolidayRepository(mock)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows([]string{"id", "holiday_date", "name", "created_at"}).
+			AddRow(int64(1), time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), "Hari Kemerdekaan RI", time.Now())
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM holidays WHERE holiday_date BETWEEN $1 AND $2 ORDER BY holiday_date")).
+			WithArgs(from, to).
+			WillReturnRows(rows)
+
+		res, err := repo.List(context.Background(), from, to)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "Hari Kemerdekaan RI", res[0].Name)
+	})
+}
+
+func TestHolidayRepository_Upsert(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHolidayRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		holiday := &model.Holiday{HolidayDate: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan RI", CreatedAt: time.Now()}
+
+		mock.ExpectExec(regexp.QuoteMeta("ON CONFLICT (holiday_date) DO UPDATE SET name = EXCLUDED.name")).
+			WithArgs(holiday.HolidayDate, holiday.Name, holiday.CreatedAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := repo.Upsert(context.Background(), holiday)
+		assert.NoError(t, err)
+	})
+}
+
+func TestHolidayRepository_Delete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHolidayRepository(mock)
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM holidays").
+			WithArgs(9).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		err := repo.Delete(context.Background(), 9)
+		assert.Error(t, err)
+	})
+}
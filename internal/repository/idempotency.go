@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type IdempotencyRepository interface {
+	Lock(ctx context.Context, userID int64, key string) (bool, error)
+	Get(ctx context.Context, userID int64, key string) (*model.IdempotencyRecord, error)
+	Add(ctx context.Context, record *model.IdempotencyRecord) error
+}
+
+type idempotencyRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewIdempotencyRepository(db postgres.PgxExecutor) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+// Lock takes a transaction scoped advisory lock on the key without waiting
+// and reports whether it was taken. It must run inside a transaction; the
+// lock is released on commit or rollback.
+func (r *idempotencyRepository) Lock(ctx context.Context, userID int64, key string) (bool, error) {
+	db := r.getExecutor(ctx)
+
+	var locked bool
+
+	query := `SELECT pg_try_advisory_xact_lock(hashtextextended('idempotency:' || $1::text || ':' || $2, 0))`
+	err := db.QueryRow(ctx, query, userID, key).Scan(&locked)
+	if err != nil {
+		return false, errorx.DbError(err)
+	}
+
+	return locked, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID int64, key string) (*model.IdempotencyRecord, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	rows, err := db.Query(ctx, query, userID, key)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	record, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.IdempotencyRecord])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return record, nil
+}
+
+func (r *idempotencyRepository) Add(ctx context.Context, record *model.IdempotencyRecord) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, response, user_facility_id, application_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.Exec(ctx, query, record.UserID, record.Key, record.RequestHash, record.Response, record.UserFacilityID,
+		record.ApplicationID, record.CreatedAt)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository_Lock(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(mock)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_xact_lock(")).
+		WithArgs(int64(1), "order-42").
+		WillReturnRows(pgxmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+
+	locked, err := repo.Lock(context.Background(), 1, "order-42")
+	assert.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestIdempotencyRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(mock)
+	query := regexp.QuoteMeta("SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2")
+	columns := []string{"id", "user_id", "key", "request_hash", "response", "user_facility_id", "application_id", "created_at"}
+
+	t.Run("Success", func(t *testing.T) {
+		facilityID := int64(7)
+		mock.ExpectQuery(query).
+			WithArgs(int64(1), "order-42").
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(int64(3), int64(1), "order-42", "abc", []byte(`{"user_facility_id":7}`), &facilityID, nil, time.Now()))
+
+		res, err := repo.Get(context.Background(), 1, "order-42")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), *res.UserFacilityID)
+		assert.Nil(t, res.ApplicationID)
+		assert.JSONEq(t, `{"user_facility_id":7}`, string(res.Response))
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(int64(1), "order-43").
+			WillReturnError(pgx.ErrNoRows)
+
+		res, err := repo.Get(context.Background(), 1, "order-43")
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
+
+func TestIdempotencyRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(mock)
+	facilityID := int64(7)
+	record := &model.IdempotencyRecord{UserID: 1, Key: "order-42", RequestHash: "abc", Response: []byte(`{}`), UserFacilityID: &facilityID, CreatedAt: time.Now()}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+			WithArgs(int64(1), "order-42", "abc", []byte(`{}`), record.UserFacilityID, record.ApplicationID, record.CreatedAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := repo.Add(context.Background(), record)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Duplicate Key", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO idempotency_keys")).
+			WithArgs(int64(1), "order-42", "abc", []byte(`{}`), record.UserFacilityID, record.ApplicationID, record.CreatedAt).
+			WillReturnError(&pgconn.PgError{Code: "23505", Detail: "Key (user_id, key)=(1, order-42) already exists."})
+
+		err := repo.Add(context.Background(), record)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "resource already exists")
+	})
+}
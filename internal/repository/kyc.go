@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type KYCRepository interface {
+	Get(ctx context.Context, userID int) (*model.KYC, error)
+	List(ctx context.Context, status string) ([]*model.KYC, error)
+	Submit(ctx context.Context, kyc *model.KYC) (int, error)
+	MarkReviewed(ctx context.Context, kyc *model.KYC) error
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+type kycRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewKYCRepository(db postgres.PgxExecutor) KYCRepository {
+	return &kycRepository{db: db}
+}
+
+func (r *kycRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *kycRepository) Get(ctx context.Context, userID int) (*model.KYC, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM user_kyc WHERE user_id = $1`
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	kyc, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.KYC])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return kyc, nil
+}
+
+// List returns the verifications with the given status, or all of them when
+// status is empty, the oldest submission first.
+func (r *kycRepository) List(ctx context.Context, status string) ([]*model.KYC, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM user_kyc WHERE ($1 = '' OR status = $1) ORDER BY submitted_at, id`
+	rows, err := db.Query(ctx, query, status)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	kycs, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.KYC])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return kycs, nil
+}
+
+// Submit records a pending verification of the user. A rejected or expired
+// verification is replaced; a pending or verified one is left as it is and
+// fails with a conflict. A NIK already used by another user fails on the
+// unique constraint.
+func (r *kycRepository) Submit(ctx context.Context, kyc *model.KYC) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO user_kyc (user_id, nik, full_name, birth_date, status, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET nik = EXCLUDED.nik, full_name = EXCLUDED.full_name, birth_date = EXCLUDED.birth_date,
+			status = EXCLUDED.status, submitted_at = EXCLUDED.submitted_at,
+			reject_reason = '', reviewed_by = NULL, reviewed_at = NULL, expires_at = NULL
+		WHERE user_kyc.status IN ($7, $8)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, kyc.UserID, kyc.NIK, kyc.FullName, kyc.BirthDate, kyc.Status, kyc.SubmittedAt,
+		model.KYCStatusRejected, model.KYCStatusExpired).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errorx.NewError(errorx.ErrTypeConflict, "kyc is already pending or verified", nil)
+		}
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+// MarkReviewed records the review of a pending verification. It fails when
+// the verification was reviewed in the meantime.
+func (r *kycRepository) MarkReviewed(ctx context.Context, kyc *model.KYC) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE user_kyc
+		SET status = $1, reject_reason = $2, reviewed_by = $3, reviewed_at = $4, expires_at = $5
+		WHERE user_id = $6 AND status = $7`
+	cmd, err := db.Exec(ctx, query, kyc.Status, kyc.RejectReason, kyc.ReviewedBy, kyc.ReviewedAt, kyc.ExpiresAt,
+		kyc.UserID, model.KYCStatusPending)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "kyc has already been reviewed", nil)
+	}
+
+	return nil
+}
+
+// Expire marks the verifications that expired at or before now and returns
+// how many were marked.
+func (r *kycRepository) Expire(ctx context.Context, now time.Time) (int64, error) {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE user_kyc SET status = $1 WHERE status = $2 AND expires_at <= $3`
+	cmd, err := db.Exec(ctx, query, model.KYCStatusExpired, model.KYCStatusVerified, now)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return cmd.RowsAffected(), nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKYCRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewKYCRepository(mock)
+	expiresAt := time.Now().AddDate(2, 0, 0)
+	reviewer := "checker"
+
+	rows := pgxmock.NewRows([]string{"id", "user_id", "nik", "full_name", "birth_date", "status", "reject_reason",
+		"submitted_at", "reviewed_by", "reviewed_at", "expires_at"}).
+		AddRow(int64(1), int64(1), "3201011708900001", "Khabib Nurmagomedov", time.Date(1990, 8, 17, 0, 0, 0, 0, time.UTC),
+			model.KYCStatusVerified, "", time.Now(), &reviewer, &expiresAt, &expiresAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM user_kyc WHERE user_id = $1")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	res, err := repo.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.KYCStatusVerified, res.Status)
+	assert.Equal(t, "checker", *res.ReviewedBy)
+}
+
+func TestKYCRepository_Submit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewKYCRepository(mock)
+	query := regexp.QuoteMeta("ON CONFLICT (user_id) DO UPDATE")
+	kyc := &model.KYC{UserID: 1, NIK: "3201011708900001", FullName: "Khabib Nurmagomedov", BirthDate: time.Date(1990, 8, 17, 0, 0, 0, 0, time.UTC),
+		Status: model.KYCStatusPending, SubmittedAt: time.Now()}
+	args := []any{kyc.UserID, kyc.NIK, kyc.FullName, kyc.BirthDate, kyc.Status, kyc.SubmittedAt, model.KYCStatusRejected, model.KYCStatusExpired}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(args...).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+
+		id, err := repo.Submit(context.Background(), kyc)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, id)
+	})
+
+	t.Run("Already Pending Or Verified", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(args...).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.Submit(context.Background(), kyc)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: kyc is already pending or verified", err.Error())
+	})
+}
+
+func TestKYCRepository_Expire(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewKYCRepository(mock)
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE user_kyc SET status = $1 WHERE status = $2 AND expires_at <= $3")).
+		WithArgs(model.KYCStatusExpired, model.KYCStatusVerified, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	expired, err := repo.Expire(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), expired)
+}
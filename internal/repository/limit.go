@@ -6,13 +6,25 @@ import (
 	"finance/internal/model"
 	"finance/pkg/errorx"
 	"finance/pkg/postgres"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 )
 
 type LimitRepository interface {
-	Get(ctx context.Context, userID int) (*model.UserFacilityLimit, error)
-	Update(ctx context.Context, id int, amount int64) error
+	GetByID(ctx context.Context, id int) (*model.UserFacilityLimit, error)
+	ListByUser(ctx context.Context, userID int) ([]*model.UserFacilityLimit, error)
+	ListUserLimits(ctx context.Context, filter *model.UserLimitFilter) ([]*model.UserLimitRow, error)
+	Add(ctx context.Context, limit *model.UserFacilityLimit) (int, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+	UpdateValidity(ctx context.Context, id int, validUntil, nextReviewDate time.Time) error
+	ListDueForReview(ctx context.Context, on time.Time) ([]*model.UserFacilityLimit, error)
+	Post(ctx context.Context, entry *model.LimitLedgerEntry) error
+	ListLedger(ctx context.Context, limitID int, before int64, limit int) ([]*model.LimitLedgerEntry, error)
+	LedgerBalance(ctx context.Context, limitID int) (decimal.Decimal, error)
 }
 
 type limitRepository struct {
@@ -32,40 +44,251 @@ func (r *limitRepository) getExecutor(ctx context.Context) postgres.PgxExecutor
 	return r.db
 }
 
-func (r *limitRepository) Get(ctx context.Context, userID int) (*model.UserFacilityLimit, error) {
+func (r *limitRepository) GetByID(ctx context.Context, id int) (*model.UserFacilityLimit, error) {
 	db := r.getExecutor(ctx)
 
-	query := `SELECT * FROM user_facility_limits WHERE user_id = $1`
-	rows, err := db.Query(ctx, query, userID)
+	query := `SELECT * FROM user_facility_limits WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.DbError(err)
-		}
 		return nil, errorx.DbError(err)
 	}
 
 	limit, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.UserFacilityLimit])
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errorx.DbError(err)
-		}
 		return nil, errorx.DbError(err)
 	}
 
 	return limit, nil
 }
 
-func (r *limitRepository) Update(ctx context.Context, id int, amount int64) error {
+// ListByUser returns every limit of the user, one per product.
+func (r *limitRepository) ListByUser(ctx context.Context, userID int) ([]*model.UserFacilityLimit, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM user_facility_limits WHERE user_id = $1 ORDER BY product_id`
+	rows, err := db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	limits, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserFacilityLimit])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return limits, nil
+}
+
+// ListUserLimits lists users with their limits and what is held on them by
+// active holds and pending applications in one query, ordered by user and
+// limit. A user without a limit is returned once
+// with the limit columns left nil.
+func (r *limitRepository) ListUserLimits(ctx context.Context, filter *model.UserLimitFilter) ([]*model.UserLimitRow, error) {
+	db := r.getExecutor(ctx)
+
+	args := []any{model.HoldStatusActive, []string{model.ApplicationStatusSubmitted, model.ApplicationStatusUnderReview}}
+	conds := []string{}
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		addCond("u.id = $%d", filter.UserID)
+	}
+	switch {
+	case filter.Search != "" && filter.Phone != "":
+		args = append(args, "%"+escapeLike(filter.Search)+"%", "%"+escapeLike(filter.Phone)+"%")
+		conds = append(conds, fmt.Sprintf("(u.name ILIKE $%d OR u.phone LIKE $%d)", len(args)-1, len(args)))
+	case filter.Search != "":
+		addCond("u.name ILIKE $%d", "%"+escapeLike(filter.Search)+"%")
+	case filter.Phone != "":
+		addCond("u.phone LIKE $%d", "%"+escapeLike(filter.Phone)+"%")
+	}
+	if filter.MinAvailable != nil {
+		addCond("COALESCE(l.limit_amount, 0) >= $%d", *filter.MinAvailable)
+	}
+	if filter.MaxAvailable != nil {
+		addCond("COALESCE(l.limit_amount, 0) <= $%d", *filter.MaxAvailable)
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.UserID, filter.Cursor.LimitID)
+		conds = append(conds, fmt.Sprintf("(u.id, COALESCE(l.id, 0)) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT u.id AS user_id, u.name, u.phone, l.id AS limit_id, l.product_id, l.limit_amount,
+			COALESCE(h.amount, 0) AS held_amount, l.status, l.valid_until
+		FROM users u
+		LEFT JOIN user_facility_limits l ON l.user_id = u.id
+		LEFT JOIN (
+			SELECT facility_limit_id, SUM(amount) AS amount FROM (
+				SELECT facility_limit_id, amount FROM limit_holds WHERE status = $1
+				UNION ALL
+				SELECT facility_limit_id, amount FROM financing_applications WHERE status = ANY($2)
+			) held GROUP BY facility_limit_id
+		) h ON h.facility_limit_id = l.id`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY u.id, COALESCE(l.id, 0) LIMIT $%d", len(args))
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	limits, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserLimitRow])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return limits, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a search term, so it is matched
+// literally.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+// Add opens a limit. Its balance should be funded through Post so the
+// opening amount is recorded in the ledger.
+func (r *limitRepository) Add(ctx context.Context, limit *model.UserFacilityLimit) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO user_facility_limits (user_id, product_id, limit_amount, status, valid_from, valid_until, next_review_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, limit.UserID, limit.ProductID, limit.LimitAmount, limit.Status,
+		limit.ValidFrom, limit.ValidUntil, limit.NextReviewDate).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *limitRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE user_facility_limits SET status = $1 WHERE id = $2`
+	cmd, err := db.Exec(ctx, query, status, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *limitRepository) UpdateValidity(ctx context.Context, id int, validUntil, nextReviewDate time.Time) error {
 	db := r.getExecutor(ctx)
 
-	query := `UPDATE user_facility_limits SET limit_amount = $1 WHERE id = $2`
-	cmd, err := db.Exec(ctx, query, amount, id)
+	query := `UPDATE user_facility_limits SET valid_until = $1, next_review_date = $2 WHERE id = $3`
+	cmd, err := db.Exec(ctx, query, validUntil, nextReviewDate, id)
 	if err != nil {
 		return errorx.DbError(err)
 	}
 	if cmd.RowsAffected() == 0 {
-		return errorx.DbError(errors.New("no rows updated"))
+		return errorx.DbError(pgx.ErrNoRows)
 	}
 
 	return nil
 }
+
+// ListDueForReview returns the limits that are not closed and whose review
+// date is on or before the given day, the most overdue first.
+func (r *limitRepository) ListDueForReview(ctx context.Context, on time.Time) ([]*model.UserFacilityLimit, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		SELECT * FROM user_facility_limits
+		WHERE status <> $1 AND next_review_date <= $2
+		ORDER BY next_review_date, id`
+	rows, err := db.Query(ctx, query, model.LimitStatusClosed, on)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	limits, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.UserFacilityLimit])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return limits, nil
+}
+
+// Post applies a ledger entry to the cached limit_amount and records it in
+// the same statement, filling in the entry id, balance and creation time. A
+// movement that would take the balance below zero is rejected, so concurrent
+// drawdowns on the same limit are serialized by the row lock and can never
+// overdraw it.
+func (r *limitRepository) Post(ctx context.Context, entry *model.LimitLedgerEntry) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		WITH updated AS (
+			UPDATE user_facility_limits SET limit_amount = limit_amount + $2
+			WHERE id = $1 AND limit_amount + $2 >= 0
+			RETURNING id, limit_amount
+		)
+		INSERT INTO limit_ledger (facility_limit_id, entry_type, amount, balance_after, reference_type, reference_id, note, created_at)
+		SELECT id, $3, $2, limit_amount, $4, $5, $6, $7 FROM updated
+		RETURNING id, balance_after`
+	err := db.QueryRow(ctx, query, entry.FacilityLimitID, entry.Amount, entry.EntryType, entry.ReferenceType, entry.ReferenceID, entry.Note, entry.CreatedAt).
+		Scan(&entry.EntryID, &entry.BalanceAfter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) && entry.Amount.IsNegative() {
+			return errorx.NewError(errorx.ErrInsufficientLimit, "limit balance is not enough", nil)
+		}
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
+
+// ListLedger returns the newest entries of a limit first. A non-zero before
+// only returns entries older than that entry id.
+func (r *limitRepository) ListLedger(ctx context.Context, limitID int, before int64, limit int) ([]*model.LimitLedgerEntry, error) {
+	db := r.getExecutor(ctx)
+
+	query := `
+		SELECT * FROM limit_ledger
+		WHERE facility_limit_id = $1 AND ($2::bigint = 0 OR id < $2)
+		ORDER BY id DESC LIMIT $3`
+	rows, err := db.Query(ctx, query, limitID, before, limit)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	entries, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.LimitLedgerEntry])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return entries, nil
+}
+
+// LedgerBalance derives the balance of a limit from its ledger, to check it
+// against the cached limit_amount.
+func (r *limitRepository) LedgerBalance(ctx context.Context, limitID int) (decimal.Decimal, error) {
+	db := r.getExecutor(ctx)
+
+	var balance decimal.Decimal
+
+	query := `SELECT COALESCE(SUM(amount), 0) FROM limit_ledger WHERE facility_limit_id = $1`
+	err := db.QueryRow(ctx, query, limitID).Scan(&balance)
+	if err != nil {
+		return decimal.Zero, errorx.DbError(err)
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type LimitChangeRepository interface {
+	Add(ctx context.Context, change *model.LimitChange) (int, error)
+	Get(ctx context.Context, id int) (*model.LimitChange, error)
+	List(ctx context.Context, status string) ([]*model.LimitChange, error)
+	MarkReviewed(ctx context.Context, change *model.LimitChange) error
+}
+
+type limitChangeRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewLimitChangeRepository(db postgres.PgxExecutor) LimitChangeRepository {
+	return &limitChangeRepository{db: db}
+}
+
+func (r *limitChangeRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *limitChangeRepository) Add(ctx context.Context, change *model.LimitChange) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO limit_changes (action, user_id, product_id, facility_limit_id, amount, reason, status, requested_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, change.Action, change.UserID, change.ProductID, change.FacilityLimitID, change.Amount,
+		change.Reason, change.Status, change.RequestedBy, change.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *limitChangeRepository) Get(ctx context.Context, id int) (*model.LimitChange, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM limit_changes WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	change, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.LimitChange])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return change, nil
+}
+
+// List returns the changes with the given status, oldest first. An empty
+// status lists every change.
+func (r *limitChangeRepository) List(ctx context.Context, status string) ([]*model.LimitChange, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM limit_changes WHERE ($1 = '' OR status = $1) ORDER BY id`
+	rows, err := db.Query(ctx, query, status)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	changes, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.LimitChange])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return changes, nil
+}
+
+// MarkReviewed records the review of a pending change. It fails when the
+// change was reviewed in the meantime, so a change can only be approved once.
+func (r *limitChangeRepository) MarkReviewed(ctx context.Context, change *model.LimitChange) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE limit_changes
+		SET status = $1, facility_limit_id = $2, reviewed_by = $3, review_note = $4, reviewed_at = $5
+		WHERE id = $6 AND status = $7`
+	cmd, err := db.Exec(ctx, query, change.Status, change.FacilityLimitID, change.ReviewedBy, change.ReviewNote, change.ReviewedAt,
+		change.ChangeID, model.ChangeStatusPending)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.NewError(errorx.ErrTypeConflict, "limit change has already been reviewed", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitChangeRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitChangeRepository(mock)
+
+	t.Run("Pending", func(t *testing.T) {
+		amount := decimal.NewFromInt(5000000)
+		limitID := int64(4)
+		rows := pgxmock.NewRows([]string{"id", "action", "user_id", "product_id", "facility_limit_id", "amount", "reason", "status",
+			"requested_by", "reviewed_by", "review_note", "created_at", "reviewed_at"}).
+			AddRow(int64(1), model.LimitActionRaise, int64(1), int64(1), &limitID, &amount, "salary increase", model.ChangeStatusPending,
+				"maker", nil, "", time.Now(), nil)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM limit_changes WHERE ($1 = '' OR status = $1) ORDER BY id")).
+			WithArgs(model.ChangeStatusPending).
+			WillReturnRows(rows)
+
+		res, err := repo.List(context.Background(), model.ChangeStatusPending)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "5000000", res[0].Amount.String())
+		assert.Nil(t, res[0].ReviewedBy)
+	})
+}
+
+func TestLimitChangeRepository_MarkReviewed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitChangeRepository(mock)
+	query := regexp.QuoteMeta("WHERE id = $6 AND status = $7")
+	reviewer := "checker"
+	now := time.Now()
+	change := &model.LimitChange{ChangeID: 1, Status: model.ChangeStatusApproved, ReviewedBy: &reviewer, ReviewedAt: &now}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(change.Status, change.FacilityLimitID, change.ReviewedBy, change.ReviewNote, change.ReviewedAt, change.ChangeID, model.ChangeStatusPending).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.MarkReviewed(context.Background(), change)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Already Reviewed", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(change.Status, change.FacilityLimitID, change.ReviewedBy, change.ReviewNote, change.ReviewedAt, change.ChangeID, model.ChangeStatusPending).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.MarkReviewed(context.Background(), change)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: limit change has already been reviewed", err.Error())
+	})
+}
@@ -2,50 +2,158 @@ package repository
 
 import (
 	"context"
+	"finance/internal/model"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestLimitRepository_Get(t *testing.T) {
+var (
+	limitColumns = []string{"id", "user_id", "product_id", "limit_amount", "status", "valid_from", "valid_until", "next_review_date"}
+	validFrom    = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	validUntil   = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextReview   = time.Date(2026, 12, 2, 0, 0, 0, 0, time.UTC)
+)
+
+func TestLimitRepository_GetByID(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer mock.Close()
 
 	repo := NewLimitRepository(mock)
+	query := regexp.QuoteMeta("SELECT * FROM user_facility_limits WHERE id = $1")
 
 	t.Run("Success", func(t *testing.T) {
-		rows := pgxmock.NewRows([]string{"id", "user_id", "limit_amount"}).
-			AddRow(1, 10, decimal.NewFromInt(10000000))
+		rows := pgxmock.NewRows(limitColumns).
+			AddRow(int64(1), int64(10), int64(2), decimal.NewFromInt(10000000), "active", validFrom, validUntil, nextReview)
 
-		query := regexp.QuoteMeta("SELECT * FROM user_facility_limits WHERE user_id = $1")
 		mock.ExpectQuery(query).
-			WithArgs(10).
+			WithArgs(1).
 			WillReturnRows(rows)
 
-		res, err := repo.Get(context.Background(), 10)
+		res, err := repo.GetByID(context.Background(), 1)
 		assert.NoError(t, err)
-		assert.Equal(t, int64(1), res.FacilityLimitID)
+		assert.Equal(t, int64(10), res.UserID)
+		assert.Equal(t, int64(2), res.ProductID)
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
-		query := regexp.QuoteMeta("SELECT * FROM user_facility_limits WHERE user_id = $1")
 		mock.ExpectQuery(query).
 			WithArgs(99).
 			WillReturnError(pgx.ErrNoRows)
 
-		res, err := repo.Get(context.Background(), 99)
+		res, err := repo.GetByID(context.Background(), 99)
 		assert.Error(t, err)
 		assert.Nil(t, res)
 		assert.Equal(t, "resource not found: resource not found in database", err.Error())
 	})
 }
 
-func TestLimitRepository_Update(t *testing.T) {
+func TestLimitRepository_ListByUser(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows(limitColumns).
+			AddRow(int64(1), int64(10), int64(1), decimal.NewFromInt(10000000), "active", validFrom, validUntil, nextReview).
+			AddRow(int64(4), int64(10), int64(2), decimal.NewFromInt(3000000), "active", validFrom, validUntil, nextReview)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM user_facility_limits WHERE user_id = $1 ORDER BY product_id")).
+			WithArgs(10).
+			WillReturnRows(rows)
+
+		res, err := repo.ListByUser(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(4), res[1].FacilityLimitID)
+	})
+}
+
+func TestLimitRepository_ListUserLimits(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	columns := []string{"user_id", "name", "phone", "limit_id", "product_id", "limit_amount", "held_amount", "status", "valid_until"}
+	pendingApplications := []string{model.ApplicationStatusSubmitted, model.ApplicationStatusUnderReview}
+
+	t.Run("Success With Filters And Cursor", func(t *testing.T) {
+		minAvailable := decimal.NewFromInt(1000000)
+		filter := &model.UserLimitFilter{
+			Search:       "0812_",
+			Phone:        "0812",
+			MinAvailable: &minAvailable,
+			Cursor:       &model.UserLimitCursor{UserID: 3, LimitID: 7},
+			Limit:        11,
+		}
+
+		limitID, productID := int64(8), int64(1)
+		limitAmount := decimal.NewFromInt(5000000)
+		status := model.LimitStatusActive
+		rows := pgxmock.NewRows(columns).
+			AddRow(int64(4), "budi", "+628123456789", &limitID, &productID, &limitAmount, decimal.NewFromInt(1000000), &status, &validUntil).
+			AddRow(int64(5), "andi", "+628120000000", nil, nil, nil, decimal.Zero, nil, nil)
+
+		query := regexp.QuoteMeta("WHERE (u.name ILIKE $3 OR u.phone LIKE $4) AND COALESCE(l.limit_amount, 0) >= $5 AND (u.id, COALESCE(l.id, 0)) > ($6, $7) ORDER BY u.id, COALESCE(l.id, 0) LIMIT $8")
+		mock.ExpectQuery(query).
+			WithArgs(model.HoldStatusActive, pendingApplications, `%0812\_%`, "%0812%", minAvailable, int64(3), int64(7), 11).
+			WillReturnRows(rows)
+
+		res, err := repo.ListUserLimits(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(8), *res[0].LimitID)
+		assert.Equal(t, "1000000", res[0].HeldAmount.String())
+		assert.Nil(t, res[1].LimitID)
+		assert.Nil(t, res[1].LimitAmount)
+	})
+
+	t.Run("Without Filters", func(t *testing.T) {
+		query := regexp.QuoteMeta(") h ON h.facility_limit_id = l.id ORDER BY u.id, COALESCE(l.id, 0) LIMIT $3")
+		mock.ExpectQuery(query).
+			WithArgs(model.HoldStatusActive, pendingApplications, 21).
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		res, err := repo.ListUserLimits(context.Background(), &model.UserLimitFilter{Limit: 21})
+		assert.NoError(t, err)
+		assert.Len(t, res, 0)
+	})
+}
+
+func TestLimitRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	t.Run("Conflict", func(t *testing.T) {
+		limit := &model.UserFacilityLimit{UserID: 10, ProductID: 2, LimitAmount: decimal.Zero, Status: model.LimitStatusActive,
+			ValidFrom: validFrom, ValidUntil: validUntil, NextReviewDate: nextReview}
+
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO user_facility_limits (user_id, product_id, limit_amount, status, valid_from, valid_until, next_review_date)")).
+			WithArgs(limit.UserID, limit.ProductID, limit.LimitAmount, limit.Status, limit.ValidFrom, limit.ValidUntil, limit.NextReviewDate).
+			WillReturnError(&pgconn.PgError{Code: "23505", Detail: "Key (user_id, product_id)=(10, 2) already exists."})
+
+		id, err := repo.Add(context.Background(), limit)
+		assert.Error(t, err)
+		assert.Zero(t, id)
+		assert.Equal(t, "resource already exists: duplicate data: Key (user_id, product_id)=(10, 2) already exists.", err.Error())
+	})
+}
+
+func TestLimitRepository_UpdateStatus(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
 	defer mock.Close()
@@ -53,11 +161,133 @@ func TestLimitRepository_Update(t *testing.T) {
 	repo := NewLimitRepository(mock)
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec("UPDATE user_facility_limits").
-			WithArgs(int64(500000), 1).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE user_facility_limits SET status = $1 WHERE id = $2")).
+			WithArgs(model.LimitStatusFrozen, 4).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
-		err := repo.Update(context.Background(), 1, int64(500000))
+		err := repo.UpdateStatus(context.Background(), 4, model.LimitStatusFrozen)
+		assert.NoError(t, err)
+	})
+}
+
+func TestLimitRepository_ListDueForReview(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		rows := pgxmock.NewRows(limitColumns).
+			AddRow(int64(1), int64(10), int64(1), decimal.NewFromInt(10000000), "frozen", validFrom, validUntil, nextReview)
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE status <> $1 AND next_review_date <= $2")).
+			WithArgs(model.LimitStatusClosed, nextReview).
+			WillReturnRows(rows)
+
+		res, err := repo.ListDueForReview(context.Background(), nextReview)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, nextReview, res[0].NextReviewDate)
+	})
+}
+
+func TestLimitRepository_Post(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+	query := regexp.QuoteMeta("UPDATE user_facility_limits SET limit_amount = limit_amount + $2 WHERE id = $1 AND limit_amount + $2 >= 0")
+	now := time.Now()
+	refType := model.ReferenceFacility
+	refID := int64(9)
+
+	t.Run("Success Drawdown", func(t *testing.T) {
+		entry := &model.LimitLedgerEntry{
+			FacilityLimitID: 1,
+			EntryType:       model.LedgerDrawdown,
+			Amount:          decimal.RequireFromString("-2500000.50"),
+			ReferenceType:   &refType,
+			ReferenceID:     &refID,
+			CreatedAt:       now,
+		}
+
+		mock.ExpectQuery(query).
+			WithArgs(int64(1), entry.Amount, model.LedgerDrawdown, &refType, &refID, "", now).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "balance_after"}).AddRow(int64(4), decimal.RequireFromString("7499999.50")))
+
+		err := repo.Post(context.Background(), entry)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), entry.EntryID)
+		assert.Equal(t, "7499999.5", entry.BalanceAfter.String())
+	})
+
+	t.Run("Insufficient Limit", func(t *testing.T) {
+		entry := &model.LimitLedgerEntry{FacilityLimitID: 1, EntryType: model.LedgerDrawdown, Amount: decimal.NewFromInt(-99000000), CreatedAt: now}
+
+		mock.ExpectQuery(query).
+			WithArgs(int64(1), entry.Amount, model.LedgerDrawdown, entry.ReferenceType, entry.ReferenceID, "", now).
+			WillReturnError(pgx.ErrNoRows)
+
+		err := repo.Post(context.Background(), entry)
+		assert.Error(t, err)
+		assert.Equal(t, "insufficient limit amount: limit balance is not enough", err.Error())
+	})
+
+	t.Run("Repayment Limit Not Found", func(t *testing.T) {
+		entry := &model.LimitLedgerEntry{FacilityLimitID: 99, EntryType: model.LedgerRepayment, Amount: decimal.NewFromInt(1), CreatedAt: now}
+
+		mock.ExpectQuery(query).
+			WithArgs(int64(99), entry.Amount, model.LedgerRepayment, entry.ReferenceType, entry.ReferenceID, "", now).
+			WillReturnError(pgx.ErrNoRows)
+
+		err := repo.Post(context.Background(), entry)
+		assert.Error(t, err)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
+
+func TestLimitRepository_ListLedger(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		refType := model.ReferencePayment
+		refID := int64(3)
+		rows := pgxmock.NewRows([]string{"id", "facility_limit_id", "entry_type", "amount", "balance_after", "reference_type", "reference_id", "note", "created_at"}).
+			AddRow(int64(5), int64(1), model.LedgerRepayment, decimal.NewFromInt(1000000), decimal.NewFromInt(9000000), &refType, &refID, "", time.Now()).
+			AddRow(int64(4), int64(1), model.LedgerDrawdown, decimal.NewFromInt(-2000000), decimal.NewFromInt(8000000), nil, nil, "", time.Now())
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE facility_limit_id = $1 AND ($2::bigint = 0 OR id < $2) ORDER BY id DESC LIMIT $3")).
+			WithArgs(1, int64(6), 2).
+			WillReturnRows(rows)
+
+		res, err := repo.ListLedger(context.Background(), 1, 6, 2)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, model.ReferencePayment, *res[0].ReferenceType)
+		assert.Nil(t, res[1].ReferenceID)
+	})
+}
+
+func TestLimitRepository_LedgerBalance(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLimitRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(amount), 0) FROM limit_ledger WHERE facility_limit_id = $1")).
+			WithArgs(1).
+			WillReturnRows(pgxmock.NewRows([]string{"coalesce"}).AddRow(decimal.NewFromInt(9000000)))
+
+		balance, err := repo.LedgerBalance(context.Background(), 1)
 		assert.NoError(t, err)
+		assert.Equal(t, "9000000", balance.String())
 	})
 }
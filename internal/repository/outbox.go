@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, event *model.OutboxEvent) error
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, event *model.OutboxEvent) error
+	Release(ctx context.Context, ids []int64, now time.Time) error
+}
+
+// outboxClaimLock keys the advisory lock that serializes the claims of
+// concurrent relays.
+const outboxClaimLock = 7303001
+
+type outboxRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewOutboxRepository(db postgres.PgxExecutor) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+// Add writes an event to the outbox. It must run in the transaction of the
+// change the event describes, so the event exists if and only if the change
+// was committed.
+func (r *outboxRepository) Add(ctx context.Context, event *model.OutboxEvent) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO outbox_events (event_id, event_type, aggregate_type, aggregate_id, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.Exec(ctx, query, event.EventID, event.EventType, event.AggregateType, event.AggregateID, event.Payload,
+		event.NextAttemptAt, event.CreatedAt)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
+
+// ClaimDue takes at most limit unpublished events that are due at or before
+// now, in the order they were written, counts the attempt and pushes their
+// next attempt out to leaseUntil. An event is only taken when no earlier
+// event of its aggregate is still waiting, on a lease or a backoff, or was
+// given up on, so the events of an aggregate go out in order and a gap is
+// never skipped over. It must run in a transaction: the claims of
+// concurrent relays are serialized on an advisory lock held until commit, so
+// none of them can claim past an event another has just leased. Events of a
+// relay that dies before marking them are sent again once the lease runs out.
+func (r *outboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.OutboxEvent, error) {
+	db := r.getExecutor(ctx)
+
+	_, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxClaimLock)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	query := `
+		UPDATE outbox_events SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE id IN (
+			SELECT e.id FROM outbox_events e
+			WHERE e.published_at IS NULL AND e.failed_at IS NULL AND e.next_attempt_at <= $1
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events prev
+				WHERE prev.aggregate_type = e.aggregate_type AND prev.aggregate_id = e.aggregate_id AND prev.id < e.id
+				AND prev.published_at IS NULL AND (prev.failed_at IS NOT NULL OR prev.next_attempt_at > $1)
+			)
+			ORDER BY e.id LIMIT $3
+			FOR UPDATE
+		)
+		RETURNING *`
+	rows, err := db.Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	events, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.OutboxEvent])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE outbox_events SET published_at = $1, last_error = '' WHERE id = $2`
+	_, err := db.Exec(ctx, query, publishedAt, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
+
+// MarkFailed stores why the last delivery of an event failed and when it is
+// tried again, or, with FailedAt set, that it is given up on.
+func (r *outboxRepository) MarkFailed(ctx context.Context, event *model.OutboxEvent) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE outbox_events SET last_error = $1, next_attempt_at = $2, failed_at = $3 WHERE id = $4`
+	_, err := db.Exec(ctx, query, event.LastError, event.NextAttemptAt, event.FailedAt, event.ID)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
+
+// Release hands claimed events that were not sent back to the next claim,
+// taking back the attempt the claim counted, so an event held back behind a
+// failed one does not use up its attempts without being tried.
+func (r *outboxRepository) Release(ctx context.Context, ids []int64, now time.Time) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE outbox_events SET attempts = attempts - 1, next_attempt_at = $1
+		WHERE id = ANY($2) AND published_at IS NULL`
+	_, err := db.Exec(ctx, query, now, ids)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+
+	return nil
+}
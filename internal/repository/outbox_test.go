@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxColumns = []string{"id", "event_id", "event_type", "aggregate_type", "aggregate_id", "payload", "attempts", "next_attempt_at",
+	"last_error", "created_at", "published_at", "failed_at"}
+
+func TestOutboxRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock)
+	now := time.Now()
+	event := &model.OutboxEvent{EventID: "6f1c2a4e-8d3b-4f7a-9c1e-2b5d7e9f0a13", EventType: model.EventFacilityCreated,
+		AggregateType: model.AggregateFacility, AggregateID: 7, Payload: []byte(`{"user_facility_id":7}`), NextAttemptAt: now, CreatedAt: now}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events")).
+		WithArgs(event.EventID, event.EventType, event.AggregateType, event.AggregateID, event.Payload, now, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = repo.Add(context.Background(), event)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_ClaimDue(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock)
+	now := time.Now()
+	leaseUntil := now.Add(time.Minute)
+
+	rows := pgxmock.NewRows(outboxColumns).
+		AddRow(int64(1), "6f1c2a4e-8d3b-4f7a-9c1e-2b5d7e9f0a13", model.EventFacilityCreated, model.AggregateFacility, int64(7),
+			[]byte(`{"user_facility_id":7}`), 1, leaseUntil, "", now.Add(-time.Second), nil, nil)
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(outboxClaimLock).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectQuery(regexp.QuoteMeta("AND prev.published_at IS NULL AND (prev.failed_at IS NOT NULL OR prev.next_attempt_at > $1)")).
+		WithArgs(now, leaseUntil, 100).
+		WillReturnRows(rows)
+
+	res, err := repo.ClaimDue(context.Background(), now, leaseUntil, 100)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, model.EventFacilityCreated, res[0].EventType)
+	assert.Equal(t, 1, res[0].Attempts)
+	assert.Nil(t, res[0].PublishedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_MarkFailed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock)
+	now := time.Now()
+	event := &model.OutboxEvent{ID: 1, LastError: "broker unavailable", NextAttemptAt: now.Add(time.Minute), FailedAt: &now}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE outbox_events SET last_error = $1, next_attempt_at = $2, failed_at = $3")).
+		WithArgs(event.LastError, event.NextAttemptAt, event.FailedAt, int64(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err = repo.MarkFailed(context.Background(), event)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Release(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock)
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE outbox_events SET attempts = attempts - 1, next_attempt_at = $1")).
+		WithArgs(now, []int64{2, 3}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	err = repo.Release(context.Background(), []int64{2, 3}, now)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PaymentRepository interface {
+	Add(ctx context.Context, payment *model.Payment) (int, error)
+}
+
+type paymentRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewPaymentRepository(db postgres.PgxExecutor) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *paymentRepository) Add(ctx context.Context, payment *model.Payment) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO payments (user_facility_id, amount, principal_amount, paid_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, payment.UserFacilityID, payment.Amount, payment.PrincipalAmount, payment.PaidAt, payment.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPaymentRepository(mock)
+
+	now := time.Now()
+	payment := &model.Payment{
+		UserFacilityID:  1,
+		Amount:          decimal.NewFromInt(1200000),
+		PrincipalAmount: decimal.NewFromInt(1000000),
+		PaidAt:          now,
+		CreatedAt:       now,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO payments").
+			WithArgs(payment.UserFacilityID, payment.Amount, payment.PrincipalAmount, payment.PaidAt, payment.CreatedAt).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+
+		id, err := repo.Add(context.Background(), payment)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, id)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO payments").
+			WillReturnError(errors.New("db error"))
+
+		id, err := repo.Add(context.Background(), payment)
+		assert.Error(t, err)
+		assert.Equal(t, 0, id)
+	})
+}
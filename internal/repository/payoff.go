@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PayoffRepository interface {
+	Add(ctx context.Context, quote *model.PayoffQuote) (int, error)
+	Get(ctx context.Context, id int) (*model.PayoffQuote, error)
+	MarkExecuted(ctx context.Context, id int, paymentID int64) error
+}
+
+type payoffRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewPayoffRepository(db postgres.PgxExecutor) PayoffRepository {
+	return &payoffRepository{db: db}
+}
+
+func (r *payoffRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *payoffRepository) Add(ctx context.Context, quote *model.PayoffQuote) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO payoff_quotes (user_facility_id, remaining_principal, outstanding_amount, rebate_policy, rebate, fee, payoff_amount, status, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, quote.UserFacilityID, quote.RemainingPrincipal, quote.OutstandingAmount, quote.RebatePolicy, quote.Rebate, quote.Fee, quote.PayoffAmount, quote.Status, quote.ExpiresAt, quote.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *payoffRepository) Get(ctx context.Context, id int) (*model.PayoffQuote, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM payoff_quotes WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	quote, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.PayoffQuote])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return quote, nil
+}
+
+func (r *payoffRepository) MarkExecuted(ctx context.Context, id int, paymentID int64) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE payoff_quotes SET status = $1, payment_id = $2 WHERE id = $3 AND status = $4`
+	cmd, err := db.Exec(ctx, query, model.QuoteStatusExecuted, paymentID, id, model.QuoteStatusOpen)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(errors.New("no rows updated"))
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayoffRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPayoffRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		now := time.Now()
+		quote := &model.PayoffQuote{
+			UserFacilityID:     7,
+			RemainingPrincipal: decimal.NewFromInt(2000000),
+			OutstandingAmount:  decimal.NewFromInt(2400000),
+			RebatePolicy:       model.RebatePolicyProRata,
+			Rebate:             decimal.NewFromInt(400000),
+			Fee:                decimal.NewFromInt(20000),
+			PayoffAmount:       decimal.NewFromInt(2020000),
+			Status:             model.QuoteStatusOpen,
+			ExpiresAt:          now.Add(time.Hour),
+			CreatedAt:          now,
+		}
+
+		mock.ExpectQuery("INSERT INTO payoff_quotes").
+			WithArgs(quote.UserFacilityID, quote.RemainingPrincipal, quote.OutstandingAmount, quote.RebatePolicy, quote.Rebate, quote.Fee, quote.PayoffAmount, quote.Status, quote.ExpiresAt, quote.CreatedAt).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+
+		id, err := repo.Add(context.Background(), quote)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, id)
+	})
+}
+
+func TestPayoffRepository_MarkExecuted(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPayoffRepository(mock)
+
+	query := regexp.QuoteMeta("UPDATE payoff_quotes SET status = $1, payment_id = $2 WHERE id = $3 AND status = $4")
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(model.QuoteStatusExecuted, int64(11), 3, model.QuoteStatusOpen).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.MarkExecuted(context.Background(), 3, 11)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Already Executed", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(model.QuoteStatusExecuted, int64(11), 3, model.QuoteStatusOpen).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.MarkExecuted(context.Background(), 3, 11)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ProductRepository interface {
+	Get(ctx context.Context, id int) (*model.LimitProduct, error)
+	List(ctx context.Context) ([]*model.LimitProduct, error)
+	Add(ctx context.Context, product *model.LimitProduct) (int, error)
+	UpdatePricingMethod(ctx context.Context, id int, method *string) error
+}
+
+type productRepository struct {
+	db postgres.PgxExecutor
+}
+
+func NewProductRepository(db postgres.PgxExecutor) ProductRepository {
+	return &productRepository{db: db}
+}
+
+func (r *productRepository) getExecutor(ctx context.Context) postgres.PgxExecutor {
+	tx, ok := ctx.Value(postgres.TrxKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *productRepository) Get(ctx context.Context, id int) (*model.LimitProduct, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM limit_products WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	product, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.LimitProduct])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return product, nil
+}
+
+func (r *productRepository) List(ctx context.Context) ([]*model.LimitProduct, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM limit_products ORDER BY id`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	products, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.LimitProduct])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return products, nil
+}
+
+func (r *productRepository) Add(ctx context.Context, product *model.LimitProduct) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO limit_products (code, name, pricing_method, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, product.Code, product.Name, product.PricingMethod, product.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *productRepository) UpdatePricingMethod(ctx context.Context, id int, method *string) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE limit_products SET pricing_method = $1 WHERE id = $2`
+	cmd, err := db.Exec(ctx, query, method, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
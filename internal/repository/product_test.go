@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewProductRepository(mock)
+	query := regexp.QuoteMeta("SELECT * FROM limit_products WHERE id = $1")
+
+	t.Run("Success", func(t *testing.T) {
+		method := "annuity"
+		rows := pgxmock.NewRows([]string{"id", "code", "name", "pricing_method", "created_at"}).
+			AddRow(int64(2), "cash_loan", "Cash Loan", &method, time.Now())
+
+		mock.ExpectQuery(query).
+			WithArgs(2).
+			WillReturnRows(rows)
+
+		res, err := repo.Get(context.Background(), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "cash_loan", res.Code)
+		assert.Equal(t, "annuity", *res.PricingMethod)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(99).
+			WillReturnError(pgx.ErrNoRows)
+
+		res, err := repo.Get(context.Background(), 99)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
+
+func TestProductRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewProductRepository(mock)
+
+	t.Run("Success", func(t *testing.T) {
+		product := &model.LimitProduct{Code: "bnpl", Name: "Buy Now Pay Later", CreatedAt: time.Now()}
+
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO limit_products (code, name, pricing_method, created_at)")).
+			WithArgs(product.Code, product.Name, product.PricingMethod, product.CreatedAt).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(3))
+
+		id, err := repo.Add(context.Background(), product)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, id)
+	})
+}
+
+func TestProductRepository_UpdatePricingMethod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewProductRepository(mock)
+	method := "annuity"
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE limit_products SET pricing_method = $1 WHERE id = $2")).
+			WithArgs(&method, 2).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.UpdatePricingMethod(context.Background(), 2, &method)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE limit_products SET pricing_method = $1 WHERE id = $2")).
+			WithArgs(&method, 9).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdatePricingMethod(context.Background(), 9, &method)
+		assert.Error(t, err)
+	})
+}
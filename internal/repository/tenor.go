@@ -6,13 +6,20 @@ import (
 	"finance/internal/model"
 	"finance/pkg/errorx"
 	"finance/pkg/postgres"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type TenorRepository interface {
-	Get(ctx context.Context, tenorValue int) (*model.Tenor, error)
-	List(ctx context.Context) ([]*model.Tenor, error)
+	Get(ctx context.Context, productID int, tenorValue int, at time.Time) (*model.Tenor, error)
+	List(ctx context.Context, productID int, at time.Time) ([]*model.Tenor, error)
+	GetByID(ctx context.Context, id int) (*model.Tenor, error)
+	ListAll(ctx context.Context) ([]*model.Tenor, error)
+	Add(ctx context.Context, tenor *model.Tenor) (int, error)
+	Update(ctx context.Context, tenor *model.Tenor) error
+	Delete(ctx context.Context, id int) error
+	HasOverlap(ctx context.Context, tenor *model.Tenor) (bool, error)
 }
 
 type tenorRepository struct {
@@ -32,11 +39,13 @@ func (r *tenorRepository) getExecutor(ctx context.Context) postgres.PgxExecutor
 	return r.db
 }
 
-func (r *tenorRepository) Get(ctx context.Context, tenorValue int) (*model.Tenor, error) {
+func (r *tenorRepository) Get(ctx context.Context, productID int, tenorValue int, at time.Time) (*model.Tenor, error) {
 	db := r.getExecutor(ctx)
 
-	query := `SELECT * FROM tenors WHERE tenor_value  = $1`
-	rows, err := db.Query(ctx, query, tenorValue)
+	query := `
+		SELECT * FROM tenors
+		WHERE product_id = $1 AND tenor_value = $2 AND effective_from <= $3 AND (effective_to IS NULL OR effective_to >= $3)`
+	rows, err := db.Query(ctx, query, productID, tenorValue, at)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errorx.DbError(err)
@@ -55,11 +64,16 @@ func (r *tenorRepository) Get(ctx context.Context, tenorValue int) (*model.Tenor
 	return tenor, nil
 }
 
-func (r *tenorRepository) List(ctx context.Context) ([]*model.Tenor, error) {
+// List returns the tenors in effect at the given time. A zero productID
+// lists the tenors of every product.
+func (r *tenorRepository) List(ctx context.Context, productID int, at time.Time) ([]*model.Tenor, error) {
 	db := r.getExecutor(ctx)
 
-	query := `SELECT * FROM tenors`
-	rows, err := db.Query(ctx, query)
+	query := `
+		SELECT * FROM tenors
+		WHERE ($1::int = 0 OR product_id = $1) AND effective_from <= $2 AND (effective_to IS NULL OR effective_to >= $2)
+		ORDER BY product_id, tenor_value`
+	rows, err := db.Query(ctx, query, productID, at)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errorx.DbError(err)
@@ -77,3 +91,109 @@ func (r *tenorRepository) List(ctx context.Context) ([]*model.Tenor, error) {
 
 	return ternors, nil
 }
+
+func (r *tenorRepository) GetByID(ctx context.Context, id int) (*model.Tenor, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM tenors WHERE id = $1`
+	rows, err := db.Query(ctx, query, id)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	tenor, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[model.Tenor])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return tenor, nil
+}
+
+func (r *tenorRepository) ListAll(ctx context.Context) ([]*model.Tenor, error) {
+	db := r.getExecutor(ctx)
+
+	query := `SELECT * FROM tenors ORDER BY product_id, tenor_value, effective_from`
+	rows, err := db.Query(ctx, query)
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	tenors, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[model.Tenor])
+	if err != nil {
+		return nil, errorx.DbError(err)
+	}
+
+	return tenors, nil
+}
+
+func (r *tenorRepository) Add(ctx context.Context, tenor *model.Tenor) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO tenors (product_id, tenor_value, rate, min_amount, max_amount, effective_from, effective_to, business_day_rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, tenor.ProductID, tenor.TenorValue, tenor.Rate, tenor.MinAmount, tenor.MaxAmount, tenor.EffectiveFrom, tenor.EffectiveTo, tenor.BusinessDayRule).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *tenorRepository) Update(ctx context.Context, tenor *model.Tenor) error {
+	db := r.getExecutor(ctx)
+
+	query := `
+		UPDATE tenors
+		SET product_id = $1, tenor_value = $2, rate = $3, min_amount = $4, max_amount = $5, effective_from = $6, effective_to = $7, business_day_rule = $8
+		WHERE id = $9`
+	cmd, err := db.Exec(ctx, query, tenor.ProductID, tenor.TenorValue, tenor.Rate, tenor.MinAmount, tenor.MaxAmount, tenor.EffectiveFrom, tenor.EffectiveTo, tenor.BusinessDayRule, tenor.TenorID)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *tenorRepository) Delete(ctx context.Context, id int) error {
+	db := r.getExecutor(ctx)
+
+	query := `DELETE FROM tenors WHERE id = $1`
+	cmd, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+// HasOverlap reports whether another rate of the same tenor and product is
+// effective on any day of the given tenor's effective period.
+func (r *tenorRepository) HasOverlap(ctx context.Context, tenor *model.Tenor) (bool, error) {
+	db := r.getExecutor(ctx)
+
+	var exists bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM tenors
+			WHERE product_id = $1 AND tenor_value = $2 AND id <> $3
+			AND effective_from <= COALESCE($5::date, 'infinity'::date)
+			AND COALESCE(effective_to, 'infinity'::date) >= $4
+		)`
+	err := db.QueryRow(ctx, query, tenor.ProductID, tenor.TenorValue, tenor.TenorID, tenor.EffectiveFrom, tenor.EffectiveTo).Scan(&exists)
+	if err != nil {
+		return false, errorx.DbError(err)
+	}
+
+	return exists, nil
+}
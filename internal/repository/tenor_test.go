@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var tenorColumns = []string{"id", "product_id", "tenor_value", "rate", "min_amount", "max_amount", "effective_from", "effective_to", "business_day_rule"}
+
+func TestTenorRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTenorRepository(mock)
+	at := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		minAmount := decimal.NewFromInt(1000000)
+		rows := pgxmock.NewRows(tenorColumns).
+			AddRow(int64(2), int64(1), 12, decimal.RequireFromString("0.20"), &minAmount, nil, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), nil, "following")
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE product_id = $1 AND tenor_value = $2 AND effective_from <= $3 AND (effective_to IS NULL OR effective_to >= $3)")).
+			WithArgs(1, 12, at).
+			WillReturnRows(rows)
+
+		res, err := repo.Get(context.Background(), 1, 12, at)
+		assert.NoError(t, err)
+		assert.Equal(t, "0.2", res.Rate.String())
+		assert.Equal(t, "1000000", res.MinAmount.String())
+		assert.Nil(t, res.MaxAmount)
+		assert.Nil(t, res.EffectiveTo)
+		assert.Equal(t, "following", res.BusinessDayRule)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\* FROM tenors").
+			WithArgs(1, 48, at).
+			WillReturnError(pgx.ErrNoRows)
+
+		res, err := repo.Get(context.Background(), 1, 48, at)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestTenorRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTenorRepository(mock)
+	at := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Every Product", func(t *testing.T) {
+		effective := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		rows := pgxmock.NewRows(tenorColumns).
+			AddRow(int64(1), int64(1), 6, decimal.RequireFromString("0.20"), nil, nil, effective, nil, "following").
+			AddRow(int64(7), int64(2), 3, decimal.RequireFromString("0.00"), nil, nil, effective, nil, "following")
+
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE ($1::int = 0 OR product_id = $1)")).
+			WithArgs(0, at).
+			WillReturnRows(rows)
+
+		res, err := repo.List(context.Background(), 0, at)
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(2), res[1].ProductID)
+	})
+}
+
+func TestTenorRepository_HasOverlap(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTenorRepository(mock)
+
+	t.Run("Overlap", func(t *testing.T) {
+		tenor := &model.Tenor{ProductID: 1, TenorValue: 12, EffectiveFrom: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(int64(1), 12, int64(0), tenor.EffectiveFrom, tenor.EffectiveTo).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+		overlap, err := repo.HasOverlap(context.Background(), tenor)
+		assert.NoError(t, err)
+		assert.True(t, overlap)
+	})
+}
+
+func TestTenorRepository_Delete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTenorRepository(mock)
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM tenors").
+			WithArgs(99).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+		err := repo.Delete(context.Background(), 99)
+		assert.Error(t, err)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"finance/internal/model"
 	"finance/pkg/errorx"
 	"finance/pkg/postgres"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,6 +14,9 @@ import (
 type UserRepository interface {
 	Get(ctx context.Context, id int) (*model.User, error)
 	List(ctx context.Context) ([]*model.User, error)
+	Add(ctx context.Context, user *model.User) (int, error)
+	Update(ctx context.Context, user *model.User) error
+	UpdateStatus(ctx context.Context, id int, status string, updatedAt time.Time) error
 }
 
 type userRepository struct {
@@ -77,3 +81,52 @@ func (r *userRepository) List(ctx context.Context) ([]*model.User, error) {
 
 	return users, nil
 }
+
+// Add inserts a user. A phone number that is already registered fails with
+// a conflict from the unique constraint.
+func (r *userRepository) Add(ctx context.Context, user *model.User) (int, error) {
+	db := r.getExecutor(ctx)
+
+	var id int
+
+	query := `
+		INSERT INTO users (name, phone, status, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	err := db.QueryRow(ctx, query, user.Name, user.Phone, user.Status, user.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, errorx.DbError(err)
+	}
+
+	return id, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE users SET name = $1, phone = $2, updated_at = $3 WHERE id = $4`
+	cmd, err := db.Exec(ctx, query, user.Name, user.Phone, user.UpdatedAt, user.UserID)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id int, status string, updatedAt time.Time) error {
+	db := r.getExecutor(ctx)
+
+	query := `UPDATE users SET status = $1, updated_at = $2 WHERE id = $3`
+	cmd, err := db.Exec(ctx, query, status, updatedAt, id)
+	if err != nil {
+		return errorx.DbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return errorx.DbError(pgx.ErrNoRows)
+	}
+
+	return nil
+}
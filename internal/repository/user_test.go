@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"finance/internal/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(mock)
+
+	rows := pgxmock.NewRows([]string{"id", "name", "phone", "status", "created_at", "updated_at"}).
+		AddRow(int64(1), "user 1", "+628123456789", model.UserStatusActive, time.Now(), nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users WHERE id  = $1")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	res, err := repo.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "+628123456789", res.Phone)
+	assert.Nil(t, res.UpdatedAt)
+}
+
+func TestUserRepository_Add(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(mock)
+	query := regexp.QuoteMeta("INSERT INTO users (name, phone, status, created_at)")
+	user := &model.User{Name: "user 1", Phone: "+628123456789", Status: model.UserStatusActive, CreatedAt: time.Now()}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(user.Name, user.Phone, user.Status, user.CreatedAt).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(4))
+
+		id, err := repo.Add(context.Background(), user)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, id)
+	})
+
+	t.Run("Duplicate Phone", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(user.Name, user.Phone, user.Status, user.CreatedAt).
+			WillReturnError(&pgconn.PgError{Code: "23505", Detail: "Key (phone)=(+628123456789) already exists."})
+
+		_, err := repo.Add(context.Background(), user)
+		assert.Error(t, err)
+		assert.Equal(t, "resource already exists: duplicate data: Key (phone)=(+628123456789) already exists.", err.Error())
+	})
+}
+
+func TestUserRepository_UpdateStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(mock)
+	query := regexp.QuoteMeta("UPDATE users SET status = $1, updated_at = $2 WHERE id = $3")
+	now := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(model.UserStatusInactive, now, 1).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err := repo.UpdateStatus(context.Background(), 1, model.UserStatusInactive, now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(model.UserStatusInactive, now, 99).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.UpdateStatus(context.Background(), 99, model.UserStatusInactive, now)
+		assert.Error(t, err)
+		assert.Equal(t, "resource not found: resource not found in database", err.Error())
+	})
+}
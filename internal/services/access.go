@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"finance/internal/auth"
+	"finance/pkg/errorx"
+)
+
+// systemActor is recorded as the actor of changes made without a principal.
+const systemActor = "system"
+
+// checkAccess rejects a request made on behalf of a user other than the
+// caller. Admins and internal callers may act on every user.
+func checkAccess(ctx context.Context, userID int64) error {
+	if !auth.CanAccess(ctx, userID) {
+		return errorx.NewError(errorx.ErrTypeForbidden, "not allowed to act on behalf of this user", nil)
+	}
+
+	return nil
+}
+
+// checkOwner answers a lookup of another user's resource as if it did not
+// exist, so customers cannot probe the IDs of other users' resources.
+func checkOwner(ctx context.Context, userID int64, resource string) error {
+	if !auth.CanAccess(ctx, userID) {
+		return errorx.NewError(errorx.ErrTypeNotFound, resource+" not found", nil)
+	}
+
+	return nil
+}
+
+// actor names the caller in ctx for the audit columns of a record: the
+// subject of its token, or system for background jobs and other internal
+// callers.
+func actor(ctx context.Context) string {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return systemActor
+	}
+
+	return p.Subject
+}
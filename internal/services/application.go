@@ -0,0 +1,575 @@
+package services
+
+import (
+	"context"
+	"finance/internal/auth"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// applicationTransitions lists the statuses an application may move to from
+// each status. Rejected, cancelled and disbursed applications are final. An
+// approved application is only moved on by the disbursement of its facility:
+// to disbursed once the money is sent, or to cancelled when it never can be.
+var applicationTransitions = map[string][]string{
+	model.ApplicationStatusDraft:       {model.ApplicationStatusSubmitted, model.ApplicationStatusCancelled},
+	model.ApplicationStatusSubmitted:   {model.ApplicationStatusUnderReview, model.ApplicationStatusCancelled},
+	model.ApplicationStatusUnderReview: {model.ApplicationStatusApproved, model.ApplicationStatusRejected, model.ApplicationStatusCancelled},
+	model.ApplicationStatusApproved:    {model.ApplicationStatusDisbursed, model.ApplicationStatusCancelled},
+}
+
+// CreateApplication drafts a financing application. Nothing is held on the
+// limit until the application is submitted. With an idempotency key a retry
+// gets the draft it already created back instead of a second one.
+func (s *service) CreateApplication(ctx context.Context, req *model.ApplicationRequest) (*model.ApplicationResponse, error) {
+	err := checkAccess(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	op := applicationOperation{Operation: "create", Request: req}
+	if req.IdempotencyKey != "" {
+		err = checkIdempotencyKey(req.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.replayApplication(ctx, req.UserID, req.IdempotencyKey, op)
+		if err != nil || response != nil {
+			return response, err
+		}
+	}
+
+	now := time.Now()
+	app := &model.FinancingApplication{
+		UserID:          req.UserID,
+		FacilityLimitID: req.FacilityLimitID,
+		Amount:          decimal.NewFromInt(req.Amount),
+		Tenor:           req.Tenor,
+		BillingDay:      req.BillingDay,
+		GracePeriod:     req.GracePeriod,
+		Status:          model.ApplicationStatusDraft,
+		CreatedAt:       now,
+	}
+
+	if req.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, errorx.NewError(errorx.ErrTypeValidation, "invalid date format, use YYYY-MM-DD", err)
+		}
+		app.StartDate = &startDate
+	}
+
+	user, err := s.userRepo.Get(ctx, int(req.UserID))
+	if err != nil {
+		s.log.Error("failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := s.applicationLimit(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	tenor, err := s.tenorRepo.Get(ctx, int(limit.ProductID), req.Tenor, now)
+	if err != nil {
+		s.log.Error("failed to get tenor", zap.Error(err))
+		return nil, err
+	}
+
+	if !tenorAllows(tenor, app.Amount) {
+		return nil, errorx.NewError(errorx.ErrTenorNotAvail, "amount is outside the range allowed for this tenor", nil)
+	}
+
+	if req.IdempotencyKey == "" {
+		id, err := s.applicationRepo.Add(ctx, app)
+		if err != nil {
+			s.log.Error("failed to insert financing application", zap.Error(err))
+			return nil, err
+		}
+		app.ApplicationID = int64(id)
+
+		return toApplicationResponse(app), nil
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	replayed, err := s.claimApplicationKey(txCtx, req.UserID, req.IdempotencyKey, op)
+	if err != nil || replayed != nil {
+		return replayed, err
+	}
+
+	id, err := s.applicationRepo.Add(txCtx, app)
+	if err != nil {
+		s.log.Error("failed to insert financing application", zap.Error(err))
+		return nil, err
+	}
+	app.ApplicationID = int64(id)
+
+	response := toApplicationResponse(app)
+	err = s.saveApplication(txCtx, req.IdempotencyKey, op, response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *service) GetApplication(ctx context.Context, id int) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// ListApplications lists applications oldest first, or only their own for a
+// customer. Reviewers list the submitted ones to work through the queue.
+func (s *service) ListApplications(ctx context.Context, req *model.ListApplicationsRequest) (*model.ListApplicationsResponse, error) {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.IsAdmin() {
+		req.UserID = principal.UserID
+	}
+
+	filter := &model.ApplicationFilter{
+		UserID:  req.UserID,
+		Status:  req.Status,
+		AfterID: req.After,
+		Limit:   req.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	apps, err := s.applicationRepo.List(ctx, filter)
+	if err != nil {
+		s.log.Error("failed to get list financing applications", zap.Error(err))
+		return nil, err
+	}
+
+	response := &model.ListApplicationsResponse{Data: []*model.ApplicationResponse{}}
+	if len(apps) > pageSize {
+		apps = apps[:pageSize]
+		response.NextAfter = apps[pageSize-1].ApplicationID
+	}
+
+	for _, app := range apps {
+		response.Data = append(response.Data, toApplicationResponse(app))
+	}
+
+	return response, nil
+}
+
+// SubmitApplication sends a draft for review and holds its amount on the
+// limit, so the limit cannot be drawn down by anything else while it waits.
+// With an idempotency key a retry of a submission that went through gets the
+// original response back instead of a conflict.
+func (s *service) SubmitApplication(ctx context.Context, id int, idempotencyKey string) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	op := applicationOperation{Operation: "submit", ApplicationID: id}
+	if idempotencyKey != "" {
+		err = checkIdempotencyKey(idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.replayApplication(ctx, app.UserID, idempotencyKey, op)
+		if err != nil || response != nil {
+			return response, err
+		}
+	}
+
+	err = checkTransition(app, model.ApplicationStatusSubmitted)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Get(ctx, int(app.UserID))
+	if err != nil {
+		s.log.Error("failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = checkKYCVerified(ctx, s.kycRepo, user.UserID, now)
+	if err != nil {
+		s.log.Warn("user kyc not verified", zap.Int64("user_id", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	limit, err := s.applicationLimit(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLimitAvailable(limit, now)
+	if err != nil {
+		s.log.Warn("facility limit not available", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	if idempotencyKey == "" {
+		err = s.transitionApplication(ctx, app, model.ApplicationStatusSubmitted, nil, func(txCtx context.Context) error {
+			return s.postApplication(txCtx, app, model.LedgerHold, app.Amount.Neg())
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return toApplicationResponse(app), nil
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	replayed, err := s.claimApplicationKey(txCtx, app.UserID, idempotencyKey, op)
+	if err != nil || replayed != nil {
+		return replayed, err
+	}
+
+	err = s.postApplication(txCtx, app, model.LedgerHold, app.Amount.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	stampApplication(txCtx, app, model.ApplicationStatusSubmitted, nil, now)
+	err = s.saveTransition(txCtx, app, model.ApplicationStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toApplicationResponse(app)
+	err = s.saveApplication(txCtx, idempotencyKey, op, response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CancelApplication withdraws an application that has not been decided yet
+// and gives back the amount it held on the limit.
+func (s *service) CancelApplication(ctx context.Context, id int) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.Status == model.ApplicationStatusApproved {
+		return nil, errorx.NewError(errorx.ErrTypeConflict, "application has already been approved", nil)
+	}
+
+	err = s.transitionApplication(ctx, app, model.ApplicationStatusCancelled, nil, s.releaseApplication(app))
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// StartReview picks a submitted application up for review.
+func (s *service) StartReview(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transitionApplication(ctx, app, model.ApplicationStatusUnderReview, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// ApproveApplication books the facility of an application under review. The
+// held amount is given back and drawn down by the facility in the same
+// transaction that approves it, priced at the rates in effect on approval.
+// An application without a start date, or with one that has passed during
+// the review, starts on the approval date.
+func (s *service) ApproveApplication(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkTransition(app, model.ApplicationStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	startDate := today
+	if app.StartDate != nil && app.StartDate.After(today) {
+		startDate = *app.StartDate
+	}
+
+	from := app.Status
+	_, err = s.submit(ctx, &model.SubmitFinancingRequest{
+		UserID:          app.UserID,
+		FacilityLimitID: app.FacilityLimitID,
+		Amount:          app.Amount.IntPart(),
+		Tenor:           app.Tenor,
+		StartDate:       startDate.Format("2006-01-02"),
+		BillingDay:      app.BillingDay,
+		GracePeriod:     app.GracePeriod,
+	}, func(txCtx context.Context, facilityID int64) error {
+		err := s.postApplication(txCtx, app, model.LedgerRelease, app.Amount)
+		if err != nil {
+			return err
+		}
+
+		app.UserFacilityID = &facilityID
+		stampApplication(txCtx, app, model.ApplicationStatusApproved, req, time.Now())
+		return s.saveTransition(txCtx, app, from)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// RejectApplication declines an application under review and gives back the
+// amount it held on the limit.
+func (s *service) RejectApplication(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error) {
+	app, err := s.application(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transitionApplication(ctx, app, model.ApplicationStatusRejected, req, s.releaseApplication(app))
+	if err != nil {
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// application loads an application the caller may see.
+func (s *service) application(ctx context.Context, id int) (*model.FinancingApplication, error) {
+	app, err := s.applicationRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get financing application", zap.Int("application_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, app.UserID, "application")
+	if err != nil {
+		return nil, err
+	}
+
+	return app, nil
+}
+
+// applicationLimit loads the limit the application draws on, which must
+// belong to the applicant.
+func (s *service) applicationLimit(ctx context.Context, app *model.FinancingApplication) (*model.UserFacilityLimit, error) {
+	limit, err := s.limitRepo.GetByID(ctx, int(app.FacilityLimitID))
+	if err != nil {
+		s.log.Error("failed to get user limit amount", zap.Int64("facility_limit_id", app.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	if limit.UserID != app.UserID {
+		return nil, errorx.NewError(errorx.ErrTypeNotFound, "facility limit not found for this user", nil)
+	}
+
+	return limit, nil
+}
+
+// transitionApplication moves the application to status to in a single
+// transaction with move, the limit movement the transition makes, if any.
+func (s *service) transitionApplication(ctx context.Context, app *model.FinancingApplication, to string, req *model.ReviewApplicationRequest, move func(ctx context.Context) error) error {
+	err := checkTransition(app, to)
+	if err != nil {
+		return err
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	if move != nil {
+		err = move(txCtx)
+		if err != nil {
+			return err
+		}
+	}
+
+	from := app.Status
+	stampApplication(txCtx, app, to, req, time.Now())
+	err = s.saveTransition(txCtx, app, from)
+	if err != nil {
+		return err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) saveTransition(ctx context.Context, app *model.FinancingApplication, from string) error {
+	err := s.applicationRepo.Transition(ctx, app, from)
+	if err != nil {
+		s.log.Error("failed to update financing application",
+			zap.Int64("application_id", app.ApplicationID),
+			zap.String("from", from),
+			zap.String("to", app.Status),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// releaseApplication returns the limit movement giving back the amount held
+// by an application, or nil for a draft that holds nothing.
+func (s *service) releaseApplication(app *model.FinancingApplication) func(ctx context.Context) error {
+	if app.Status == model.ApplicationStatusDraft {
+		return nil
+	}
+
+	return func(ctx context.Context) error {
+		return s.postApplication(ctx, app, model.LedgerRelease, app.Amount)
+	}
+}
+
+func (s *service) postApplication(ctx context.Context, app *model.FinancingApplication, entryType string, amount decimal.Decimal) error {
+	err := s.limitRepo.Post(ctx, newLedgerEntry(app.FacilityLimitID, entryType, amount, model.ReferenceApplication, app.ApplicationID))
+	if err != nil {
+		s.log.Error("failed to post application to limit",
+			zap.Int64("application_id", app.ApplicationID),
+			zap.String("entry_type", entryType),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// checkTransition rejects moving an application to a status its current
+// status does not lead to.
+func checkTransition(app *model.FinancingApplication, to string) error {
+	for _, next := range applicationTransitions[app.Status] {
+		if next == to {
+			return nil
+		}
+	}
+
+	return errorx.NewError(errorx.ErrTypeConflict, "application cannot move from "+app.Status+" to "+to, nil)
+}
+
+// stampApplication sets the new status of an application with the time it
+// moved there and, for a review, the subject of the token that reviewed it.
+func stampApplication(ctx context.Context, app *model.FinancingApplication, to string, req *model.ReviewApplicationRequest, now time.Time) {
+	app.Status = to
+	if req != nil {
+		reviewer := actor(ctx)
+		app.ReviewedBy = &reviewer
+		app.ReviewNote = req.Note
+	}
+
+	switch to {
+	case model.ApplicationStatusSubmitted:
+		app.SubmittedAt = &now
+	case model.ApplicationStatusUnderReview:
+		app.ReviewStartedAt = &now
+	case model.ApplicationStatusApproved:
+		app.ApprovedAt = &now
+	case model.ApplicationStatusRejected:
+		app.RejectedAt = &now
+	case model.ApplicationStatusCancelled:
+		app.CancelledAt = &now
+	case model.ApplicationStatusDisbursed:
+		app.DisbursedAt = &now
+	}
+}
+
+func toApplicationResponse(app *model.FinancingApplication) *model.ApplicationResponse {
+	response := &model.ApplicationResponse{
+		ApplicationID:   app.ApplicationID,
+		UserID:          app.UserID,
+		FacilityLimitID: app.FacilityLimitID,
+		Amount:          app.Amount,
+		Tenor:           app.Tenor,
+		BillingDay:      app.BillingDay,
+		GracePeriod:     app.GracePeriod,
+		Status:          app.Status,
+		UserFacilityID:  app.UserFacilityID,
+		ReviewNote:      app.ReviewNote,
+		CreatedAt:       app.CreatedAt.Format(time.RFC3339),
+		SubmittedAt:     formatTimestamp(app.SubmittedAt),
+		ReviewStartedAt: formatTimestamp(app.ReviewStartedAt),
+		ApprovedAt:      formatTimestamp(app.ApprovedAt),
+		RejectedAt:      formatTimestamp(app.RejectedAt),
+		CancelledAt:     formatTimestamp(app.CancelledAt),
+		DisbursedAt:     formatTimestamp(app.DisbursedAt),
+	}
+	if app.StartDate != nil {
+		response.StartDate = app.StartDate.Format("2006-01-02")
+	}
+	if app.ReviewedBy != nil {
+		response.ReviewedBy = *app.ReviewedBy
+	}
+
+	return response
+}
+
+// formatTimestamp formats an optional timestamp, empty when it is not set.
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
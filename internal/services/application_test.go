@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"finance/internal/auth"
+	"finance/internal/model"
+	"finance/internal/pricing"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupApplicationService() (Service, *MockUserRepo, *MockLimitRepo, *MockTenorRepo, *MockFacilityRepo, *MockDetailRepo, *MockApplicationRepo, *MockTrx) {
+	userRepo := new(MockUserRepo)
+	limitRepo := new(MockLimitRepo)
+	tenorRepo := new(MockTenorRepo)
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	applicationRepo := new(MockApplicationRepo)
+	trx := new(MockTrx)
+
+	svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), newProductRepo(), tenorRepo, facilityRepo, detailRepo, new(MockIdempotencyRepo), applicationRepo, newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+	return svc, userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, applicationRepo, trx
+}
+
+func TestService_CreateApplication(t *testing.T) {
+	req := &model.ApplicationRequest{UserID: 1, FacilityLimitID: 10, Amount: 5000000, Tenor: 6}
+	limit := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000), Status: model.LimitStatusActive}
+	minAmount := decimal.NewFromInt(1000000)
+
+	t.Run("success", func(t *testing.T) {
+		svc, userRepo, limitRepo, tenorRepo, _, _, applicationRepo, _ := setupApplicationService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(limit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 6, mock.Anything).Return(&model.Tenor{ProductID: 1, TenorValue: 6, MinAmount: &minAmount}, nil).Once()
+		applicationRepo.On("Add", mock.Anything, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.Status == model.ApplicationStatusDraft && app.StartDate == nil && app.Amount.Equal(decimal.NewFromInt(5000000))
+		})).Return(3, nil).Once()
+
+		res, err := svc.CreateApplication(context.Background(), req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), res.ApplicationID)
+		assert.Equal(t, model.ApplicationStatusDraft, res.Status)
+		assert.Empty(t, res.SubmittedAt)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error limit of another user", func(t *testing.T) {
+		svc, userRepo, limitRepo, _, _, _, applicationRepo, _ := setupApplicationService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(&model.UserFacilityLimit{FacilityLimitID: 10, UserID: 2}, nil).Once()
+
+		res, err := svc.CreateApplication(context.Background(), req)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: facility limit not found for this user", err.Error())
+		applicationRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error on behalf of another customer", func(t *testing.T) {
+		svc, userRepo, _, _, _, _, _, _ := setupApplicationService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", UserID: 2, Role: auth.RoleCustomer})
+
+		res, err := svc.CreateApplication(ctx, req)
+		assert.Nil(t, res)
+		assert.Equal(t, "forbidden: not allowed to act on behalf of this user", err.Error())
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ApplicationTransitions(t *testing.T) {
+	ctx := operator("checker")
+	txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+	review := &model.ReviewApplicationRequest{Note: "income verified"}
+	activeLimit := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000),
+		Status: model.LimitStatusActive, ValidUntil: time.Now().AddDate(1, 0, 0)}
+	application := func(status string) *model.FinancingApplication {
+		return &model.FinancingApplication{ApplicationID: 3, UserID: 1, FacilityLimitID: 10, Amount: decimal.NewFromInt(5000000), Tenor: 12, Status: status}
+	}
+
+	t.Run("submit holds the amount", func(t *testing.T) {
+		svc, userRepo, limitRepo, _, _, _, applicationRepo, trx := setupApplicationService()
+
+		applicationRepo.On("Get", ctx, 3).Return(application(model.ApplicationStatusDraft), nil).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(activeLimit, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerHold && e.Amount.Equal(decimal.NewFromInt(-5000000)) &&
+				*e.ReferenceType == model.ReferenceApplication && *e.ReferenceID == 3
+		})).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.Status == model.ApplicationStatusSubmitted && app.SubmittedAt != nil
+		}), model.ApplicationStatusDraft).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.SubmitApplication(ctx, 3, "")
+		assert.NoError(t, err)
+		assert.Equal(t, model.ApplicationStatusSubmitted, res.Status)
+		assert.NotEmpty(t, res.SubmittedAt)
+		limitRepo.AssertExpectations(t)
+		applicationRepo.AssertExpectations(t)
+	})
+
+	t.Run("error submit over the limit", func(t *testing.T) {
+		svc, userRepo, limitRepo, _, _, _, applicationRepo, trx := setupApplicationService()
+
+		applicationRepo.On("Get", ctx, 3).Return(application(model.ApplicationStatusDraft), nil).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(activeLimit, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.Anything).Return(errorx.NewError(errorx.ErrInsufficientLimit, "limit balance is not enough", nil)).Once()
+
+		res, err := svc.SubmitApplication(ctx, 3, "")
+		assert.Nil(t, res)
+		assert.Equal(t, "insufficient limit amount: limit balance is not enough", err.Error())
+		applicationRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("not allowed transitions", func(t *testing.T) {
+		cases := []struct {
+			status string
+			call   func(svc Service) (*model.ApplicationResponse, error)
+			want   string
+		}{
+			{model.ApplicationStatusSubmitted, func(svc Service) (*model.ApplicationResponse, error) { return svc.SubmitApplication(ctx, 3, "") },
+				"resource already exists: application cannot move from submitted to submitted"},
+			{model.ApplicationStatusSubmitted, func(svc Service) (*model.ApplicationResponse, error) { return svc.ApproveApplication(ctx, 3, review) },
+				"resource already exists: application cannot move from submitted to approved"},
+			{model.ApplicationStatusDraft, func(svc Service) (*model.ApplicationResponse, error) { return svc.StartReview(ctx, 3, review) },
+				"resource already exists: application cannot move from draft to under_review"},
+			{model.ApplicationStatusApproved, func(svc Service) (*model.ApplicationResponse, error) { return svc.CancelApplication(ctx, 3) },
+				"resource already exists: application has already been approved"},
+			{model.ApplicationStatusRejected, func(svc Service) (*model.ApplicationResponse, error) { return svc.RejectApplication(ctx, 3, review) },
+				"resource already exists: application cannot move from rejected to rejected"},
+			{model.ApplicationStatusDisbursed, func(svc Service) (*model.ApplicationResponse, error) { return svc.CancelApplication(ctx, 3) },
+				"resource already exists: application cannot move from disbursed to cancelled"},
+		}
+
+		for _, c := range cases {
+			svc, _, limitRepo, _, _, _, applicationRepo, trx := setupApplicationService()
+			applicationRepo.On("Get", ctx, 3).Return(application(c.status), nil).Once()
+
+			res, err := c.call(svc)
+			assert.Nil(t, res)
+			assert.Equal(t, c.want, err.Error())
+			limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			trx.AssertNotCalled(t, "Begin", mock.Anything)
+		}
+	})
+
+	t.Run("cancel gives back the held amount", func(t *testing.T) {
+		for status, releases := range map[string]bool{model.ApplicationStatusDraft: false, model.ApplicationStatusUnderReview: true} {
+			svc, _, limitRepo, _, _, _, applicationRepo, trx := setupApplicationService()
+
+			applicationRepo.On("Get", ctx, 3).Return(application(status), nil).Once()
+			trx.On("Begin", ctx).Return(txCtx, nil).Once()
+			trx.On("Rollback", txCtx).Return(nil).Once()
+			if releases {
+				limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+					return e.EntryType == model.LedgerRelease && e.Amount.Equal(decimal.NewFromInt(5000000))
+				})).Return(nil).Once()
+			}
+			applicationRepo.On("Transition", txCtx, mock.Anything, status).Return(nil).Once()
+			trx.On("Commit", txCtx).Return(nil).Once()
+
+			res, err := svc.CancelApplication(ctx, 3)
+			assert.NoError(t, err, status)
+			assert.Equal(t, model.ApplicationStatusCancelled, res.Status)
+			assert.NotEmpty(t, res.CancelledAt)
+			if !releases {
+				limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+			}
+			limitRepo.AssertExpectations(t)
+		}
+	})
+
+	t.Run("reject records the reviewer", func(t *testing.T) {
+		svc, _, limitRepo, _, _, _, applicationRepo, trx := setupApplicationService()
+
+		applicationRepo.On("Get", ctx, 3).Return(application(model.ApplicationStatusUnderReview), nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerRelease && e.Amount.Equal(decimal.NewFromInt(5000000))
+		})).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.Anything, model.ApplicationStatusUnderReview).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.RejectApplication(ctx, 3, review)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ApplicationStatusRejected, res.Status)
+		assert.Equal(t, "checker", res.ReviewedBy)
+		assert.Equal(t, "income verified", res.ReviewNote)
+		assert.NotEmpty(t, res.RejectedAt)
+	})
+
+	t.Run("approve books the facility", func(t *testing.T) {
+		svc, userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, applicationRepo, trx := setupApplicationService()
+		// the held 5,000,000 was already taken off, leaving less than the amount
+		heldLimit := *activeLimit
+		heldLimit.LimitAmount = decimal.NewFromInt(1000000)
+		app := application(model.ApplicationStatusUnderReview)
+		past := time.Now().AddDate(0, 0, -3)
+		app.StartDate = &past
+
+		applicationRepo.On("Get", ctx, 3).Return(app, nil).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(&heldLimit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(&model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		facilityRepo.On("Add", txCtx, mock.MatchedBy(func(f *model.UserFacility) bool {
+			return f.StartDate.Equal(time.Now().UTC().Truncate(24 * time.Hour))
+		})).Return(8, nil).Once()
+		detailRepo.On("Add", txCtx, mock.Anything).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerRelease && e.Amount.Equal(decimal.NewFromInt(5000000)) && *e.ReferenceID == 3
+		})).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.Status == model.ApplicationStatusApproved && *app.UserFacilityID == 8 && app.ApprovedAt != nil
+		}), model.ApplicationStatusUnderReview).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerDrawdown && e.Amount.Equal(decimal.NewFromInt(-5000000)) && *e.ReferenceID == 8
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.ApproveApplication(ctx, 3, review)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ApplicationStatusApproved, res.Status)
+		assert.Equal(t, int64(8), *res.UserFacilityID)
+		assert.Equal(t, "checker", res.ReviewedBy)
+		limitRepo.AssertExpectations(t)
+		applicationRepo.AssertExpectations(t)
+	})
+
+	t.Run("error approve decided meanwhile", func(t *testing.T) {
+		svc, userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, applicationRepo, trx := setupApplicationService()
+
+		applicationRepo.On("Get", ctx, 3).Return(application(model.ApplicationStatusUnderReview), nil).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(activeLimit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(&model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		facilityRepo.On("Add", txCtx, mock.Anything).Return(8, nil).Once()
+		detailRepo.On("Add", txCtx, mock.Anything).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.Anything).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.Anything, model.ApplicationStatusUnderReview).
+			Return(errorx.NewError(errorx.ErrTypeConflict, "application is no longer under_review", nil)).Once()
+
+		res, err := svc.ApproveApplication(ctx, 3, review)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: application is no longer under_review", err.Error())
+		trx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("error application of another customer", func(t *testing.T) {
+		svc, _, _, _, _, _, applicationRepo, trx := setupApplicationService()
+		customer := auth.WithPrincipal(ctx, &auth.Principal{Subject: "2", UserID: 2, Role: auth.RoleCustomer})
+
+		applicationRepo.On("Get", customer, 3).Return(application(model.ApplicationStatusSubmitted), nil).Once()
+
+		res, err := svc.CancelApplication(customer, 3)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: application not found", err.Error())
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+}
+
+func TestService_ListApplications(t *testing.T) {
+	svc, _, _, _, _, _, applicationRepo, _ := setupApplicationService()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "1", UserID: 1, Role: auth.RoleCustomer})
+
+	applicationRepo.On("List", ctx, &model.ApplicationFilter{UserID: 1, Status: model.ApplicationStatusSubmitted, Limit: 3}).Return([]*model.FinancingApplication{
+		{ApplicationID: 4, UserID: 1, Status: model.ApplicationStatusSubmitted},
+		{ApplicationID: 6, UserID: 1, Status: model.ApplicationStatusSubmitted},
+		{ApplicationID: 9, UserID: 1, Status: model.ApplicationStatusSubmitted},
+	}, nil).Once()
+
+	// a customer asking for another user's applications only gets their own
+	res, err := svc.ListApplications(ctx, &model.ListApplicationsRequest{UserID: 2, Status: model.ApplicationStatusSubmitted, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 2)
+	assert.Equal(t, int64(6), res.NextAfter)
+}
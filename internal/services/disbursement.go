@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/disbursement"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	// disburseBatchSize caps how many disbursements one run sends.
+	disburseBatchSize = 50
+	// disburseLease is how long a claimed disbursement is left to the worker
+	// that claimed it before another worker may try it again.
+	disburseLease = 5 * time.Minute
+	// maxLastErrorLength fits the last error of a disbursement or an outbox
+	// event into its column.
+	maxLastErrorLength = 255
+)
+
+// DisbursementConfig sets how often a transfer is tried before the bank is
+// asked how it ended, and how long to wait between tries.
+type DisbursementConfig struct {
+	MaxAttempts int
+	Backoff     disbursement.Backoff
+}
+
+type DisbursementService interface {
+	List(ctx context.Context, req *model.ListDisbursementsRequest) ([]*model.DisbursementResponse, error)
+	ProcessDue(ctx context.Context, now time.Time) (int, error)
+	Retry(ctx context.Context, id int) (*model.DisbursementResponse, error)
+}
+
+type disbursementService struct {
+	disbursementRepo repository.DisbursementRepository
+	facilityRepo     repository.FacilityRepository
+	detailRepo       repository.DetailRepository
+	applicationRepo  repository.ApplicationRepository
+	limitRepo        repository.LimitRepository
+	outboxRepo       repository.OutboxRepository
+	bank             disbursement.Disburser
+	cfg              DisbursementConfig
+	log              *logger.Logger
+	trx              postgres.Trx
+}
+
+func NewDisbursementService(
+	disbursementRepo repository.DisbursementRepository,
+	facilityRepo repository.FacilityRepository,
+	detailRepo repository.DetailRepository,
+	applicationRepo repository.ApplicationRepository,
+	limitRepo repository.LimitRepository,
+	outboxRepo repository.OutboxRepository,
+	bank disbursement.Disburser,
+	cfg DisbursementConfig,
+	log *logger.Logger,
+	trx postgres.Trx,
+) DisbursementService {
+	return &disbursementService{
+		disbursementRepo: disbursementRepo,
+		facilityRepo:     facilityRepo,
+		detailRepo:       detailRepo,
+		applicationRepo:  applicationRepo,
+		limitRepo:        limitRepo,
+		outboxRepo:       outboxRepo,
+		bank:             bank,
+		cfg:              cfg,
+		log:              log,
+		trx:              trx,
+	}
+}
+
+func (s *disbursementService) List(ctx context.Context, req *model.ListDisbursementsRequest) ([]*model.DisbursementResponse, error) {
+	disbursements, err := s.disbursementRepo.List(ctx, req.Status)
+	if err != nil {
+		s.log.Error("failed to get list disbursements", zap.String("status", req.Status), zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.DisbursementResponse{}
+	for _, d := range disbursements {
+		response = append(response, toDisbursementResponse(d))
+	}
+
+	return response, nil
+}
+
+// ProcessDue sends the disbursements that are due at or before now and
+// returns how many were sent or given up on. A transfer that fails for a
+// reason that may pass is tried again later with backoff. One the bank
+// rejects is compensated: the facility is cancelled and its amount given back
+// to the limit. One that keeps failing past MaxAttempts is resolved with the
+// bank first.
+func (s *disbursementService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	claimed, err := s.disbursementRepo.ClaimDue(ctx, now, now.Add(disburseLease), disburseBatchSize)
+	if err != nil {
+		s.log.Error("failed to claim due disbursements", zap.Error(err))
+		return 0, err
+	}
+
+	completed := 0
+	for _, d := range claimed {
+		done, err := s.disburse(ctx, d, now)
+		if err != nil {
+			s.log.Warn("failed to process disbursement", zap.Int64("disbursement_id", d.DisbursementID), zap.Error(err))
+			continue
+		}
+		if done {
+			completed++
+		}
+	}
+
+	return completed, nil
+}
+
+// disburse makes one attempt at a claimed disbursement and reports whether
+// it is now complete. The bank is called outside any transaction, so a slow
+// bank never holds locks on the facility or the limit.
+func (s *disbursementService) disburse(ctx context.Context, d *model.Disbursement, now time.Time) (bool, error) {
+	reference := fmt.Sprintf("disbursement-%d", d.DisbursementID)
+	ref, err := s.bank.Disburse(ctx, disbursement.Transfer{
+		Reference: reference,
+		UserID:    d.UserID,
+		Amount:    d.Amount,
+	})
+	if err == nil {
+		return true, s.settle(ctx, d, ref, now)
+	}
+
+	d.LastError = lastError(err)
+
+	if errors.Is(err, disbursement.ErrRejected) {
+		s.log.Warn("disbursement rejected, cancelling facility",
+			zap.Int64("disbursement_id", d.DisbursementID),
+			zap.Int64("user_facility_id", d.UserFacilityID),
+			zap.Error(err))
+		return s.compensate(ctx, d, now)
+	}
+
+	if d.Attempts >= s.cfg.MaxAttempts {
+		return s.resolve(ctx, d, reference, now)
+	}
+
+	d.NextAttemptAt = now.Add(s.cfg.Backoff.Delay(d.Attempts))
+	err = s.disbursementRepo.Reschedule(ctx, d)
+	if err != nil {
+		s.log.Error("failed to reschedule disbursement", zap.Int64("disbursement_id", d.DisbursementID), zap.Error(err))
+		return false, err
+	}
+
+	return false, nil
+}
+
+// resolve decides a disbursement that ran out of attempts without the bank
+// rejecting it. Any of those attempts may still have gone through, so the
+// bank is asked before anything is undone: a transfer it sent is settled, one
+// it never received is compensated, and one it cannot tell about is parked in
+// manual review, keeping the facility until an operator requeues it.
+func (s *disbursementService) resolve(ctx context.Context, d *model.Disbursement, reference string, now time.Time) (bool, error) {
+	ref, err := s.bank.Status(ctx, reference)
+	if err == nil {
+		return true, s.settle(ctx, d, ref, now)
+	}
+
+	if errors.Is(err, disbursement.ErrNotFound) {
+		s.log.Warn("disbursement failed for good, cancelling facility",
+			zap.Int64("disbursement_id", d.DisbursementID),
+			zap.Int64("user_facility_id", d.UserFacilityID),
+			zap.Int("attempts", d.Attempts),
+			zap.String("last_error", d.LastError))
+		return s.compensate(ctx, d, now)
+	}
+
+	s.log.Warn("disbursement outcome unknown, parking for manual review",
+		zap.Int64("disbursement_id", d.DisbursementID),
+		zap.Int64("user_facility_id", d.UserFacilityID),
+		zap.Int("attempts", d.Attempts),
+		zap.Error(err))
+	d.Status = model.DisbursementStatusManualReview
+	d.LastError = lastError(err)
+
+	return false, s.complete(ctx, d)
+}
+
+// settle records a transfer the bank accepted and moves the application
+// that booked the facility, if any, to disbursed.
+func (s *disbursementService) settle(ctx context.Context, d *model.Disbursement, ref string, now time.Time) error {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	d.Status = model.DisbursementStatusSucceeded
+	d.BankReference = &ref
+	d.LastError = ""
+	d.CompletedAt = &now
+	err = s.complete(txCtx, d)
+	if err != nil {
+		return err
+	}
+
+	err = s.moveApplication(txCtx, d.UserFacilityID, model.ApplicationStatusDisbursed, now)
+	if err != nil {
+		return err
+	}
+
+	return s.trx.Commit(txCtx)
+}
+
+// compensate undoes a facility whose money could not be sent and reports
+// whether the disbursement is now complete. In a single transaction the
+// disbursement is failed, the facility, still active as no payment is taken
+// before it is disbursed, cancelled, the principal it still draws on the
+// limit reversed and the application that booked it, if any, cancelled, with
+// the facility closing written to the outbox. A facility that is no longer
+// active was changed by hand and is not undone: the disbursement is parked in
+// manual review instead, so it is not claimed again.
+func (s *disbursementService) compensate(ctx context.Context, d *model.Disbursement, now time.Time) (bool, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return false, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	facilityID := int(d.UserFacilityID)
+	facility, err := s.facilityRepo.Get(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", facilityID), zap.Error(err))
+		return false, err
+	}
+
+	if facility.Status != model.FacilityStatusActive {
+		s.log.Warn("facility to cancel is no longer active, parking for manual review",
+			zap.Int64("disbursement_id", d.DisbursementID),
+			zap.Int("facility_id", facilityID),
+			zap.String("status", facility.Status))
+		d.Status = model.DisbursementStatusManualReview
+		d.LastError = lastError(fmt.Errorf("facility is %s: %s", facility.Status, d.LastError))
+		err = s.complete(txCtx, d)
+		if err != nil {
+			return false, err
+		}
+
+		return false, s.trx.Commit(txCtx)
+	}
+
+	d.Status = model.DisbursementStatusFailed
+	d.CompletedAt = &now
+	err = s.complete(txCtx, d)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.facilityRepo.UpdateStatus(txCtx, facilityID, model.FacilityStatusCancelled)
+	if err != nil {
+		s.log.Error("failed to cancel facility", zap.Int("facility_id", facilityID), zap.Error(err))
+		return false, err
+	}
+
+	unpaid, err := s.detailRepo.ListUnpaid(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get unpaid installments", zap.Int("facility_id", facilityID), zap.Error(err))
+		return false, err
+	}
+
+	principal := decimal.Zero
+	for _, detail := range unpaid {
+		principal = principal.Add(detail.PrincipalAmount)
+	}
+
+	if principal.IsPositive() {
+		err = s.limitRepo.Post(txCtx, newLedgerEntry(facility.FacilityLimitID, model.LedgerReversal, principal, model.ReferenceFacility, facility.UserFacilityID))
+		if err != nil {
+			s.log.Error("failed to reverse facility drawdown", zap.Int64("facility_limit_id", facility.FacilityLimitID), zap.Error(err))
+			return false, err
+		}
+	}
+
+	err = s.moveApplication(txCtx, d.UserFacilityID, model.ApplicationStatusCancelled, now)
+	if err != nil {
+		return false, err
+	}
+
+	err = addFacilityEvent(txCtx, s.outboxRepo, s.log, model.EventFacilityClosed, facility.UserFacilityID, model.FacilityClosedEvent{
+		UserFacilityID: facility.UserFacilityID,
+		Status:         model.FacilityStatusCancelled,
+		ClosedAt:       now.Format(time.RFC3339),
+	}, now)
+	if err != nil {
+		return false, err
+	}
+
+	return true, s.trx.Commit(txCtx)
+}
+
+// Retry puts a disbursement parked in manual review back in the queue. The
+// bank sends a reference only once, so a transfer that did go through is
+// settled on the next attempt instead of being sent again.
+func (s *disbursementService) Retry(ctx context.Context, id int) (*model.DisbursementResponse, error) {
+	d, err := s.disbursementRepo.Requeue(ctx, id, time.Now())
+	if err != nil {
+		s.log.Error("failed to requeue disbursement", zap.Int("disbursement_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toDisbursementResponse(d), nil
+}
+
+func (s *disbursementService) complete(ctx context.Context, d *model.Disbursement) error {
+	err := s.disbursementRepo.Complete(ctx, d)
+	if err != nil {
+		s.log.Error("failed to complete disbursement",
+			zap.Int64("disbursement_id", d.DisbursementID),
+			zap.String("status", d.Status),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// moveApplication moves the approved application that booked the facility
+// to status to. A facility submitted directly has no application to move.
+func (s *disbursementService) moveApplication(ctx context.Context, facilityID int64, to string, now time.Time) error {
+	app, err := s.applicationRepo.GetByFacility(ctx, facilityID)
+	if err != nil {
+		var appErr *errorx.AppError
+		if errors.As(err, &appErr) && appErr.Type == errorx.ErrTypeNotFound {
+			return nil
+		}
+		s.log.Error("failed to get financing application", zap.Int64("user_facility_id", facilityID), zap.Error(err))
+		return err
+	}
+
+	err = checkTransition(app, to)
+	if err != nil {
+		return err
+	}
+
+	from := app.Status
+	stampApplication(ctx, app, to, nil, now)
+	err = s.applicationRepo.Transition(ctx, app, from)
+	if err != nil {
+		s.log.Error("failed to update financing application",
+			zap.Int64("application_id", app.ApplicationID),
+			zap.String("from", from),
+			zap.String("to", to),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// lastError formats err to fit the last_error column.
+func lastError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxLastErrorLength {
+		msg = msg[:maxLastErrorLength]
+	}
+
+	return msg
+}
+
+func toDisbursementResponse(d *model.Disbursement) *model.DisbursementResponse {
+	response := &model.DisbursementResponse{
+		DisbursementID: d.DisbursementID,
+		UserFacilityID: d.UserFacilityID,
+		UserID:         d.UserID,
+		Amount:         d.Amount,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+		CompletedAt:    formatTimestamp(d.CompletedAt),
+	}
+	if d.Status == model.DisbursementStatusPending {
+		response.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if d.BankReference != nil {
+		response.BankReference = *d.BankReference
+	}
+
+	return response
+}
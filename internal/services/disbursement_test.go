@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/disbursement"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupDisbursementService() (DisbursementService, *MockDisbursementRepo, *MockFacilityRepo, *MockDetailRepo, *MockApplicationRepo, *MockLimitRepo, *MockDisburser, *MockTrx) {
+	disbursementRepo := new(MockDisbursementRepo)
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	applicationRepo := new(MockApplicationRepo)
+	limitRepo := new(MockLimitRepo)
+	bank := new(MockDisburser)
+	trx := new(MockTrx)
+	cfg := DisbursementConfig{MaxAttempts: 3, Backoff: disbursement.Backoff{Base: 30 * time.Second, Max: 10 * time.Minute}}
+	svc := NewDisbursementService(disbursementRepo, facilityRepo, detailRepo, applicationRepo, limitRepo, newOutboxRepo(), bank, cfg, logger.NewNop(), trx)
+
+	return svc, disbursementRepo, facilityRepo, detailRepo, applicationRepo, limitRepo, bank, trx
+}
+
+func TestDisbursementService_ProcessDue(t *testing.T) {
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+	now := time.Now()
+	due := func(attempts int) *model.Disbursement {
+		return &model.Disbursement{DisbursementID: 1, UserFacilityID: 7, UserID: 1, Amount: decimal.NewFromInt(3000000),
+			Status: model.DisbursementStatusPending, Attempts: attempts}
+	}
+	transfer := disbursement.Transfer{Reference: "disbursement-1", UserID: 1, Amount: decimal.NewFromInt(3000000)}
+	approved := func() *model.FinancingApplication {
+		facilityID := int64(7)
+		return &model.FinancingApplication{ApplicationID: 3, UserID: 1, Status: model.ApplicationStatusApproved, UserFacilityID: &facilityID}
+	}
+
+	t.Run("success moves the application to disbursed", func(t *testing.T) {
+		svc, disbursementRepo, _, _, applicationRepo, _, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(1)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("FAKE-000001", nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		disbursementRepo.On("Complete", txCtx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.Status == model.DisbursementStatusSucceeded && *d.BankReference == "FAKE-000001" && d.CompletedAt.Equal(now)
+		})).Return(nil).Once()
+		applicationRepo.On("GetByFacility", txCtx, int64(7)).Return(approved(), nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.Status == model.ApplicationStatusDisbursed && app.DisbursedAt.Equal(now)
+		}), model.ApplicationStatusApproved).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, completed)
+		disbursementRepo.AssertExpectations(t)
+		applicationRepo.AssertExpectations(t)
+	})
+
+	t.Run("transient error is retried with backoff", func(t *testing.T) {
+		svc, disbursementRepo, facilityRepo, _, _, limitRepo, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(2)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("", errors.New("bank timeout")).Once()
+		disbursementRepo.On("Reschedule", ctx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.LastError == "bank timeout" && d.NextAttemptAt.Equal(now.Add(time.Minute))
+		})).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, completed)
+		disbursementRepo.AssertExpectations(t)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+		facilityRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("permanent failure cancels the facility and restores the limit", func(t *testing.T) {
+		cases := map[string]struct {
+			attempts int
+			err      error
+			lookup   error
+		}{
+			"rejected by the bank": {1, fmt.Errorf("%w: account closed", disbursement.ErrRejected), nil},
+			"out of retry attempts and never received": {3, errors.New("bank timeout"),
+				fmt.Errorf("%w: disbursement-1", disbursement.ErrNotFound)},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				svc, disbursementRepo, facilityRepo, detailRepo, applicationRepo, limitRepo, bank, trx := setupDisbursementService()
+
+				disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(c.attempts)}, nil).Once()
+				bank.On("Disburse", ctx, transfer).Return("", c.err).Once()
+				if c.lookup != nil {
+					bank.On("Status", ctx, "disbursement-1").Return("", c.lookup).Once()
+				}
+				trx.On("Begin", ctx).Return(txCtx, nil).Once()
+				trx.On("Rollback", txCtx).Return(nil).Once()
+				disbursementRepo.On("Complete", txCtx, mock.MatchedBy(func(d *model.Disbursement) bool {
+					return d.Status == model.DisbursementStatusFailed && d.LastError == c.err.Error() && d.BankReference == nil
+				})).Return(nil).Once()
+				facilityRepo.On("Get", txCtx, 7).Return(&model.UserFacility{UserFacilityID: 7, UserID: 1, FacilityLimitID: 10, Status: model.FacilityStatusActive}, nil).Once()
+				facilityRepo.On("UpdateStatus", txCtx, 7, model.FacilityStatusCancelled).Return(nil).Once()
+				detailRepo.On("ListUnpaid", txCtx, 7).Return([]*model.UserFacilityDetail{
+					{PrincipalAmount: decimal.NewFromInt(1000000)},
+					{PrincipalAmount: decimal.NewFromInt(2000000)},
+				}, nil).Once()
+				limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+					return e.FacilityLimitID == 10 && e.EntryType == model.LedgerReversal && e.Amount.Equal(decimal.NewFromInt(3000000)) &&
+						*e.ReferenceType == model.ReferenceFacility && *e.ReferenceID == 7
+				})).Return(nil).Once()
+				applicationRepo.On("GetByFacility", txCtx, int64(7)).Return(approved(), nil).Once()
+				applicationRepo.On("Transition", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+					return app.Status == model.ApplicationStatusCancelled && app.CancelledAt.Equal(now)
+				}), model.ApplicationStatusApproved).Return(nil).Once()
+				trx.On("Commit", txCtx).Return(nil).Once()
+
+				completed, err := svc.ProcessDue(ctx, now)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, completed)
+				facilityRepo.AssertExpectations(t)
+				limitRepo.AssertExpectations(t)
+				applicationRepo.AssertExpectations(t)
+				disbursementRepo.AssertNotCalled(t, "Reschedule", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("facility no longer active is parked for manual review", func(t *testing.T) {
+		svc, disbursementRepo, facilityRepo, _, _, limitRepo, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(1)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("", fmt.Errorf("%w: account closed", disbursement.ErrRejected)).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(&model.UserFacility{UserFacilityID: 7, FacilityLimitID: 10, Status: model.FacilityStatusPaidOff}, nil).Once()
+		disbursementRepo.On("Complete", txCtx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.Status == model.DisbursementStatusManualReview && d.CompletedAt == nil &&
+				d.LastError == "facility is paid_off: disbursement: transfer rejected by the bank: account closed"
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, completed)
+		disbursementRepo.AssertExpectations(t)
+		facilityRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("out of retry attempts but sent by the bank settles", func(t *testing.T) {
+		svc, disbursementRepo, facilityRepo, _, applicationRepo, _, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(3)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("", errors.New("bank timeout")).Once()
+		bank.On("Status", ctx, "disbursement-1").Return("FAKE-000001", nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		disbursementRepo.On("Complete", txCtx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.Status == model.DisbursementStatusSucceeded && *d.BankReference == "FAKE-000001" && d.LastError == ""
+		})).Return(nil).Once()
+		applicationRepo.On("GetByFacility", txCtx, int64(7)).Return(approved(), nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.Anything, model.ApplicationStatusApproved).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, completed)
+		disbursementRepo.AssertExpectations(t)
+		facilityRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("out of retry attempts with unknown outcome waits for manual review", func(t *testing.T) {
+		svc, disbursementRepo, facilityRepo, _, _, limitRepo, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(3)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("", errors.New("bank timeout")).Once()
+		bank.On("Status", ctx, "disbursement-1").Return("", errors.New("bank unavailable")).Once()
+		disbursementRepo.On("Complete", ctx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.Status == model.DisbursementStatusManualReview && d.LastError == "bank unavailable" && d.CompletedAt == nil
+		})).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, completed)
+		disbursementRepo.AssertExpectations(t)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+		facilityRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("facility submitted directly has no application to move", func(t *testing.T) {
+		svc, disbursementRepo, _, _, applicationRepo, _, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(1)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("FAKE-000001", nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		disbursementRepo.On("Complete", txCtx, mock.Anything).Return(nil).Once()
+		applicationRepo.On("GetByFacility", txCtx, int64(7)).Return(nil, errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, completed)
+		applicationRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed settle is not counted and left to the lease", func(t *testing.T) {
+		svc, disbursementRepo, _, _, _, _, bank, trx := setupDisbursementService()
+
+		disbursementRepo.On("ClaimDue", ctx, now, now.Add(disburseLease), disburseBatchSize).Return([]*model.Disbursement{due(1)}, nil).Once()
+		bank.On("Disburse", ctx, transfer).Return("FAKE-000001", nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		disbursementRepo.On("Complete", txCtx, mock.Anything).Return(errors.New("db error")).Once()
+
+		completed, err := svc.ProcessDue(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, completed)
+		trx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+}
+
+func TestDisbursementService_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		svc, disbursementRepo, _, _, _, _, _, _ := setupDisbursementService()
+
+		disbursementRepo.On("Requeue", ctx, 1, mock.Anything).Return(&model.Disbursement{DisbursementID: 1, UserFacilityID: 7,
+			Status: model.DisbursementStatusPending, NextAttemptAt: time.Now(), CreatedAt: time.Now()}, nil).Once()
+
+		res, err := svc.Retry(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.DisbursementStatusPending, res.Status)
+		assert.NotEmpty(t, res.NextAttemptAt)
+	})
+
+	t.Run("error not in manual review", func(t *testing.T) {
+		svc, disbursementRepo, _, _, _, _, _, _ := setupDisbursementService()
+
+		disbursementRepo.On("Requeue", ctx, 1, mock.Anything).
+			Return(nil, errorx.NewError(errorx.ErrTypeConflict, "disbursement is not waiting for manual review", nil)).Once()
+
+		res, err := svc.Retry(ctx, 1)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: disbursement is not waiting for manual review", err.Error())
+	})
+}
@@ -2,11 +2,19 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"finance/internal/auth"
+	"finance/internal/calendar"
+	"finance/internal/duedate"
 	"finance/internal/model"
+	"finance/internal/phone"
+	"finance/internal/pricing"
 	"finance/internal/repository"
 	"finance/pkg/errorx"
 	"finance/pkg/logger"
 	"finance/pkg/postgres"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -14,122 +22,350 @@ import (
 )
 
 type Service interface {
-	ListUserLimit(ctx context.Context) ([]*model.UserLimit, error)
-	TenorList(ctx context.Context) ([]*model.ListTenor, error)
-	Installment(ctx context.Context, amount int64) ([]*model.InstallmentSimulation, error)
+	ListUserLimit(ctx context.Context, req *model.ListUserLimitsRequest) (*model.ListUserLimitsResponse, error)
+	TenorList(ctx context.Context, req *model.ListTenorsRequest) ([]*model.ListTenor, error)
+	Installment(ctx context.Context, req *model.CalculateInstallmentsRequest) ([]*model.InstallmentSimulation, error)
 	Submit(ctx context.Context, req *model.SubmitFinancingRequest) (*model.SubmitFinancingResponse, error)
+	CaptureHold(ctx context.Context, holdID int, req *model.CaptureHoldRequest) (*model.ApplicationResponse, error)
+	GetFacility(ctx context.Context, id int) (*model.FacilityResponse, error)
+	ListFacilities(ctx context.Context, req *model.ListFacilitiesRequest) (*model.ListFacilitiesResponse, error)
+	RecomputeFacility(ctx context.Context, id int) (*model.RecomputeResponse, error)
+	CreateApplication(ctx context.Context, req *model.ApplicationRequest) (*model.ApplicationResponse, error)
+	GetApplication(ctx context.Context, id int) (*model.ApplicationResponse, error)
+	ListApplications(ctx context.Context, req *model.ListApplicationsRequest) (*model.ListApplicationsResponse, error)
+	SubmitApplication(ctx context.Context, id int, idempotencyKey string) (*model.ApplicationResponse, error)
+	CancelApplication(ctx context.Context, id int) (*model.ApplicationResponse, error)
+	StartReview(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error)
+	ApproveApplication(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error)
+	RejectApplication(ctx context.Context, id int, req *model.ReviewApplicationRequest) (*model.ApplicationResponse, error)
 }
 
+const defaultPageSize = 20
+
 type service struct {
-	userRepo     repository.UserRepository
-	limitRepo    repository.LimitRepository
-	tenorRepo    repository.TenorRepository
-	facilityRepo repository.FacilityRepository
-	detailRepo   repository.DetailRepository
-	log          *logger.Logger
-	trx          postgres.Trx
+	userRepo         repository.UserRepository
+	kycRepo          repository.KYCRepository
+	limitRepo        repository.LimitRepository
+	holdRepo         repository.HoldRepository
+	productRepo      repository.ProductRepository
+	tenorRepo        repository.TenorRepository
+	facilityRepo     repository.FacilityRepository
+	detailRepo       repository.DetailRepository
+	idempotencyRepo  repository.IdempotencyRepository
+	applicationRepo  repository.ApplicationRepository
+	disbursementRepo repository.DisbursementRepository
+	outboxRepo       repository.OutboxRepository
+	holidayRepo      repository.HolidayRepository
+	pricer           pricing.Pricer
+	rounding         pricing.Rounding
+	log              *logger.Logger
+	trx              postgres.Trx
 }
 
 func NewService(
 	userRepo repository.UserRepository,
+	kycRepo repository.KYCRepository,
 	limitRepo repository.LimitRepository,
+	holdRepo repository.HoldRepository,
+	productRepo repository.ProductRepository,
 	tenorRepo repository.TenorRepository,
 	facilityRepo repository.FacilityRepository,
 	detailRepo repository.DetailRepository,
+	idempotencyRepo repository.IdempotencyRepository,
+	applicationRepo repository.ApplicationRepository,
+	disbursementRepo repository.DisbursementRepository,
+	outboxRepo repository.OutboxRepository,
+	holidayRepo repository.HolidayRepository,
+	pricer pricing.Pricer,
+	rounding pricing.Rounding,
 	log *logger.Logger,
 	trx postgres.Trx,
 ) Service {
 	return &service{
-		userRepo:     userRepo,
-		limitRepo:    limitRepo,
-		tenorRepo:    tenorRepo,
-		facilityRepo: facilityRepo,
-		detailRepo:   detailRepo,
-		log:          log,
-		trx:          trx,
+		userRepo:         userRepo,
+		kycRepo:          kycRepo,
+		limitRepo:        limitRepo,
+		holdRepo:         holdRepo,
+		productRepo:      productRepo,
+		tenorRepo:        tenorRepo,
+		facilityRepo:     facilityRepo,
+		detailRepo:       detailRepo,
+		idempotencyRepo:  idempotencyRepo,
+		applicationRepo:  applicationRepo,
+		disbursementRepo: disbursementRepo,
+		outboxRepo:       outboxRepo,
+		holidayRepo:      holidayRepo,
+		pricer:           pricer,
+		rounding:         rounding,
+		log:              log,
+		trx:              trx,
 	}
 }
 
-func (s *service) calculateFinancials(amount decimal.Decimal, tenor int) (monthly, totalMargin, totalPayment decimal.Decimal) {
-	rate := decimal.RequireFromString("0.20")
-	tenorDec := decimal.NewFromInt(int64(tenor))
-	monthsInYear := decimal.NewFromInt(12)
-
-	totalMargin = amount.Mul(rate).Mul(tenorDec).Div(monthsInYear).Round(2)
-	totalPayment = amount.Add(totalMargin)
-	monthly = totalPayment.DivRound(tenorDec, 2)
+// ListUserLimit lists users with their limits, a user without a limit
+// included once, or only their own for a customer. LimitAmount is what is
+// left to draw on, after the active holds and pending applications shown in
+// HeldAmount were taken off.
+func (s *service) ListUserLimit(ctx context.Context, req *model.ListUserLimitsRequest) (*model.ListUserLimitsResponse, error) {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.IsAdmin() {
+		req.UserID = principal.UserID
+	}
 
-	return monthly, totalMargin, totalPayment
-}
+	filter, err := newUserLimitFilter(req)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *service) ListUserLimit(ctx context.Context) ([]*model.UserLimit, error) {
-	var response []*model.UserLimit
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
 
-	users, err := s.userRepo.List(ctx)
+	rows, err := s.limitRepo.ListUserLimits(ctx, filter)
 	if err != nil {
-		s.log.Error("failed to get list user", zap.Error(err))
+		s.log.Error("failed to get list user limit", zap.Error(err))
 		return nil, err
 	}
 
-	for _, user := range users {
-		limit, err := s.limitRepo.Get(ctx, int(user.UserID))
-		if err != nil {
-			s.log.Warn("failed to get limit user", zap.Int64("user_id", user.UserID), zap.Error(err))
-			continue
-		}
+	response := &model.ListUserLimitsResponse{Data: []*model.UserLimit{}}
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		response.NextCursor = encodeUserLimitCursor(rows[pageSize-1])
+	}
 
-		response = append(response, &model.UserLimit{
-			UserID:      user.UserID,
-			Name:        user.Name,
-			Phone:       user.Phone,
-			LimitId:     limit.FacilityLimitID,
-			LimitAmount: limit.LimitAmount,
-		})
+	for _, row := range rows {
+		response.Data = append(response.Data, toUserLimit(row))
 	}
 
 	return response, nil
 }
 
-func (s *service) TenorList(ctx context.Context) ([]*model.ListTenor, error) {
+func (s *service) TenorList(ctx context.Context, req *model.ListTenorsRequest) ([]*model.ListTenor, error) {
 	var response []*model.ListTenor
 
-	tenors, err := s.tenorRepo.List(ctx)
+	tenors, err := s.tenorRepo.List(ctx, int(req.ProductID), time.Now())
 	if err != nil {
 		s.log.Error("failed to get list tenors")
 		return nil, err
 	}
 
 	for _, tenor := range tenors {
-		response = append(response, &model.ListTenor{TenorValue: tenor.TenorValue})
+		response = append(response, &model.ListTenor{
+			ProductID:  tenor.ProductID,
+			TenorValue: tenor.TenorValue,
+			Rate:       tenor.Rate,
+			MinAmount:  tenor.MinAmount,
+			MaxAmount:  tenor.MaxAmount,
+		})
 	}
 
 	return response, nil
 }
 
-func (s *service) Installment(ctx context.Context, amount int64) ([]*model.InstallmentSimulation, error) {
+func (s *service) Installment(ctx context.Context, req *model.CalculateInstallmentsRequest) ([]*model.InstallmentSimulation, error) {
 	var response []*model.InstallmentSimulation
 
-	tenors, err := s.tenorRepo.List(ctx)
+	amountDec := decimal.NewFromInt(req.Amount)
+
+	startDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.StartDate != "" {
+		date, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			s.log.Error("invalid date format", zap.Error(err))
+			return nil, errorx.NewError(errorx.ErrTypeValidation, "invalid date format, use YYYY-MM-DD", err)
+		}
+		startDate = date
+	}
+
+	pricers, err := s.productPricers(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenors, err := s.tenorRepo.List(ctx, int(req.ProductID), time.Now())
 	if err != nil {
 		s.log.Error("failed to get list tenors", zap.Error(err))
 		return nil, err
 	}
 
 	for _, tenor := range tenors {
-		monthly, margin, payment := s.calculateFinancials(decimal.NewFromInt(amount), tenor.TenorValue)
+		if !tenorAllows(tenor, amountDec) {
+			continue
+		}
+
+		pricer, ok := pricers[tenor.ProductID]
+		if !ok {
+			continue
+		}
+
+		quote, schedule, err := s.buildSchedule(pricer, amountDec, tenor.TenorValue, tenor.Rate)
+		if err != nil {
+			s.log.Error("installment schedule does not reconcile", zap.Int("tenor", tenor.TenorValue), zap.Error(err))
+			return nil, errorx.NewError(errorx.ErrTypeInternal, "installment schedule does not reconcile with total payment", err)
+		}
+
+		first := schedule[0].Amount
+		last := schedule[len(schedule)-1].Amount
+		dueDates, err := s.dueDates(ctx, startDate, tenor, req.BillingDay, req.GracePeriod)
+		if err != nil {
+			s.log.Error("failed to load holiday calendar", zap.Error(err))
+			return nil, err
+		}
 
 		response = append(response, &model.InstallmentSimulation{
+			ProductID:          tenor.ProductID,
 			Tenor:              tenor.TenorValue,
-			MonthlyInstallment: monthly,
-			TotalMargin:        margin,
-			TotalPayment:       payment,
+			MonthlyInstallment: quote.MonthlyInstallment,
+			FirstInstallment:   &first,
+			LastInstallment:    &last,
+			TotalMargin:        quote.TotalMargin,
+			TotalPayment:       quote.TotalPayment,
+			FirstDueDate:       dueDates[0].Format("2006-01-02"),
+			LastDueDate:        dueDates[len(dueDates)-1].Format("2006-01-02"),
 		})
 	}
 
 	return response, nil
 }
 
+// Submit books a facility directly, without the review a financing
+// application goes through, so only admins reach it over HTTP. With an
+// idempotency key a retry of a booked submission gets the original response
+// back instead of a second facility.
 func (s *service) Submit(ctx context.Context, req *model.SubmitFinancingRequest) (*model.SubmitFinancingResponse, error) {
+	err := checkAccess(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IdempotencyKey != "" {
+		err = checkIdempotencyKey(req.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.replaySubmission(ctx, req)
+		if err != nil || response != nil {
+			return response, err
+		}
+	}
+
+	return s.submit(ctx, req, nil)
+}
+
+// CaptureHold turns a hold into a submitted application once the merchant
+// accepts the order. The amount moves from the hold to the application in
+// one transaction, so it stays taken off the limit while the application is
+// reviewed, and the facility is only booked when a reviewer approves it.
+func (s *service) CaptureHold(ctx context.Context, holdID int, req *model.CaptureHoldRequest) (*model.ApplicationResponse, error) {
+	hold, err := s.holdRepo.Get(ctx, holdID)
+	if err != nil {
+		s.log.Error("failed to get limit hold", zap.Int("hold_id", holdID), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, hold.UserID, "limit hold")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = checkHoldCapturable(hold, now)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, errorx.NewError(errorx.ErrTypeValidation, "invalid date format, use YYYY-MM-DD", err)
+	}
+
+	app := &model.FinancingApplication{
+		UserID:          hold.UserID,
+		FacilityLimitID: hold.FacilityLimitID,
+		Amount:          hold.Amount,
+		Tenor:           req.Tenor,
+		StartDate:       &startDate,
+		BillingDay:      req.BillingDay,
+		GracePeriod:     req.GracePeriod,
+		Status:          model.ApplicationStatusDraft,
+		CreatedAt:       now,
+	}
+
+	user, err := s.userRepo.Get(ctx, int(app.UserID))
+	if err != nil {
+		s.log.Error("failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkKYCVerified(ctx, s.kycRepo, user.UserID, now)
+	if err != nil {
+		s.log.Warn("user kyc not verified", zap.Int64("user_id", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	limit, err := s.applicationLimit(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	tenor, err := s.tenorRepo.Get(ctx, int(limit.ProductID), req.Tenor, now)
+	if err != nil {
+		s.log.Error("failed to get tenor", zap.Error(err))
+		return nil, err
+	}
+
+	if !tenorAllows(tenor, app.Amount) {
+		return nil, errorx.NewError(errorx.ErrTenorNotAvail, "amount is outside the range allowed for this tenor", nil)
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	id, err := s.applicationRepo.Add(txCtx, app)
+	if err != nil {
+		s.log.Error("failed to insert financing application", zap.Error(err))
+		return nil, err
+	}
+	app.ApplicationID = int64(id)
+
+	err = s.captureHold(txCtx, hold, app.ApplicationID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.postApplication(txCtx, app, model.LedgerHold, app.Amount.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	stampApplication(txCtx, app, model.ApplicationStatusSubmitted, nil, now)
+	err = s.saveTransition(txCtx, app, model.ApplicationStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return toApplicationResponse(app), nil
+}
+
+// submit books a facility on the limit. When the amount has already been
+// taken off the limit by a submitted application, release gives it
+// back in the same transaction as the drawdown. The money is sent to the
+// customer afterwards by the disbursement queued with the facility.
+func (s *service) submit(ctx context.Context, req *model.SubmitFinancingRequest, release func(ctx context.Context, facilityID int64) error) (*model.SubmitFinancingResponse, error) {
 	amountDec := decimal.NewFromInt(req.Amount)
 
 	startDate, err := time.Parse("2006-01-02", req.StartDate)
@@ -144,35 +380,98 @@ func (s *service) Submit(ctx context.Context, req *model.SubmitFinancingRequest)
 		return nil, err
 	}
 
-	limit, err := s.limitRepo.Get(ctx, int(user.UserID))
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkKYCVerified(ctx, s.kycRepo, user.UserID, time.Now())
+	if err != nil {
+		s.log.Warn("user kyc not verified", zap.Int64("user_id", user.UserID), zap.Error(err))
+		return nil, err
+	}
+
+	limit, err := s.limitRepo.GetByID(ctx, int(req.FacilityLimitID))
+	if err != nil {
+		s.log.Error("failed to get user limit amount", zap.Int64("facility_limit_id", req.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	if limit.UserID != user.UserID {
+		s.log.Warn("facility limit belongs to another user",
+			zap.Int64("user_id", user.UserID),
+			zap.Int64("facility_limit_id", limit.FacilityLimitID))
+		return nil, errorx.NewError(errorx.ErrTypeNotFound, "facility limit not found for this user", nil)
+	}
+
+	err = checkLimitAvailable(limit, time.Now())
 	if err != nil {
-		s.log.Error("failed to get user limit amount", zap.Error(err))
+		s.log.Warn("facility limit not available",
+			zap.Int64("facility_limit_id", limit.FacilityLimitID),
+			zap.String("status", limit.Status),
+			zap.Error(err))
 		return nil, err
 	}
 
-	if amountDec.GreaterThan(limit.LimitAmount) {
+	if release == nil && amountDec.GreaterThan(limit.LimitAmount) {
 		s.log.Warn("amount request over the limit",
 			zap.Int64("req", req.Amount),
 			zap.String("limit", limit.LimitAmount.String()))
 		return nil, errorx.NewError(errorx.ErrInsufficientLimit, "limit balance is not enough", nil)
 	}
 
-	tenor, err := s.tenorRepo.Get(ctx, req.Tenor)
+	product, err := s.productRepo.Get(ctx, int(limit.ProductID))
+	if err != nil {
+		s.log.Error("failed to get limit product", zap.Int64("product_id", limit.ProductID), zap.Error(err))
+		return nil, err
+	}
+
+	pricer, err := s.pricerFor(product)
+	if err != nil {
+		return nil, err
+	}
+
+	tenor, err := s.tenorRepo.Get(ctx, int(limit.ProductID), req.Tenor, time.Now())
 	if err != nil {
 		s.log.Error("failed to get tenor", zap.Error(err))
 		return nil, err
 	}
 
-	monthlyInstallment, margin, payment := s.calculateFinancials(amountDec, tenor.TenorValue)
+	if !tenorAllows(tenor, amountDec) {
+		s.log.Warn("amount outside tenor range",
+			zap.Int64("req", req.Amount),
+			zap.Int("tenor", tenor.TenorValue))
+		return nil, errorx.NewError(errorx.ErrTenorNotAvail, "amount is outside the range allowed for this tenor", nil)
+	}
+
+	quote, schedule, err := s.buildSchedule(pricer, amountDec, tenor.TenorValue, tenor.Rate)
+	if err != nil {
+		s.log.Error("installment schedule does not reconcile", zap.Int("tenor", tenor.TenorValue), zap.Error(err))
+		return nil, errorx.NewError(errorx.ErrTypeInternal, "installment schedule does not reconcile with total payment", err)
+	}
+
+	dueDates, err := s.dueDates(ctx, startDate, tenor, req.BillingDay, req.GracePeriod)
+	if err != nil {
+		s.log.Error("failed to load holiday calendar", zap.Error(err))
+		return nil, err
+	}
+
 	facility := model.UserFacility{
 		UserID:             user.UserID,
 		FacilityLimitID:    limit.FacilityLimitID,
 		Amount:             amountDec,
 		Tenor:              tenor.TenorValue,
 		StartDate:          startDate,
-		MonthlyInstallment: monthlyInstallment,
-		TotalMargin:        margin,
-		TotalPayment:       payment,
+		BillingDay:         req.BillingDay,
+		GracePeriod:        req.GracePeriod,
+		MonthlyInstallment: quote.MonthlyInstallment,
+		TotalMargin:        quote.TotalMargin,
+		TotalPayment:       quote.TotalPayment,
+		Rate:               tenor.Rate,
+		PricingMethod:      pricer.Method(),
+		RemainderStrategy:  s.rounding.Strategy,
+		RoundingUnit:       s.rounding.Unit,
+		EngineVersion:      pricing.Version,
 		CreatedAt:          time.Now(),
 	}
 
@@ -183,6 +482,13 @@ func (s *service) Submit(ctx context.Context, req *model.SubmitFinancingRequest)
 	}
 	defer s.trx.Rollback(txCtx)
 
+	if req.IdempotencyKey != "" {
+		response, err := s.claimIdempotencyKey(txCtx, req)
+		if err != nil || response != nil {
+			return response, err
+		}
+	}
+
 	facilityID, err := s.facilityRepo.Add(txCtx, &facility)
 	if err != nil {
 		s.log.Error("failed to submit new finance", zap.Error(err))
@@ -191,19 +497,18 @@ func (s *service) Submit(ctx context.Context, req *model.SubmitFinancingRequest)
 
 	responseSchedule := []model.ScheduleDetail{}
 	details := []*model.UserFacilityDetail{}
-	for i := 1; i <= tenor.TenorValue; i++ {
-		dueDate := startDate.AddDate(0, i, 0)
+	for _, row := range schedule {
 		detail := &model.UserFacilityDetail{
-			UserFacilityID:    int64(facilityID),
-			DueDate:           dueDate,
-			InstallmentAmount: monthlyInstallment,
+			UserFacilityID:       int64(facilityID),
+			DueDate:              dueDates[row.Seq-1],
+			InstallmentAmount:    row.Amount,
+			PrincipalAmount:      row.Principal,
+			MarginAmount:         row.Margin,
+			OutstandingPrincipal: row.Outstanding,
+			Status:               model.DetailStatusUnpaid,
 		}
 		details = append(details, detail)
-
-		responseSchedule = append(responseSchedule, model.ScheduleDetail{
-			DueDate:           dueDate.Format("2006-01-02"),
-			InstallmentAmount: monthlyInstallment,
-		})
+		responseSchedule = append(responseSchedule, toScheduleDetail(detail))
 	}
 
 	err = s.detailRepo.Add(txCtx, details)
@@ -212,29 +517,541 @@ func (s *service) Submit(ctx context.Context, req *model.SubmitFinancingRequest)
 		return nil, err
 	}
 
-	limit.LimitAmount = limit.LimitAmount.Sub(amountDec)
-	err = s.limitRepo.Update(txCtx, int(limit.FacilityLimitID), limit.LimitAmount.IntPart())
+	if release != nil {
+		err = release(txCtx, int64(facilityID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.limitRepo.Post(txCtx, newLedgerEntry(limit.FacilityLimitID, model.LedgerDrawdown, amountDec.Neg(), model.ReferenceFacility, int64(facilityID)))
 	if err != nil {
-		s.log.Error("failed to update limit user", zap.Error(err))
+		s.log.Error("failed to deduct limit user", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
 		return nil, err
 	}
 
-	err = s.trx.Commit(txCtx)
+	_, err = s.disbursementRepo.Add(txCtx, &model.Disbursement{
+		UserFacilityID: int64(facilityID),
+		UserID:         user.UserID,
+		Amount:         amountDec,
+		Status:         model.DisbursementStatusPending,
+		NextAttemptAt:  facility.CreatedAt,
+		CreatedAt:      facility.CreatedAt,
+	})
 	if err != nil {
-		s.log.Error("failed to commit query", zap.Error(err))
+		s.log.Error("failed to queue disbursement", zap.Int("facility_id", facilityID), zap.Error(err))
 		return nil, err
 	}
 
-	return &model.SubmitFinancingResponse{
+	err = addFacilityEvent(txCtx, s.outboxRepo, s.log, model.EventFacilityCreated, int64(facilityID), model.FacilityCreatedEvent{
 		UserFacilityID:     int64(facilityID),
 		UserID:             user.UserID,
 		FacilityLimitID:    limit.FacilityLimitID,
 		Amount:             amountDec,
 		Tenor:              tenor.TenorValue,
 		StartDate:          startDate.Format("2006-01-02"),
-		MonthlyInstallment: monthlyInstallment,
-		TotalMargin:        margin,
-		TotalPayment:       payment,
+		MonthlyInstallment: quote.MonthlyInstallment,
+		TotalPayment:       quote.TotalPayment,
+	}, facility.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.SubmitFinancingResponse{
+		UserFacilityID:     int64(facilityID),
+		UserID:             user.UserID,
+		FacilityLimitID:    limit.FacilityLimitID,
+		Amount:             amountDec,
+		Tenor:              tenor.TenorValue,
+		StartDate:          startDate.Format("2006-01-02"),
+		MonthlyInstallment: quote.MonthlyInstallment,
+		TotalMargin:        quote.TotalMargin,
+		TotalPayment:       quote.TotalPayment,
 		Schedule:           responseSchedule,
+	}
+
+	if req.IdempotencyKey != "" {
+		err = s.saveSubmission(txCtx, req, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// captureHold resolves a hold into the application that takes over its
+// amount and gives the amount back to the limit for the application to hold.
+func (s *service) captureHold(ctx context.Context, hold *model.LimitHold, applicationID int64, now time.Time) error {
+	hold.Status = model.HoldStatusCaptured
+	hold.ApplicationID = &applicationID
+	hold.ResolvedAt = &now
+	err := s.holdRepo.Resolve(ctx, hold)
+	if err != nil {
+		s.log.Error("failed to capture limit hold", zap.Int64("hold_id", hold.HoldID), zap.Error(err))
+		return err
+	}
+
+	err = s.limitRepo.Post(ctx, newLedgerEntry(hold.FacilityLimitID, model.LedgerRelease, hold.Amount, model.ReferenceLimitHold, hold.HoldID))
+	if err != nil {
+		s.log.Error("failed to release limit hold", zap.Int64("facility_limit_id", hold.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) GetFacility(ctx context.Context, id int) (*model.FacilityResponse, error) {
+	facility, err := s.facilityRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, facility.UserID, "facility")
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := s.detailRepo.ListByFacility(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get facility schedule", zap.Int("facility_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	schedule := []model.ScheduleDetail{}
+	for _, detail := range details {
+		schedule = append(schedule, toScheduleDetail(detail))
+	}
+
+	return toFacilityResponse(facility, schedule), nil
+}
+
+// ListFacilities lists the facilities matching the request. A customer only
+// ever sees their own facilities.
+func (s *service) ListFacilities(ctx context.Context, req *model.ListFacilitiesRequest) (*model.ListFacilitiesResponse, error) {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.IsAdmin() && req.UserID == 0 {
+		req.UserID = principal.UserID
+	}
+
+	err := checkAccess(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newFacilityFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserID != 0 {
+		_, err := s.userRepo.Get(ctx, int(req.UserID))
+		if err != nil {
+			s.log.Error("failed to get user", zap.Int64("user_id", req.UserID), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	facilities, err := s.facilityRepo.List(ctx, filter)
+	if err != nil {
+		s.log.Error("failed to get list facilities", zap.Error(err))
+		return nil, err
+	}
+
+	response := &model.ListFacilitiesResponse{Data: []*model.FacilityResponse{}}
+	if len(facilities) > pageSize {
+		facilities = facilities[:pageSize]
+		response.NextCursor = encodeFacilityCursor(filter, facilities[pageSize-1])
+	}
+
+	for _, facility := range facilities {
+		response.Data = append(response.Data, toFacilityResponse(facility, nil))
+	}
+
+	return response, nil
+}
+
+func (s *service) RecomputeFacility(ctx context.Context, id int) (*model.RecomputeResponse, error) {
+	facility, err := s.facilityRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	pricer, err := pricing.New(facility.PricingMethod)
+	if err != nil {
+		s.log.Error("failed to load pricing method", zap.String("method", facility.PricingMethod), zap.Error(err))
+		return nil, errorx.NewError(errorx.ErrTypeValidation, "pricing method of this facility is no longer supported", err)
+	}
+
+	rounding, err := pricing.NewRounding(facility.RemainderStrategy, facility.RoundingUnit)
+	if err != nil {
+		s.log.Error("failed to load installment rounding", zap.String("strategy", facility.RemainderStrategy), zap.Error(err))
+		return nil, errorx.NewError(errorx.ErrTypeValidation, "installment rounding of this facility is no longer supported", err)
+	}
+
+	quote, _ := rounding.Apply(pricer.Price(facility.Amount, facility.Tenor, facility.Rate), pricer.Schedule(facility.Amount, facility.Tenor, facility.Rate))
+	drift := model.InstallmentSimulation{
+		Tenor:              facility.Tenor,
+		MonthlyInstallment: quote.MonthlyInstallment.Sub(facility.MonthlyInstallment),
+		TotalMargin:        quote.TotalMargin.Sub(facility.TotalMargin),
+		TotalPayment:       quote.TotalPayment.Sub(facility.TotalPayment),
+	}
+
+	return &model.RecomputeResponse{
+		UserFacilityID:       facility.UserFacilityID,
+		PricingMethod:        facility.PricingMethod,
+		RemainderStrategy:    facility.RemainderStrategy,
+		RoundingUnit:         facility.RoundingUnit,
+		Rate:                 facility.Rate,
+		StoredEngineVersion:  facility.EngineVersion,
+		CurrentEngineVersion: pricing.Version,
+		Stored: model.InstallmentSimulation{
+			Tenor:              facility.Tenor,
+			MonthlyInstallment: facility.MonthlyInstallment,
+			TotalMargin:        facility.TotalMargin,
+			TotalPayment:       facility.TotalPayment,
+		},
+		Recomputed: model.InstallmentSimulation{
+			Tenor:              facility.Tenor,
+			MonthlyInstallment: quote.MonthlyInstallment,
+			TotalMargin:        quote.TotalMargin,
+			TotalPayment:       quote.TotalPayment,
+		},
+		Drift:    drift,
+		HasDrift: !drift.MonthlyInstallment.IsZero() || !drift.TotalMargin.IsZero() || !drift.TotalPayment.IsZero(),
 	}, nil
 }
+
+// pricerFor returns the pricer of a product, falling back to the configured
+// pricing method when the product does not set its own.
+func (s *service) pricerFor(product *model.LimitProduct) (pricing.Pricer, error) {
+	if product.PricingMethod == nil {
+		return s.pricer, nil
+	}
+
+	pricer, err := pricing.New(*product.PricingMethod)
+	if err != nil {
+		s.log.Error("failed to load pricing method", zap.Int64("product_id", product.ProductID), zap.String("method", *product.PricingMethod), zap.Error(err))
+		return nil, errorx.NewError(errorx.ErrTypeInternal, "pricing method of this product is not supported", err)
+	}
+
+	return pricer, nil
+}
+
+// productPricers maps the product, or every product when productID is zero,
+// to its pricer.
+func (s *service) productPricers(ctx context.Context, productID int64) (map[int64]pricing.Pricer, error) {
+	var products []*model.LimitProduct
+
+	if productID != 0 {
+		product, err := s.productRepo.Get(ctx, int(productID))
+		if err != nil {
+			s.log.Error("failed to get limit product", zap.Int64("product_id", productID), zap.Error(err))
+			return nil, err
+		}
+		products = append(products, product)
+	} else {
+		list, err := s.productRepo.List(ctx)
+		if err != nil {
+			s.log.Error("failed to get list limit products", zap.Error(err))
+			return nil, err
+		}
+		products = list
+	}
+
+	pricers := make(map[int64]pricing.Pricer, len(products))
+	for _, product := range products {
+		pricer, err := s.pricerFor(product)
+		if err != nil {
+			return nil, err
+		}
+		pricers[product.ProductID] = pricer
+	}
+
+	return pricers, nil
+}
+
+// buildSchedule prices a facility and reconciles its installments with the
+// total payment using the configured remainder strategy.
+func (s *service) buildSchedule(pricer pricing.Pricer, amount decimal.Decimal, tenor int, rate decimal.Decimal) (pricing.Quote, []pricing.Installment, error) {
+	quote, schedule := s.rounding.Apply(pricer.Price(amount, tenor, rate), pricer.Schedule(amount, tenor, rate))
+
+	err := pricing.CheckSchedule(schedule, amount, quote.TotalPayment)
+	if err != nil {
+		return pricing.Quote{}, nil, err
+	}
+
+	return quote, schedule, nil
+}
+
+// dueDates generates the due dates of a facility and moves the ones falling
+// on a weekend or holiday according to the business day rule of the tenor.
+func (s *service) dueDates(ctx context.Context, start time.Time, tenor *model.Tenor, billingDay, gracePeriod int) ([]time.Time, error) {
+	dates := duedate.Generate(start, tenor.TenorValue, billingDay, gracePeriod)
+	if tenor.BusinessDayRule == calendar.RuleNone || len(dates) == 0 {
+		return dates, nil
+	}
+
+	cal, err := loadCalendar(ctx, s.holidayRepo, dates[0], dates[len(dates)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, date := range dates {
+		dates[i] = cal.Adjust(date, tenor.BusinessDayRule)
+	}
+
+	return dates, nil
+}
+
+func newFacilityFilter(req *model.ListFacilitiesRequest) (*model.FacilityFilter, error) {
+	filter := &model.FacilityFilter{
+		UserID:   req.UserID,
+		Status:   req.Status,
+		Tenor:    req.Tenor,
+		SortBy:   req.SortBy,
+		SortDesc: req.SortOrder != "asc",
+		Limit:    req.Limit,
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "created_at"
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+
+	fields := map[string]string{}
+	if req.StartFrom != "" {
+		startFrom, err := time.Parse("2006-01-02", req.StartFrom)
+		if err != nil {
+			fields["start_from"] = "invalid date format, use YYYY-MM-DD"
+		}
+		filter.StartFrom = &startFrom
+	}
+	if req.StartTo != "" {
+		startTo, err := time.Parse("2006-01-02", req.StartTo)
+		if err != nil {
+			fields["start_to"] = "invalid date format, use YYYY-MM-DD"
+		}
+		filter.StartTo = &startTo
+	}
+	if req.MinAmount != "" {
+		minAmount, err := decimal.NewFromString(req.MinAmount)
+		if err != nil {
+			fields["min_amount"] = "must be a number"
+		}
+		filter.MinAmount = &minAmount
+	}
+	if req.MaxAmount != "" {
+		maxAmount, err := decimal.NewFromString(req.MaxAmount)
+		if err != nil {
+			fields["max_amount"] = "must be a number"
+		}
+		filter.MaxAmount = &maxAmount
+	}
+	if req.Cursor != "" {
+		cursor, err := decodeFacilityCursor(req.Cursor)
+		if err != nil {
+			fields["cursor"] = "invalid cursor"
+		} else if cursor.SortBy != filter.SortBy || cursor.SortDesc != filter.SortDesc {
+			fields["cursor"] = "cursor was made for a different sort_by or sort_order"
+		}
+		filter.Cursor = cursor
+	}
+
+	if len(fields) > 0 {
+		return nil, errorx.NewValidationError(fields)
+	}
+
+	return filter, nil
+}
+
+// newUserLimitFilter builds the listing filter. A search term that looks
+// like a phone number also matches the phone, written in any accepted form
+// or as a part of its digits.
+func newUserLimitFilter(req *model.ListUserLimitsRequest) (*model.UserLimitFilter, error) {
+	filter := &model.UserLimitFilter{
+		UserID: req.UserID,
+		Search: strings.TrimSpace(req.Search),
+		Limit:  req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+
+	if normalized, err := phone.Normalize(filter.Search); err == nil {
+		filter.Phone = normalized
+	} else if digits := strings.TrimPrefix(filter.Search, "+"); digits != "" && strings.Trim(digits, "0123456789") == "" {
+		filter.Phone = digits
+	}
+
+	fields := map[string]string{}
+	if req.MinAvailable != "" {
+		minAvailable, err := decimal.NewFromString(req.MinAvailable)
+		if err != nil {
+			fields["min_available"] = "must be a number"
+		}
+		filter.MinAvailable = &minAvailable
+	}
+	if req.MaxAvailable != "" {
+		maxAvailable, err := decimal.NewFromString(req.MaxAvailable)
+		if err != nil {
+			fields["max_available"] = "must be a number"
+		}
+		filter.MaxAvailable = &maxAvailable
+	}
+	if req.Cursor != "" {
+		cursor, err := decodeUserLimitCursor(req.Cursor)
+		if err != nil {
+			fields["cursor"] = "invalid cursor"
+		}
+		filter.Cursor = cursor
+	}
+
+	if len(fields) > 0 {
+		return nil, errorx.NewValidationError(fields)
+	}
+
+	return filter, nil
+}
+
+func encodeUserLimitCursor(row *model.UserLimitRow) string {
+	cursor := model.UserLimitCursor{UserID: row.UserID}
+	if row.LimitID != nil {
+		cursor.LimitID = *row.LimitID
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeUserLimitCursor(encoded string) (*model.UserLimitCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor model.UserLimitCursor
+	err = json.Unmarshal(raw, &cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func toUserLimit(row *model.UserLimitRow) *model.UserLimit {
+	limit := &model.UserLimit{
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Phone:      row.Phone,
+		HasLimit:   row.LimitID != nil,
+		LimitId:    row.LimitID,
+		ProductID:  row.ProductID,
+		HeldAmount: row.HeldAmount,
+	}
+	if row.LimitAmount != nil {
+		limit.LimitAmount = *row.LimitAmount
+	}
+	if row.Status != nil {
+		limit.Status = *row.Status
+	}
+	if row.ValidUntil != nil {
+		limit.ValidUntil = row.ValidUntil.Format("2006-01-02")
+	}
+
+	return limit
+}
+
+func encodeFacilityCursor(filter *model.FacilityFilter, facility *model.UserFacility) string {
+	cursor := model.FacilityCursor{SortBy: filter.SortBy, SortDesc: filter.SortDesc, ID: facility.UserFacilityID}
+	switch filter.SortBy {
+	case "start_date":
+		cursor.Value = facility.StartDate.Format("2006-01-02")
+	case "amount":
+		cursor.Value = facility.Amount.String()
+	default:
+		cursor.Value = facility.CreatedAt.Format("2006-01-02T15:04:05.999999")
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeFacilityCursor(encoded string) (*model.FacilityCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor model.FacilityCursor
+	err = json.Unmarshal(raw, &cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func toFacilityResponse(facility *model.UserFacility, schedule []model.ScheduleDetail) *model.FacilityResponse {
+	return &model.FacilityResponse{
+		UserFacilityID:     facility.UserFacilityID,
+		UserID:             facility.UserID,
+		FacilityLimitID:    facility.FacilityLimitID,
+		Amount:             facility.Amount,
+		Tenor:              facility.Tenor,
+		StartDate:          facility.StartDate.Format("2006-01-02"),
+		BillingDay:         facility.BillingDay,
+		GracePeriod:        facility.GracePeriod,
+		MonthlyInstallment: facility.MonthlyInstallment,
+		TotalMargin:        facility.TotalMargin,
+		TotalPayment:       facility.TotalPayment,
+		Rate:               facility.Rate,
+		PricingMethod:      facility.PricingMethod,
+		EngineVersion:      facility.EngineVersion,
+		Status:             facility.Status,
+		CreatedAt:          facility.CreatedAt.Format(time.RFC3339),
+		Schedule:           schedule,
+	}
+}
+
+// tenorAllows reports whether amount falls within the optional min/max amount
+// of the tenor rate.
+func tenorAllows(tenor *model.Tenor, amount decimal.Decimal) bool {
+	if tenor.MinAmount != nil && amount.LessThan(*tenor.MinAmount) {
+		return false
+	}
+	if tenor.MaxAmount != nil && amount.GreaterThan(*tenor.MaxAmount) {
+		return false
+	}
+
+	return true
+}
+
+func toScheduleDetail(detail *model.UserFacilityDetail) model.ScheduleDetail {
+	schedule := model.ScheduleDetail{
+		DueDate:              detail.DueDate.Format("2006-01-02"),
+		InstallmentAmount:    detail.InstallmentAmount,
+		PrincipalAmount:      detail.PrincipalAmount,
+		MarginAmount:         detail.MarginAmount,
+		OutstandingPrincipal: detail.OutstandingPrincipal,
+		Status:               detail.Status,
+	}
+	if detail.PaidAt != nil {
+		schedule.PaidAt = detail.PaidAt.Format(time.RFC3339)
+	}
+
+	return schedule
+}
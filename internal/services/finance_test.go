@@ -3,8 +3,16 @@ package services
 import (
 	"context"
 	"errors"
+	"finance/internal/auth"
+	"finance/internal/calendar"
+	"finance/internal/disbursement"
 	"finance/internal/model"
+	"finance/internal/outbox"
+	"finance/internal/pricing"
+	"finance/pkg/errorx"
 	"finance/pkg/logger"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -35,30 +43,449 @@ func (m *MockUserRepo) List(ctx context.Context) ([]*model.User, error) {
 	return args.Get(0).([]*model.User), args.Error(1)
 }
 
-type MockLimitRepo struct {
+func (m *MockUserRepo) Add(ctx context.Context, user *model.User) (int, error) {
+	args := m.Called(ctx, user)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepo) Update(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) UpdateStatus(ctx context.Context, id int, status string, updatedAt time.Time) error {
+	args := m.Called(ctx, id, status, updatedAt)
+	return args.Error(0)
+}
+
+type MockKYCRepo struct {
 	mock.Mock
 }
 
-func (m *MockLimitRepo) Get(ctx context.Context, userID int) (*model.UserFacilityLimit, error) {
+func (m *MockKYCRepo) Get(ctx context.Context, userID int) (*model.KYC, error) {
 	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 
+	return args.Get(0).(*model.KYC), args.Error(1)
+}
+
+func (m *MockKYCRepo) List(ctx context.Context, status string) ([]*model.KYC, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.KYC), args.Error(1)
+}
+
+func (m *MockKYCRepo) Submit(ctx context.Context, kyc *model.KYC) (int, error) {
+	args := m.Called(ctx, kyc)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockKYCRepo) MarkReviewed(ctx context.Context, kyc *model.KYC) error {
+	args := m.Called(ctx, kyc)
+	return args.Error(0)
+}
+
+func (m *MockKYCRepo) Expire(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newKYCRepo returns a KYC repository where every user is verified.
+func newKYCRepo() *MockKYCRepo {
+	expiresAt := time.Now().AddDate(1, 0, 0)
+	kycRepo := new(MockKYCRepo)
+	kycRepo.On("Get", mock.Anything, mock.Anything).Return(&model.KYC{Status: model.KYCStatusVerified, ExpiresAt: &expiresAt}, nil).Maybe()
+
+	return kycRepo
+}
+
+type MockLimitRepo struct {
+	mock.Mock
+}
+
+func (m *MockLimitRepo) GetByID(ctx context.Context, id int) (*model.UserFacilityLimit, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
 	return args.Get(0).(*model.UserFacilityLimit), args.Error(1)
 }
 
-func (m *MockLimitRepo) Update(ctx context.Context, id int, amount int64) error {
-	args := m.Called(ctx, id, amount)
+func (m *MockLimitRepo) ListUserLimits(ctx context.Context, filter *model.UserLimitFilter) ([]*model.UserLimitRow, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserLimitRow), args.Error(1)
+}
+
+func (m *MockLimitRepo) ListByUser(ctx context.Context, userID int) ([]*model.UserFacilityLimit, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserFacilityLimit), args.Error(1)
+}
+
+func (m *MockLimitRepo) Add(ctx context.Context, limit *model.UserFacilityLimit) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLimitRepo) UpdateStatus(ctx context.Context, id int, status string) error {
+	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
+func (m *MockLimitRepo) UpdateValidity(ctx context.Context, id int, validUntil, nextReviewDate time.Time) error {
+	args := m.Called(ctx, id, validUntil, nextReviewDate)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepo) ListDueForReview(ctx context.Context, on time.Time) ([]*model.UserFacilityLimit, error) {
+	args := m.Called(ctx, on)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserFacilityLimit), args.Error(1)
+}
+
+func (m *MockLimitRepo) Post(ctx context.Context, entry *model.LimitLedgerEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepo) ListLedger(ctx context.Context, limitID int, before int64, limit int) ([]*model.LimitLedgerEntry, error) {
+	args := m.Called(ctx, limitID, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.LimitLedgerEntry), args.Error(1)
+}
+
+func (m *MockLimitRepo) LedgerBalance(ctx context.Context, limitID int) (decimal.Decimal, error) {
+	args := m.Called(ctx, limitID)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+type MockHoldRepo struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepo) Add(ctx context.Context, hold *model.LimitHold) (int, error) {
+	args := m.Called(ctx, hold)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockHoldRepo) Get(ctx context.Context, id int) (*model.LimitHold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.LimitHold), args.Error(1)
+}
+
+func (m *MockHoldRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.LimitHold, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.LimitHold), args.Error(1)
+}
+
+func (m *MockHoldRepo) Resolve(ctx context.Context, hold *model.LimitHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+type MockIdempotencyRepo struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepo) Lock(ctx context.Context, userID int64, key string) (bool, error) {
+	args := m.Called(ctx, userID, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Get(ctx context.Context, userID int64, key string) (*model.IdempotencyRecord, error) {
+	args := m.Called(ctx, userID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Add(ctx context.Context, record *model.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+type MockApplicationRepo struct {
+	mock.Mock
+}
+
+func (m *MockApplicationRepo) Add(ctx context.Context, app *model.FinancingApplication) (int, error) {
+	args := m.Called(ctx, app)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockApplicationRepo) Get(ctx context.Context, id int) (*model.FinancingApplication, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.FinancingApplication), args.Error(1)
+}
+
+func (m *MockApplicationRepo) List(ctx context.Context, filter *model.ApplicationFilter) ([]*model.FinancingApplication, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*model.FinancingApplication), args.Error(1)
+}
+
+func (m *MockApplicationRepo) Transition(ctx context.Context, app *model.FinancingApplication, from string) error {
+	args := m.Called(ctx, app, from)
+	return args.Error(0)
+}
+
+func (m *MockApplicationRepo) GetByFacility(ctx context.Context, facilityID int64) (*model.FinancingApplication, error) {
+	args := m.Called(ctx, facilityID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.FinancingApplication), args.Error(1)
+}
+
+type MockDisbursementRepo struct {
+	mock.Mock
+}
+
+func (m *MockDisbursementRepo) Add(ctx context.Context, disbursement *model.Disbursement) (int, error) {
+	args := m.Called(ctx, disbursement)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDisbursementRepo) List(ctx context.Context, status string) ([]*model.Disbursement, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*model.Disbursement), args.Error(1)
+}
+
+func (m *MockDisbursementRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Disbursement, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.Disbursement), args.Error(1)
+}
+
+func (m *MockDisbursementRepo) Complete(ctx context.Context, disbursement *model.Disbursement) error {
+	args := m.Called(ctx, disbursement)
+	return args.Error(0)
+}
+
+func (m *MockDisbursementRepo) Reschedule(ctx context.Context, disbursement *model.Disbursement) error {
+	args := m.Called(ctx, disbursement)
+	return args.Error(0)
+}
+
+func (m *MockDisbursementRepo) GetByFacility(ctx context.Context, facilityID int64) (*model.Disbursement, error) {
+	args := m.Called(ctx, facilityID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.Disbursement), args.Error(1)
+}
+
+func (m *MockDisbursementRepo) Requeue(ctx context.Context, id int, now time.Time) (*model.Disbursement, error) {
+	args := m.Called(ctx, id, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.Disbursement), args.Error(1)
+}
+
+type MockDisburser struct {
+	mock.Mock
+}
+
+func (m *MockDisburser) Disburse(ctx context.Context, transfer disbursement.Transfer) (string, error) {
+	args := m.Called(ctx, transfer)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockDisburser) Status(ctx context.Context, reference string) (string, error) {
+	args := m.Called(ctx, reference)
+	return args.String(0), args.Error(1)
+}
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) Add(ctx context.Context, event *model.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.OutboxEvent, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	args := m.Called(ctx, id, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) MarkFailed(ctx context.Context, event *model.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) Release(ctx context.Context, ids []int64, now time.Time) error {
+	args := m.Called(ctx, ids, now)
+	return args.Error(0)
+}
+
+// newOutboxRepo accepts every event written to the outbox.
+func newOutboxRepo() *MockOutboxRepo {
+	outboxRepo := new(MockOutboxRepo)
+	outboxRepo.On("Add", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return outboxRepo
+}
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, msg outbox.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+// newDisbursementRepo queues the disbursement of every booked facility and
+// reports every facility as disbursed.
+func newDisbursementRepo() *MockDisbursementRepo {
+	disbursementRepo := new(MockDisbursementRepo)
+	disbursementRepo.On("Add", mock.Anything, mock.Anything).Return(1, nil).Maybe()
+	disbursementRepo.On("GetByFacility", mock.Anything, mock.Anything).
+		Return(&model.Disbursement{Status: model.DisbursementStatusSucceeded}, nil).Maybe()
+
+	return disbursementRepo
+}
+
+func newHoldRepo() *MockHoldRepo {
+	return new(MockHoldRepo)
+}
+
+type MockLimitChangeRepo struct {
+	mock.Mock
+}
+
+func (m *MockLimitChangeRepo) Add(ctx context.Context, change *model.LimitChange) (int, error) {
+	args := m.Called(ctx, change)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLimitChangeRepo) Get(ctx context.Context, id int) (*model.LimitChange, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.LimitChange), args.Error(1)
+}
+
+func (m *MockLimitChangeRepo) List(ctx context.Context, status string) ([]*model.LimitChange, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.LimitChange), args.Error(1)
+}
+
+func (m *MockLimitChangeRepo) MarkReviewed(ctx context.Context, change *model.LimitChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+type MockProductRepo struct {
+	mock.Mock
+}
+
+func (m *MockProductRepo) Get(ctx context.Context, id int) (*model.LimitProduct, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.LimitProduct), args.Error(1)
+}
+
+func (m *MockProductRepo) List(ctx context.Context) ([]*model.LimitProduct, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.LimitProduct), args.Error(1)
+}
+
+func (m *MockProductRepo) Add(ctx context.Context, product *model.LimitProduct) (int, error) {
+	args := m.Called(ctx, product)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepo) UpdatePricingMethod(ctx context.Context, id int, method *string) error {
+	args := m.Called(ctx, id, method)
+	return args.Error(0)
+}
+
+// newProductRepo returns a product repository that knows the given products,
+// or a single product 1 priced with the default method when none are given.
+func newProductRepo(products ...*model.LimitProduct) *MockProductRepo {
+	if len(products) == 0 {
+		products = []*model.LimitProduct{{ProductID: 1, Code: "general", Name: "General Financing"}}
+	}
+
+	productRepo := new(MockProductRepo)
+	for _, product := range products {
+		productRepo.On("Get", mock.Anything, int(product.ProductID)).Return(product, nil).Maybe()
+	}
+	productRepo.On("List", mock.Anything).Return(products, nil).Maybe()
+
+	return productRepo
+}
+
 type MockTenorRepo struct {
 	mock.Mock
 }
 
-func (m *MockTenorRepo) Get(ctx context.Context, tenorValue int) (*model.Tenor, error) {
-	args := m.Called(ctx, tenorValue)
+func (m *MockTenorRepo) Get(ctx context.Context, productID int, tenorValue int, at time.Time) (*model.Tenor, error) {
+	args := m.Called(ctx, productID, tenorValue, at)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -66,7 +493,25 @@ func (m *MockTenorRepo) Get(ctx context.Context, tenorValue int) (*model.Tenor,
 	return args.Get(0).(*model.Tenor), args.Error(1)
 }
 
-func (m *MockTenorRepo) List(ctx context.Context) ([]*model.Tenor, error) {
+func (m *MockTenorRepo) List(ctx context.Context, productID int, at time.Time) ([]*model.Tenor, error) {
+	args := m.Called(ctx, productID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.Tenor), args.Error(1)
+}
+
+func (m *MockTenorRepo) GetByID(ctx context.Context, id int) (*model.Tenor, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.Tenor), args.Error(1)
+}
+
+func (m *MockTenorRepo) ListAll(ctx context.Context) ([]*model.Tenor, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -75,6 +520,26 @@ func (m *MockTenorRepo) List(ctx context.Context) ([]*model.Tenor, error) {
 	return args.Get(0).([]*model.Tenor), args.Error(1)
 }
 
+func (m *MockTenorRepo) Add(ctx context.Context, tenor *model.Tenor) (int, error) {
+	args := m.Called(ctx, tenor)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTenorRepo) Update(ctx context.Context, tenor *model.Tenor) error {
+	args := m.Called(ctx, tenor)
+	return args.Error(0)
+}
+
+func (m *MockTenorRepo) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTenorRepo) HasOverlap(ctx context.Context, tenor *model.Tenor) (bool, error) {
+	args := m.Called(ctx, tenor)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockFacilityRepo struct {
 	mock.Mock
 }
@@ -93,6 +558,20 @@ func (m *MockFacilityRepo) Get(ctx context.Context, id int) (*model.UserFacility
 	return args.Get(0).(*model.UserFacility), args.Error(1)
 }
 
+func (m *MockFacilityRepo) List(ctx context.Context, filter *model.FacilityFilter) ([]*model.UserFacility, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserFacility), args.Error(1)
+}
+
+func (m *MockFacilityRepo) UpdateStatus(ctx context.Context, id int, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
 type MockDetailRepo struct {
 	mock.Mock
 }
@@ -111,6 +590,98 @@ func (m *MockDetailRepo) Get(ctx context.Context, id int) (*model.UserFacilityDe
 	return args.Get(0).(*model.UserFacilityDetail), args.Error(1)
 }
 
+func (m *MockDetailRepo) ListByFacility(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error) {
+	args := m.Called(ctx, facilityID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserFacilityDetail), args.Error(1)
+}
+
+func (m *MockDetailRepo) ListUnpaid(ctx context.Context, facilityID int) ([]*model.UserFacilityDetail, error) {
+	args := m.Called(ctx, facilityID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.UserFacilityDetail), args.Error(1)
+}
+
+func (m *MockDetailRepo) MarkPaid(ctx context.Context, ids []int64, paymentID int64, paidAt time.Time) error {
+	args := m.Called(ctx, ids, paymentID, paidAt)
+	return args.Error(0)
+}
+
+type MockPaymentRepo struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepo) Add(ctx context.Context, payment *model.Payment) (int, error) {
+	args := m.Called(ctx, payment)
+	return args.Int(0), args.Error(1)
+}
+
+type MockPayoffRepo struct {
+	mock.Mock
+}
+
+func (m *MockPayoffRepo) Add(ctx context.Context, quote *model.PayoffQuote) (int, error) {
+	args := m.Called(ctx, quote)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPayoffRepo) Get(ctx context.Context, id int) (*model.PayoffQuote, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*model.PayoffQuote), args.Error(1)
+}
+
+func (m *MockPayoffRepo) MarkExecuted(ctx context.Context, id int, paymentID int64) error {
+	args := m.Called(ctx, id, paymentID)
+	return args.Error(0)
+}
+
+type MockHolidayRepo struct {
+	mock.Mock
+}
+
+func (m *MockHolidayRepo) List(ctx context.Context, from, to time.Time) ([]*model.Holiday, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*model.Holiday), args.Error(1)
+}
+
+func (m *MockHolidayRepo) Add(ctx context.Context, holiday *model.Holiday) (int, error) {
+	args := m.Called(ctx, holiday)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockHolidayRepo) Upsert(ctx context.Context, holiday *model.Holiday) error {
+	args := m.Called(ctx, holiday)
+	return args.Error(0)
+}
+
+func (m *MockHolidayRepo) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// newHolidayRepo returns a holiday repository that always answers with the
+// given holidays.
+func newHolidayRepo(holidays ...*model.Holiday) *MockHolidayRepo {
+	holidayRepo := new(MockHolidayRepo)
+	holidayRepo.On("List", mock.Anything, mock.Anything, mock.Anything).Return(holidays, nil).Maybe()
+
+	return holidayRepo
+}
+
 type MockTrx struct {
 	mock.Mock
 }
@@ -151,77 +722,114 @@ func setupService() (
 	trx := new(MockTrx)
 	log := logger.NewNop()
 
-	svc := NewService(userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, log, trx)
+	svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), newProductRepo(), tenorRepo, facilityRepo, detailRepo, new(MockIdempotencyRepo), new(MockApplicationRepo), newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, log, trx)
 
 	return svc, userRepo, detailRepo, facilityRepo, tenorRepo, limitRepo, trx
 }
 
 func TestService_ListUserLimit(t *testing.T) {
-	svc, userRepo, _, _, _, limitRepo, _ := setupService()
-	ctx := context.Background()
+	limitID, productID := int64(10), int64(1)
+	limitAmount := decimal.NewFromInt(7000000)
+	status := model.LimitStatusActive
+	validUntil := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	rows := []*model.UserLimitRow{
+		{UserID: 1, Name: "user 1", Phone: "+628123456789", LimitID: &limitID, ProductID: &productID, LimitAmount: &limitAmount, HeldAmount: decimal.NewFromInt(3000000), Status: &status, ValidUntil: &validUntil},
+		{UserID: 2, Name: "user 2", Phone: "+628111111111"},
+		{UserID: 3, Name: "user 3", Phone: "+628222222222"},
+	}
 
-	t.Run("success", func(t *testing.T) {
-		mockUser := []*model.User{
-			{
-				UserID: 1,
-				Name:   "user 1",
-				Phone:  "911",
-			},
-			{
-				UserID: 2,
-				Name:   "user 2",
-				Phone:  "911",
-			},
-		}
-		mockLimit1 := &model.UserFacilityLimit{
-			FacilityLimitID: 10,
-			UserID:          1,
-			LimitAmount:     decimal.NewFromInt(1000000),
-		}
-		mockLimit2 := &model.UserFacilityLimit{
-			FacilityLimitID: 11,
-			UserID:          2,
-			LimitAmount:     decimal.NewFromInt(2000000),
-		}
+	t.Run("success with next cursor", func(t *testing.T) {
+		svc, _, _, _, _, limitRepo, _ := setupService()
 
-		userRepo.On("List", mock.Anything).Return(mockUser, nil)
-		limitRepo.On("Get", mock.Anything, 1).Return(mockLimit1, nil)
-		limitRepo.On("Get", mock.Anything, 2).Return(mockLimit2, nil)
+		limitRepo.On("ListUserLimits", mock.Anything, mock.MatchedBy(func(f *model.UserLimitFilter) bool {
+			return f.Limit == 3 && f.UserID == 0 && f.MinAvailable.Equal(decimal.NewFromInt(1000)) && f.MaxAvailable == nil
+		})).Return(rows, nil).Once()
 
-		res, err := svc.ListUserLimit(ctx)
+		res, err := svc.ListUserLimit(context.Background(), &model.ListUserLimitsRequest{MinAvailable: "1000", Limit: 2})
 		assert.NoError(t, err)
-		assert.Len(t, res, 2)
-		assert.Equal(t, "1000000", res[0].LimitAmount.String())
-		assert.Equal(t, "2000000", res[1].LimitAmount.String())
+		assert.Len(t, res.Data, 2)
+
+		assert.True(t, res.Data[0].HasLimit)
+		assert.Equal(t, int64(10), *res.Data[0].LimitId)
+		assert.Equal(t, "7000000", res.Data[0].LimitAmount.String())
+		assert.Equal(t, "3000000", res.Data[0].HeldAmount.String())
+		assert.Equal(t, "2027-01-31", res.Data[0].ValidUntil)
+
+		assert.False(t, res.Data[1].HasLimit)
+		assert.Nil(t, res.Data[1].LimitId)
+		assert.True(t, res.Data[1].LimitAmount.IsZero())
+		assert.Empty(t, res.Data[1].Status)
+
+		cursor, err := decodeUserLimitCursor(res.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserLimitCursor{UserID: 2, LimitID: 0}, *cursor)
 	})
 
-	t.Run("partial success", func(t *testing.T) {
-		userRepo.ExpectedCalls = nil
-		limitRepo.ExpectedCalls = nil
-		userRepo.Calls = nil
-		limitRepo.Calls = nil
+	t.Run("success last page from cursor", func(t *testing.T) {
+		svc, _, _, _, _, limitRepo, _ := setupService()
+
+		cursor := encodeUserLimitCursor(rows[0])
+		limitRepo.On("ListUserLimits", mock.Anything, mock.MatchedBy(func(f *model.UserLimitFilter) bool {
+			return f.Limit == defaultPageSize+1 && f.Cursor.UserID == 1 && f.Cursor.LimitID == 10
+		})).Return(rows[1:], nil).Once()
 
-		mockUser := []*model.User{
-			{UserID: 1, Name: "user 1", Phone: "911"},
+		res, err := svc.ListUserLimit(context.Background(), &model.ListUserLimitsRequest{Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Len(t, res.Data, 2)
+		assert.Empty(t, res.NextCursor)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		cases := map[string]struct{ search, phone string }{
+			"name":          {"budi", ""},
+			"full phone":    {"0812-3456-789", "+628123456789"},
+			"partial phone": {"3456", "3456"},
 		}
 
-		userRepo.On("List", mock.Anything).Return(mockUser, nil)
-		limitRepo.On("Get", mock.Anything, 1).Return(nil, errors.New("not found"))
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				svc, _, _, _, _, limitRepo, _ := setupService()
 
-		res, err := svc.ListUserLimit(ctx)
+				limitRepo.On("ListUserLimits", mock.Anything, mock.MatchedBy(func(f *model.UserLimitFilter) bool {
+					return f.Search == c.search && f.Phone == c.phone
+				})).Return([]*model.UserLimitRow{}, nil).Once()
+
+				res, err := svc.ListUserLimit(context.Background(), &model.ListUserLimitsRequest{Search: c.search})
+				assert.NoError(t, err)
+				assert.Empty(t, res.Data)
+				limitRepo.AssertExpectations(t)
+			})
+		}
+	})
 
+	t.Run("customer only sees own limits", func(t *testing.T) {
+		svc, _, _, _, _, limitRepo, _ := setupService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "1", UserID: 1, Role: auth.RoleCustomer})
+
+		limitRepo.On("ListUserLimits", mock.Anything, mock.MatchedBy(func(f *model.UserLimitFilter) bool {
+			return f.UserID == 1
+		})).Return(rows[:1], nil).Once()
+
+		res, err := svc.ListUserLimit(ctx, &model.ListUserLimitsRequest{})
 		assert.NoError(t, err)
-		assert.Len(t, res, 0)
+		assert.Len(t, res.Data, 1)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		svc, _, _, _, _, limitRepo, _ := setupService()
+
+		res, err := svc.ListUserLimit(context.Background(), &model.ListUserLimitsRequest{Cursor: "not-a-cursor"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		limitRepo.AssertNotCalled(t, "ListUserLimits", mock.Anything, mock.Anything)
 	})
 
-	t.Run("failed user list", func(t *testing.T) {
-		userRepo.ExpectedCalls = nil
-		limitRepo.ExpectedCalls = nil
-		userRepo.Calls = nil
-		limitRepo.Calls = nil
+	t.Run("failed list", func(t *testing.T) {
+		svc, _, _, _, _, limitRepo, _ := setupService()
 
-		userRepo.On("List", mock.Anything).Return(nil, errors.New("db error"))
-		res, err := svc.ListUserLimit(ctx)
+		limitRepo.On("ListUserLimits", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		res, err := svc.ListUserLimit(context.Background(), &model.ListUserLimitsRequest{})
 		assert.Error(t, err)
 		assert.Nil(t, res)
 	})
@@ -231,20 +839,136 @@ func TestService_Installment(t *testing.T) {
 	svc, _, _, _, tenorRepo, _, _ := setupService()
 	ctx := context.Background()
 
-	t.Run("Success Calculation", func(t *testing.T) {
-		amount := 10000000
-		mockTenors := []*model.Tenor{
-			{TenorValue: 12},
-		}
+	t.Run("Success Calculation", func(t *testing.T) {
+		amount := 10000000
+		mockTenors := []*model.Tenor{
+			{ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")},
+		}
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return(mockTenors, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{Amount: int64(amount)})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, 12, res[0].Tenor)
+		assert.Equal(t, int64(1000000), res[0].MonthlyInstallment.IntPart())
+		assert.Equal(t, int64(2000000), res[0].TotalMargin.IntPart())
+	})
+
+	t.Run("Skip Tenor Outside Amount Range", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		svc := NewService(nil, nil, nil, nil, newProductRepo(), tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
+
+		maxAmount := decimal.NewFromInt(5000000)
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{
+			{ProductID: 1, TenorValue: 6, Rate: decimal.RequireFromString("0.18"), MaxAmount: &maxAmount},
+			{ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")},
+		}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{Amount: 10000000})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, 12, res[0].Tenor)
+	})
+
+	t.Run("Success Annuity Pricer", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		svc := NewService(nil, nil, nil, nil, newProductRepo(), tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewAnnuity(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{{ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.12")}}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{Amount: 10000000})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "888487.89", res[0].MonthlyInstallment.String())
+		assert.Equal(t, "661854.68", res[0].TotalMargin.String())
+	})
+
+	t.Run("Round To Currency Unit", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		rounding := pricing.Rounding{Strategy: pricing.RemainderUnit, Unit: decimal.NewFromInt(100)}
+		svc := NewService(nil, nil, nil, nil, newProductRepo(), tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), rounding, logger.NewNop(), nil)
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{{ProductID: 1, TenorValue: 3, Rate: decimal.RequireFromString("0.10")}}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{Amount: 1000000})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "341700", res[0].MonthlyInstallment.String())
+		assert.Equal(t, "341700", res[0].FirstInstallment.String())
+		assert.Equal(t, "341600", res[0].LastInstallment.String())
+		assert.Equal(t, "1025000", res[0].TotalPayment.String())
+	})
+
+	t.Run("Due Dates With Billing Day And Grace Period", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		svc := NewService(nil, nil, nil, nil, newProductRepo(), tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{{ProductID: 1, TenorValue: 3, Rate: decimal.RequireFromString("0.10"), BusinessDayRule: calendar.RuleNone}}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{
+			Amount:      1000000,
+			StartDate:   "2027-01-31",
+			BillingDay:  28,
+			GracePeriod: 1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "2027-03-28", res[0].FirstDueDate)
+		assert.Equal(t, "2027-05-28", res[0].LastDueDate)
+	})
+
+	t.Run("Due Dates Follow Business Days", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		holidayRepo := newHolidayRepo(&model.Holiday{HolidayDate: time.Date(2027, 2, 17, 0, 0, 0, 0, time.UTC), Name: "Holiday"})
+		svc := NewService(nil, nil, nil, nil, newProductRepo(), tenorRepo, nil, nil, nil, nil, nil, nil, holidayRepo, pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{{ProductID: 1, TenorValue: 3, Rate: decimal.RequireFromString("0.10"), BusinessDayRule: calendar.RuleFollowing}}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{
+			Amount:     1000000,
+			StartDate:  "2027-01-10",
+			BillingDay: 17,
+		})
+		assert.NoError(t, err)
+		// Feb 17 is a holiday and Apr 17 a Saturday
+		assert.Equal(t, "2027-02-18", res[0].FirstDueDate)
+		assert.Equal(t, "2027-04-19", res[0].LastDueDate)
+	})
+
+	t.Run("Price Each Product With Its Own Method", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		annuity := pricing.MethodAnnuity
+		productRepo := newProductRepo(
+			&model.LimitProduct{ProductID: 1, Code: "general"},
+			&model.LimitProduct{ProductID: 2, Code: "cash_loan", PricingMethod: &annuity},
+		)
+		svc := NewService(nil, nil, nil, nil, productRepo, tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
+
+		tenorRepo.On("List", mock.Anything, 0, mock.Anything).Return([]*model.Tenor{
+			{ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.12")},
+			{ProductID: 2, TenorValue: 12, Rate: decimal.RequireFromString("0.12")},
+		}, nil)
+
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{Amount: 10000000})
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, int64(1), res[0].ProductID)
+		assert.Equal(t, "933333.33", res[0].MonthlyInstallment.String())
+		assert.Equal(t, int64(2), res[1].ProductID)
+		assert.Equal(t, "888487.89", res[1].MonthlyInstallment.String())
+	})
+
+	t.Run("Error Unknown Product", func(t *testing.T) {
+		tenorRepo := new(MockTenorRepo)
+		productRepo := new(MockProductRepo)
+		svc := NewService(nil, nil, nil, nil, productRepo, tenorRepo, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), nil)
 
-		tenorRepo.On("List", mock.Anything).Return(mockTenors, nil)
+		productRepo.On("Get", mock.Anything, 9).Return(nil, errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)).Once()
 
-		res, err := svc.Installment(ctx, int64(amount))
-		assert.NoError(t, err)
-		assert.Len(t, res, 1)
-		assert.Equal(t, 12, res[0].Tenor)
-		assert.Equal(t, int64(1000000), res[0].MonthlyInstallment.IntPart())
-		assert.Equal(t, int64(2000000), res[0].TotalMargin.IntPart())
+		res, err := svc.Installment(ctx, &model.CalculateInstallmentsRequest{ProductID: 9, Amount: 10000000})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		tenorRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
 	})
 }
 
@@ -261,11 +985,14 @@ func TestService_Submit(t *testing.T) {
 	mockUser := &model.User{
 		UserID: 1, Name: "user 1", Phone: "911",
 	}
-	mockTenor := &model.Tenor{TenorID: 1, TenorValue: 12}
+	mockTenor := &model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}
 	mockLimit := &model.UserFacilityLimit{
 		FacilityLimitID: 10,
 		UserID:          1,
+		ProductID:       1,
 		LimitAmount:     decimal.NewFromInt(20000000),
+		Status:          model.LimitStatusActive,
+		ValidUntil:      time.Now().AddDate(1, 0, 0),
 	}
 
 	t.Run("Success Transaction", func(t *testing.T) {
@@ -275,22 +1002,27 @@ func TestService_Submit(t *testing.T) {
 		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
 
 		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
-		limitRepo.On("Get", mock.Anything, 1).Return(mockLimit, nil).Once()
-		tenorRepo.On("Get", mock.Anything, 12).Return(mockTenor, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(mockLimit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(mockTenor, nil).Once()
 
 		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
 		trx.On("Rollback", mock.Anything).Return(nil).Once() // Defer rollback selalu dipanggil
 
 		facilityRepo.On("Add", txCtx, mock.MatchedBy(func(f *model.UserFacility) bool {
-			return f.Amount.IntPart() == req.Amount && f.Tenor == 12 && f.UserID == 1
+			return f.Amount.IntPart() == req.Amount && f.Tenor == 12 && f.UserID == 1 &&
+				f.Rate.Equal(mockTenor.Rate) && f.PricingMethod == pricing.MethodFlat && f.RemainderStrategy == pricing.RemainderLast && f.EngineVersion == pricing.Version
 		})).Return(1, nil).Once()
 
 		detailRepo.On("Add", txCtx, mock.MatchedBy(func(details []*model.UserFacilityDetail) bool {
-			return len(details) == 12 && details[0].UserFacilityID == 1
+			return len(details) == 12 && details[0].UserFacilityID == 1 &&
+				details[0].PrincipalAmount.Add(details[0].MarginAmount).Equal(details[0].InstallmentAmount) &&
+				details[11].OutstandingPrincipal.IsZero()
 		})).Return(nil).Once()
 
-		remainingLimit := mockLimit.LimitAmount.Sub(decimal.NewFromInt(req.Amount))
-		limitRepo.On("Update", txCtx, 10, remainingLimit.IntPart()).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.FacilityLimitID == 10 && e.EntryType == model.LedgerDrawdown &&
+				e.Amount.Equal(decimal.NewFromInt(-req.Amount)) && *e.ReferenceID == 1
+		})).Return(nil).Once()
 
 		trx.On("Commit", txCtx).Return(nil).Once()
 
@@ -298,10 +1030,159 @@ func TestService_Submit(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, res)
 		assert.Equal(t, int64(1), res.UserFacilityID)
+		assert.Equal(t, "833333.33", res.Schedule[0].PrincipalAmount.String())
+		assert.Equal(t, "166666.67", res.Schedule[0].MarginAmount.String())
+		assert.Equal(t, "9166666.67", res.Schedule[0].OutstandingPrincipal.String())
 		trx.AssertExpectations(t)
 		limitRepo.AssertExpectations(t)
 	})
 
+	t.Run("error inactive user", func(t *testing.T) {
+		svc, userRepo, _, _, _, limitRepo, trx := setupService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusInactive}, nil).Once()
+
+		res, err := svc.Submit(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "user not active: user has been deactivated", err.Error())
+		limitRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("error acting for another user", func(t *testing.T) {
+		svc, userRepo, _, _, _, _, trx := setupService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", UserID: 2, Role: auth.RoleCustomer})
+
+		res, err := svc.Submit(ctx, req)
+		assert.Nil(t, res)
+		assert.Equal(t, "forbidden: not allowed to act on behalf of this user", err.Error())
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("error kyc not verified", func(t *testing.T) {
+		expiredAt := time.Now().Add(-time.Hour)
+		cases := map[string]func(kycRepo *MockKYCRepo){
+			"user has not submitted kyc": func(kycRepo *MockKYCRepo) {
+				kycRepo.On("Get", mock.Anything, 1).Return(nil, errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)).Once()
+			},
+			"kyc is pending": func(kycRepo *MockKYCRepo) {
+				kycRepo.On("Get", mock.Anything, 1).Return(&model.KYC{UserID: 1, Status: model.KYCStatusPending}, nil).Once()
+			},
+			"kyc is rejected": func(kycRepo *MockKYCRepo) {
+				kycRepo.On("Get", mock.Anything, 1).Return(&model.KYC{UserID: 1, Status: model.KYCStatusRejected}, nil).Once()
+			},
+			"kyc is expired": func(kycRepo *MockKYCRepo) {
+				kycRepo.On("Get", mock.Anything, 1).Return(&model.KYC{UserID: 1, Status: model.KYCStatusVerified, ExpiresAt: &expiredAt}, nil).Once()
+			},
+		}
+
+		for msg, setup := range cases {
+			userRepo := new(MockUserRepo)
+			kycRepo := new(MockKYCRepo)
+			limitRepo := new(MockLimitRepo)
+			trx := new(MockTrx)
+			svc := NewService(userRepo, kycRepo, limitRepo, newHoldRepo(), newProductRepo(), nil, nil, nil, nil, nil, nil, nil, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+			userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
+			setup(kycRepo)
+
+			res, err := svc.Submit(context.Background(), req)
+			assert.Error(t, err)
+			assert.Nil(t, res)
+			assert.Equal(t, "kyc not verified: "+msg, err.Error())
+			limitRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+			trx.AssertNotCalled(t, "Begin", mock.Anything)
+		}
+	})
+
+	t.Run("error limit of another user", func(t *testing.T) {
+		svc, userRepo, _, _, tenorRepo, limitRepo, trx := setupService()
+		ctx := context.Background()
+
+		otherLimit := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 2, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000)}
+		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(otherLimit, nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: facility limit not found for this user", err.Error())
+		tenorRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("success with product pricing method", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		limitRepo := new(MockLimitRepo)
+		tenorRepo := new(MockTenorRepo)
+		facilityRepo := new(MockFacilityRepo)
+		detailRepo := new(MockDetailRepo)
+		trx := new(MockTrx)
+		annuity := pricing.MethodAnnuity
+		productRepo := newProductRepo(&model.LimitProduct{ProductID: 2, Code: "cash_loan", PricingMethod: &annuity})
+		disbursementRepo := new(MockDisbursementRepo)
+		outboxRepo := new(MockOutboxRepo)
+		svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), productRepo, tenorRepo, facilityRepo, detailRepo, new(MockIdempotencyRepo), new(MockApplicationRepo), disbursementRepo, outboxRepo, newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+		cashLoan := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 2, LimitAmount: decimal.NewFromInt(20000000), ValidUntil: time.Now().AddDate(1, 0, 0)}
+
+		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(cashLoan, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 2, 12, mock.Anything).Return(&model.Tenor{TenorID: 5, ProductID: 2, TenorValue: 12, Rate: decimal.RequireFromString("0.12")}, nil).Once()
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		facilityRepo.On("Add", txCtx, mock.MatchedBy(func(f *model.UserFacility) bool {
+			return f.FacilityLimitID == 10 && f.PricingMethod == pricing.MethodAnnuity
+		})).Return(3, nil).Once()
+		detailRepo.On("Add", txCtx, mock.Anything).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.Anything).Return(nil).Once()
+		disbursementRepo.On("Add", txCtx, mock.MatchedBy(func(d *model.Disbursement) bool {
+			return d.UserFacilityID == 3 && d.Amount.Equal(decimal.NewFromInt(req.Amount)) && d.Status == model.DisbursementStatusPending
+		})).Return(1, nil).Once()
+		outboxRepo.On("Add", txCtx, mock.MatchedBy(func(e *model.OutboxEvent) bool {
+			return e.EventType == model.EventFacilityCreated && e.AggregateType == model.AggregateFacility && e.AggregateID == 3 && e.EventID != ""
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, "888487.89", res.MonthlyInstallment.String())
+		facilityRepo.AssertExpectations(t)
+		disbursementRepo.AssertExpectations(t)
+		outboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("error limit not available", func(t *testing.T) {
+		cases := map[string]func(l *model.UserFacilityLimit){
+			"limit is frozen": func(l *model.UserFacilityLimit) { l.Status = model.LimitStatusFrozen },
+			"limit is closed": func(l *model.UserFacilityLimit) { l.Status = model.LimitStatusClosed },
+			"limit has expired, it must be renewed before drawing on it": func(l *model.UserFacilityLimit) {
+				l.ValidUntil = time.Now().AddDate(0, 0, -1)
+			},
+		}
+
+		for msg, modify := range cases {
+			svc, userRepo, _, _, tenorRepo, limitRepo, trx := setupService()
+			ctx := context.Background()
+
+			limit := *mockLimit
+			modify(&limit)
+			userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
+			limitRepo.On("GetByID", mock.Anything, 10).Return(&limit, nil).Once()
+
+			res, err := svc.Submit(ctx, req)
+			assert.Error(t, err)
+			assert.Nil(t, res)
+			assert.Equal(t, "limit not available: "+msg, err.Error())
+			tenorRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			trx.AssertNotCalled(t, "Begin", mock.Anything)
+		}
+	})
+
 	t.Run("error insufficent limit", func(t *testing.T) {
 		svc, userRepo, _, _, _, limitRepo, _ := setupService()
 		ctx := context.Background()
@@ -309,11 +1190,14 @@ func TestService_Submit(t *testing.T) {
 		smallLimit := &model.UserFacilityLimit{
 			FacilityLimitID: 10,
 			UserID:          1,
+			ProductID:       1,
 			LimitAmount:     decimal.NewFromInt(5000000),
+			Status:          model.LimitStatusActive,
+			ValidUntil:      time.Now().AddDate(1, 0, 0),
 		}
 
 		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
-		limitRepo.On("Get", mock.Anything, 1).Return(smallLimit, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(smallLimit, nil).Once()
 
 		res, err := svc.Submit(ctx, req)
 
@@ -322,14 +1206,33 @@ func TestService_Submit(t *testing.T) {
 		assert.Equal(t, "insufficient limit amount: limit balance is not enough", err.Error())
 	})
 
+	t.Run("error amount outside tenor range", func(t *testing.T) {
+		svc, userRepo, _, facilityRepo, tenorRepo, limitRepo, trx := setupService()
+		ctx := context.Background()
+
+		minAmount := decimal.NewFromInt(15000000)
+		rangedTenor := &model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20"), MinAmount: &minAmount}
+
+		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(mockLimit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(rangedTenor, nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "tenor option not available: amount is outside the range allowed for this tenor", err.Error())
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+		facilityRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
 	t.Run("error database fail on insert", func(t *testing.T) {
 		svc, userRepo, _, facilityRepo, tenorRepo, limitRepo, trx := setupService()
 		ctx := context.Background()
 		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
 
 		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil)
-		limitRepo.On("Get", mock.Anything, 1).Return(mockLimit, nil)
-		tenorRepo.On("Get", mock.Anything, 12).Return(mockTenor, nil)
+		limitRepo.On("GetByID", mock.Anything, 10).Return(mockLimit, nil)
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(mockTenor, nil)
 
 		trx.On("Begin", mock.Anything).Return(txCtx, nil)
 		trx.On("Rollback", mock.Anything).Return(nil)
@@ -346,29 +1249,531 @@ func TestService_Submit(t *testing.T) {
 		trx.AssertCalled(t, "Rollback", mock.Anything)
 	})
 
-	t.Run("error update limit", func(t *testing.T) {
+	t.Run("error deduct limit", func(t *testing.T) {
 		svc, userRepo, detailRepo, facilityRepo, tenorRepo, limitRepo, trx := setupService()
 		ctx := context.Background()
 		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
 
 		userRepo.On("Get", mock.Anything, 1).Return(mockUser, nil)
-		limitRepo.On("Get", mock.Anything, 1).Return(mockLimit, nil)
-		tenorRepo.On("Get", mock.Anything, 12).Return(mockTenor, nil)
+		limitRepo.On("GetByID", mock.Anything, 10).Return(mockLimit, nil)
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(mockTenor, nil)
 
 		trx.On("Begin", mock.Anything).Return(txCtx, nil)
 		trx.On("Rollback", mock.Anything).Return(nil)
 
 		facilityRepo.On("Add", txCtx, mock.Anything).Return(9, nil)
 		detailRepo.On("Add", txCtx, mock.Anything).Return(nil)
-		limitRepo.On("Update", txCtx, 10, mock.Anything).Return(errors.New("update limit failed"))
+		limitRepo.On("Post", txCtx, mock.Anything).Return(errors.New("deduct limit failed"))
 
 		res, err := svc.Submit(ctx, req)
 
 		assert.Error(t, err)
 		assert.Nil(t, res)
-		assert.Equal(t, "update limit failed", err.Error())
+		assert.Equal(t, "deduct limit failed", err.Error())
 
 		trx.AssertNotCalled(t, "Commit", txCtx)
 		trx.AssertCalled(t, "Rollback", mock.Anything)
 	})
 }
+
+func TestService_CaptureHold(t *testing.T) {
+	req := &model.CaptureHoldRequest{Tenor: 12, StartDate: time.Now().Format("2006-01-02")}
+	mockTenor := &model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}
+	// the held 10,000,000 was already taken off, leaving less than the amount
+	heldLimit := &model.UserFacilityLimit{
+		FacilityLimitID: 10,
+		UserID:          1,
+		ProductID:       1,
+		LimitAmount:     decimal.NewFromInt(2000000),
+		Status:          model.LimitStatusActive,
+		ValidUntil:      time.Now().AddDate(1, 0, 0),
+	}
+	activeHold := func() *model.LimitHold {
+		return &model.LimitHold{
+			HoldID:          4,
+			FacilityLimitID: 10,
+			UserID:          1,
+			Amount:          decimal.NewFromInt(10000000),
+			Status:          model.HoldStatusActive,
+			ExpiresAt:       time.Now().Add(10 * time.Minute),
+		}
+	}
+
+	setup := func() (Service, *MockUserRepo, *MockLimitRepo, *MockHoldRepo, *MockTenorRepo, *MockFacilityRepo, *MockApplicationRepo, *MockTrx) {
+		userRepo := new(MockUserRepo)
+		limitRepo := new(MockLimitRepo)
+		holdRepo := new(MockHoldRepo)
+		tenorRepo := new(MockTenorRepo)
+		facilityRepo := new(MockFacilityRepo)
+		applicationRepo := new(MockApplicationRepo)
+		trx := new(MockTrx)
+		svc := NewService(userRepo, newKYCRepo(), limitRepo, holdRepo, newProductRepo(), tenorRepo, facilityRepo, new(MockDetailRepo), new(MockIdempotencyRepo), applicationRepo, newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+		return svc, userRepo, limitRepo, holdRepo, tenorRepo, facilityRepo, applicationRepo, trx
+	}
+
+	t.Run("success moves the held amount to a submitted application", func(t *testing.T) {
+		svc, userRepo, limitRepo, holdRepo, tenorRepo, facilityRepo, applicationRepo, trx := setup()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		holdRepo.On("Get", mock.Anything, 4).Return(activeHold(), nil).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(heldLimit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(mockTenor, nil).Once()
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		applicationRepo.On("Add", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.UserID == 1 && app.Amount.Equal(decimal.NewFromInt(10000000)) && app.Tenor == 12
+		})).Return(6, nil).Once()
+		holdRepo.On("Resolve", txCtx, mock.MatchedBy(func(h *model.LimitHold) bool {
+			return h.Status == model.HoldStatusCaptured && *h.ApplicationID == 6 && h.UserFacilityID == nil
+		})).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerRelease && e.Amount.Equal(decimal.NewFromInt(10000000)) &&
+				*e.ReferenceType == model.ReferenceLimitHold && *e.ReferenceID == 4
+		})).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerHold && e.Amount.Equal(decimal.NewFromInt(-10000000)) &&
+				*e.ReferenceType == model.ReferenceApplication && *e.ReferenceID == 6
+		})).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.MatchedBy(func(app *model.FinancingApplication) bool {
+			return app.Status == model.ApplicationStatusSubmitted && app.SubmittedAt != nil
+		}), model.ApplicationStatusDraft).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.CaptureHold(ctx, 4, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), res.ApplicationID)
+		assert.Equal(t, model.ApplicationStatusSubmitted, res.Status)
+		assert.Nil(t, res.UserFacilityID)
+		assert.Equal(t, "10000000", res.Amount.String())
+		holdRepo.AssertExpectations(t)
+		limitRepo.AssertExpectations(t)
+		applicationRepo.AssertExpectations(t)
+		facilityRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error hold expired", func(t *testing.T) {
+		svc, userRepo, _, holdRepo, _, _, _, trx := setup()
+
+		hold := activeHold()
+		hold.ExpiresAt = time.Now().Add(-time.Minute)
+		holdRepo.On("Get", mock.Anything, 4).Return(hold, nil).Once()
+
+		res, err := svc.CaptureHold(context.Background(), 4, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "limit hold not valid: hold has expired, place a new hold", err.Error())
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("error hold released", func(t *testing.T) {
+		svc, _, _, holdRepo, _, _, _, _ := setup()
+
+		hold := activeHold()
+		hold.Status = model.HoldStatusReleased
+		holdRepo.On("Get", mock.Anything, 4).Return(hold, nil).Once()
+
+		res, err := svc.CaptureHold(context.Background(), 4, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "limit hold not valid: hold has already been released", err.Error())
+	})
+}
+
+// memLimitRepo keeps limits in memory and applies Post as one guarded
+// check-and-write, the same guarantee the conditional UPDATE gives in Postgres.
+type memLimitRepo struct {
+	mu     sync.Mutex
+	limits map[int]*model.UserFacilityLimit
+}
+
+func (r *memLimitRepo) GetByID(ctx context.Context, id int) (*model.UserFacilityLimit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit, ok := r.limits[id]
+	if !ok {
+		return nil, errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)
+	}
+	copied := *limit
+
+	return &copied, nil
+}
+
+func (r *memLimitRepo) ListByUser(ctx context.Context, userID int) ([]*model.UserFacilityLimit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var limits []*model.UserFacilityLimit
+	for _, limit := range r.limits {
+		if limit.UserID == int64(userID) {
+			copied := *limit
+			limits = append(limits, &copied)
+		}
+	}
+
+	return limits, nil
+}
+
+func (r *memLimitRepo) ListUserLimits(ctx context.Context, filter *model.UserLimitFilter) ([]*model.UserLimitRow, error) {
+	return nil, nil
+}
+
+func (r *memLimitRepo) Add(ctx context.Context, limit *model.UserFacilityLimit) (int, error) {
+	return 0, nil
+}
+
+func (r *memLimitRepo) UpdateStatus(ctx context.Context, id int, status string) error {
+	return nil
+}
+
+func (r *memLimitRepo) UpdateValidity(ctx context.Context, id int, validUntil, nextReviewDate time.Time) error {
+	return nil
+}
+
+func (r *memLimitRepo) ListDueForReview(ctx context.Context, on time.Time) ([]*model.UserFacilityLimit, error) {
+	return nil, nil
+}
+
+func (r *memLimitRepo) Post(ctx context.Context, entry *model.LimitLedgerEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limit := r.limits[int(entry.FacilityLimitID)]
+	balance := limit.LimitAmount.Add(entry.Amount)
+	if balance.IsNegative() {
+		return errorx.NewError(errorx.ErrInsufficientLimit, "limit balance is not enough", nil)
+	}
+	limit.LimitAmount = balance
+	entry.BalanceAfter = balance
+
+	return nil
+}
+
+func (r *memLimitRepo) ListLedger(ctx context.Context, limitID int, before int64, limit int) ([]*model.LimitLedgerEntry, error) {
+	return nil, nil
+}
+
+func (r *memLimitRepo) LedgerBalance(ctx context.Context, limitID int) (decimal.Decimal, error) {
+	return decimal.Zero, nil
+}
+
+func TestService_Submit_Concurrent(t *testing.T) {
+	const parallel = 20
+
+	limitRepo := &memLimitRepo{limits: map[int]*model.UserFacilityLimit{
+		10: {FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(10000000), ValidUntil: time.Now().AddDate(1, 0, 0)},
+	}}
+	userRepo := new(MockUserRepo)
+	tenorRepo := new(MockTenorRepo)
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	trx := new(MockTrx)
+
+	userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil)
+	tenorRepo.On("Get", mock.Anything, 1, 6, mock.Anything).Return(&model.Tenor{ProductID: 1, TenorValue: 6, Rate: decimal.RequireFromString("0.20")}, nil)
+	trx.On("Begin", mock.Anything).Return(context.Background(), nil)
+	trx.On("Rollback", mock.Anything).Return(nil)
+	trx.On("Commit", mock.Anything).Return(nil)
+	facilityRepo.On("Add", mock.Anything, mock.Anything).Return(1, nil)
+	detailRepo.On("Add", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), newProductRepo(), tenorRepo, facilityRepo, detailRepo, new(MockIdempotencyRepo), new(MockApplicationRepo), newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+	req := &model.SubmitFinancingRequest{
+		UserID:          1,
+		FacilityLimitID: 10,
+		Amount:          3000000,
+		Tenor:           6,
+		StartDate:       time.Now().Format("2006-01-02"),
+	}
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+		rejected  atomic.Int32
+	)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := svc.Submit(context.Background(), req)
+			var appErr *errorx.AppError
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.As(err, &appErr) && appErr.Type == errorx.ErrInsufficientLimit:
+				rejected.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 10,000,000 covers exactly three drawdowns of 3,000,000
+	assert.Equal(t, int32(3), succeeded.Load())
+	assert.Equal(t, int32(parallel-3), rejected.Load())
+	assert.Equal(t, "1000000", limitRepo.limits[10].LimitAmount.String())
+}
+
+func TestService_GetFacility(t *testing.T) {
+	startDate := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	paidAt := time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC)
+	mockFacility := &model.UserFacility{
+		UserFacilityID:     7,
+		UserID:             1,
+		FacilityLimitID:    10,
+		Amount:             decimal.NewFromInt(6000000),
+		Tenor:              2,
+		StartDate:          startDate,
+		MonthlyInstallment: decimal.NewFromInt(3100000),
+		TotalMargin:        decimal.NewFromInt(200000),
+		TotalPayment:       decimal.NewFromInt(6200000),
+		CreatedAt:          startDate,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, _, detailRepo, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		mockDetails := []*model.UserFacilityDetail{
+			{DetailID: 1, UserFacilityID: 7, DueDate: startDate.AddDate(0, 1, 0), InstallmentAmount: decimal.NewFromInt(3100000), Status: model.DetailStatusPaid, PaidAt: &paidAt},
+			{DetailID: 2, UserFacilityID: 7, DueDate: startDate.AddDate(0, 2, 0), InstallmentAmount: decimal.NewFromInt(3100000), Status: model.DetailStatusUnpaid},
+		}
+
+		facilityRepo.On("Get", mock.Anything, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListByFacility", mock.Anything, 7).Return(mockDetails, nil).Once()
+
+		res, err := svc.GetFacility(ctx, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), res.UserFacilityID)
+		assert.Equal(t, "2026-01-15", res.StartDate)
+		assert.Len(t, res.Schedule, 2)
+		assert.Equal(t, "2026-02-15", res.Schedule[0].DueDate)
+		assert.Equal(t, model.DetailStatusPaid, res.Schedule[0].Status)
+		assert.Equal(t, "2026-02-10T09:00:00Z", res.Schedule[0].PaidAt)
+		assert.Equal(t, model.DetailStatusUnpaid, res.Schedule[1].Status)
+		assert.Empty(t, res.Schedule[1].PaidAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		svc, _, detailRepo, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		facilityRepo.On("Get", mock.Anything, 99).Return(nil, errors.New("not found")).Once()
+
+		res, err := svc.GetFacility(ctx, 99)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		detailRepo.AssertNotCalled(t, "ListByFacility", mock.Anything, mock.Anything)
+	})
+
+	t.Run("facility of another user", func(t *testing.T) {
+		svc, _, detailRepo, facilityRepo, _, _, _ := setupService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", UserID: 2, Role: auth.RoleCustomer})
+
+		facilityRepo.On("Get", mock.Anything, 7).Return(mockFacility, nil).Once()
+
+		res, err := svc.GetFacility(ctx, 7)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: facility not found", err.Error())
+		detailRepo.AssertNotCalled(t, "ListByFacility", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ListFacilities(t *testing.T) {
+	createdAt := time.Date(2026, 2, 1, 10, 30, 0, 0, time.UTC)
+	mockFacilities := []*model.UserFacility{
+		{UserFacilityID: 3, UserID: 1, Amount: decimal.NewFromInt(3000000), Tenor: 6, Status: model.FacilityStatusActive, CreatedAt: createdAt},
+		{UserFacilityID: 2, UserID: 1, Amount: decimal.NewFromInt(2000000), Tenor: 6, Status: model.FacilityStatusActive, CreatedAt: createdAt.Add(-time.Hour)},
+		{UserFacilityID: 1, UserID: 1, Amount: decimal.NewFromInt(1000000), Tenor: 6, Status: model.FacilityStatusPaidOff, CreatedAt: createdAt.Add(-2 * time.Hour)},
+	}
+
+	t.Run("success with next cursor", func(t *testing.T) {
+		svc, userRepo, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		facilityRepo.On("List", mock.Anything, mock.MatchedBy(func(f *model.FacilityFilter) bool {
+			return f.UserID == 1 && f.Limit == 3 && f.SortBy == "created_at" && f.SortDesc && f.MinAmount.Equal(decimal.NewFromInt(500000))
+		})).Return(mockFacilities, nil).Once()
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{UserID: 1, Limit: 2, MinAmount: "500000"})
+		assert.NoError(t, err)
+		assert.Len(t, res.Data, 2)
+		assert.Empty(t, res.Data[0].Schedule)
+		assert.NotEmpty(t, res.NextCursor)
+
+		cursor, err := decodeFacilityCursor(res.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), cursor.ID)
+		assert.Equal(t, "created_at", cursor.SortBy)
+		assert.Equal(t, "2026-02-01T09:30:00", cursor.Value)
+	})
+
+	t.Run("success last page", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		cursor := encodeFacilityCursor(&model.FacilityFilter{SortBy: "amount"}, mockFacilities[0])
+		facilityRepo.On("List", mock.Anything, mock.MatchedBy(func(f *model.FacilityFilter) bool {
+			return f.Limit == defaultPageSize+1 && !f.SortDesc && f.Cursor.Value == "3000000" && f.Cursor.ID == 3
+		})).Return(mockFacilities[1:], nil).Once()
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{SortBy: "amount", SortOrder: "asc", Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Len(t, res.Data, 2)
+		assert.Empty(t, res.NextCursor)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{Cursor: "not-a-cursor"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		facilityRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cursor of another sort", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		cursor := encodeFacilityCursor(&model.FacilityFilter{SortBy: "created_at", SortDesc: true}, mockFacilities[0])
+		for _, req := range []*model.ListFacilitiesRequest{
+			{SortBy: "amount", Cursor: cursor},
+			{SortOrder: "asc", Cursor: cursor},
+		} {
+			res, err := svc.ListFacilities(ctx, req)
+			var appErr *errorx.AppError
+			assert.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errorx.ErrTypeValidation, appErr.Type)
+			assert.Nil(t, res)
+		}
+		facilityRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		svc, userRepo, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		userRepo.On("Get", mock.Anything, 99).Return(nil, errors.New("not found")).Once()
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{UserID: 99})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		facilityRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("customer only sees own facilities", func(t *testing.T) {
+		svc, userRepo, _, facilityRepo, _, _, _ := setupService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "1", UserID: 1, Role: auth.RoleCustomer})
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		facilityRepo.On("List", mock.Anything, mock.MatchedBy(func(f *model.FacilityFilter) bool {
+			return f.UserID == 1
+		})).Return(mockFacilities, nil).Once()
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, res.Data, 3)
+	})
+
+	t.Run("customer listing another user", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "1", UserID: 1, Role: auth.RoleCustomer})
+
+		res, err := svc.ListFacilities(ctx, &model.ListFacilitiesRequest{UserID: 2})
+		assert.Nil(t, res)
+		assert.Equal(t, "forbidden: not allowed to act on behalf of this user", err.Error())
+		facilityRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_RecomputeFacility(t *testing.T) {
+	booked := func() *model.UserFacility {
+		return &model.UserFacility{
+			UserFacilityID:     7,
+			Amount:             decimal.NewFromInt(10000000),
+			Tenor:              12,
+			MonthlyInstallment: decimal.NewFromInt(1000000),
+			TotalMargin:        decimal.NewFromInt(2000000),
+			TotalPayment:       decimal.NewFromInt(12000000),
+			Rate:               decimal.RequireFromString("0.20"),
+			PricingMethod:      pricing.MethodFlat,
+			RemainderStrategy:  pricing.RemainderLast,
+			EngineVersion:      pricing.Version,
+		}
+	}
+
+	t.Run("no drift", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		facilityRepo.On("Get", mock.Anything, 7).Return(booked(), nil).Once()
+
+		res, err := svc.RecomputeFacility(ctx, 7)
+		assert.NoError(t, err)
+		assert.False(t, res.HasDrift)
+		assert.True(t, res.Drift.TotalPayment.IsZero())
+		assert.Equal(t, pricing.Version, res.CurrentEngineVersion)
+	})
+
+	t.Run("drift detected", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		facility := booked()
+		facility.TotalMargin = decimal.NewFromInt(1900000)
+		facility.TotalPayment = decimal.NewFromInt(11900000)
+		facilityRepo.On("Get", mock.Anything, 7).Return(facility, nil).Once()
+
+		res, err := svc.RecomputeFacility(ctx, 7)
+		assert.NoError(t, err)
+		assert.True(t, res.HasDrift)
+		assert.Equal(t, "100000", res.Drift.TotalMargin.String())
+		assert.Equal(t, "2000000", res.Recomputed.TotalMargin.String())
+	})
+
+	t.Run("recomputes with the rounding stored on the facility", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		// booked while installments were rounded up to IDR 100, the service
+		// now reconciles on the last installment instead
+		facility := booked()
+		facility.Amount = decimal.NewFromInt(1000000)
+		facility.Tenor = 3
+		facility.Rate = decimal.RequireFromString("0.10")
+		facility.MonthlyInstallment = decimal.NewFromInt(341700)
+		facility.TotalMargin = decimal.NewFromInt(25000)
+		facility.TotalPayment = decimal.NewFromInt(1025000)
+		facility.RemainderStrategy = pricing.RemainderUnit
+		facility.RoundingUnit = decimal.NewFromInt(100)
+		facilityRepo.On("Get", mock.Anything, 7).Return(facility, nil).Once()
+
+		res, err := svc.RecomputeFacility(ctx, 7)
+		assert.NoError(t, err)
+		assert.False(t, res.HasDrift)
+		assert.Equal(t, "341700", res.Recomputed.MonthlyInstallment.String())
+		assert.Equal(t, pricing.RemainderUnit, res.RemainderStrategy)
+	})
+
+	t.Run("unknown pricing method", func(t *testing.T) {
+		svc, _, _, facilityRepo, _, _, _ := setupService()
+		ctx := context.Background()
+
+		facility := booked()
+		facility.PricingMethod = "balloon"
+		facilityRepo.On("Get", mock.Anything, 7).Return(facility, nil).Once()
+
+		res, err := svc.RecomputeFacility(ctx, 7)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
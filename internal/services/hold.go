@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// expireBatchSize caps how many expired holds one sweep gives back.
+const expireBatchSize = 100
+
+type HoldService interface {
+	Hold(ctx context.Context, req *model.HoldRequest) (*model.HoldResponse, error)
+	Get(ctx context.Context, id int) (*model.HoldResponse, error)
+	Release(ctx context.Context, id int) (*model.HoldResponse, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int, error)
+}
+
+type holdService struct {
+	userRepo  repository.UserRepository
+	limitRepo repository.LimitRepository
+	holdRepo  repository.HoldRepository
+	ttl       time.Duration
+	log       *logger.Logger
+	trx       postgres.Trx
+}
+
+func NewHoldService(
+	userRepo repository.UserRepository,
+	limitRepo repository.LimitRepository,
+	holdRepo repository.HoldRepository,
+	ttl time.Duration,
+	log *logger.Logger,
+	trx postgres.Trx,
+) HoldService {
+	return &holdService{
+		userRepo:  userRepo,
+		limitRepo: limitRepo,
+		holdRepo:  holdRepo,
+		ttl:       ttl,
+		log:       log,
+		trx:       trx,
+	}
+}
+
+// Hold reserves part of the user's limit until the hold is captured,
+// released or expires. The amount is taken off the limit balance right away,
+// so concurrent holds and drawdowns can never overdraw the limit.
+func (s *holdService) Hold(ctx context.Context, req *model.HoldRequest) (*model.HoldResponse, error) {
+	err := checkAccess(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Get(ctx, int(req.UserID))
+	if err != nil {
+		s.log.Error("failed to get user", zap.Error(err))
+		return nil, err
+	}
+
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := s.limitRepo.GetByID(ctx, int(req.FacilityLimitID))
+	if err != nil {
+		s.log.Error("failed to get user limit amount", zap.Int64("facility_limit_id", req.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	if limit.UserID != user.UserID {
+		return nil, errorx.NewError(errorx.ErrTypeNotFound, "facility limit not found for this user", nil)
+	}
+
+	now := time.Now()
+	err = checkLimitAvailable(limit, now)
+	if err != nil {
+		s.log.Warn("facility limit not available", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	ttl := s.ttl
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	hold := &model.LimitHold{
+		FacilityLimitID: limit.FacilityLimitID,
+		UserID:          user.UserID,
+		Amount:          decimal.NewFromInt(req.Amount),
+		Reference:       req.Reference,
+		Status:          model.HoldStatusActive,
+		ExpiresAt:       now.Add(ttl),
+		CreatedAt:       now,
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	holdID, err := s.holdRepo.Add(txCtx, hold)
+	if err != nil {
+		s.log.Error("failed to insert limit hold", zap.Error(err))
+		return nil, err
+	}
+	hold.HoldID = int64(holdID)
+
+	err = s.limitRepo.Post(txCtx, newLedgerEntry(limit.FacilityLimitID, model.LedgerHold, hold.Amount.Neg(), model.ReferenceLimitHold, hold.HoldID))
+	if err != nil {
+		s.log.Error("failed to hold limit", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return toHoldResponse(hold), nil
+}
+
+func (s *holdService) Get(ctx context.Context, id int) (*model.HoldResponse, error) {
+	hold, err := s.holdRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get limit hold", zap.Int("hold_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, hold.UserID, "limit hold")
+	if err != nil {
+		return nil, err
+	}
+
+	return toHoldResponse(hold), nil
+}
+
+// Release gives an active hold back to the limit, e.g. when the merchant
+// declines the order.
+func (s *holdService) Release(ctx context.Context, id int) (*model.HoldResponse, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	hold, err := s.holdRepo.Get(txCtx, id)
+	if err != nil {
+		s.log.Error("failed to get limit hold", zap.Int("hold_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, hold.UserID, "limit hold")
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.Status != model.HoldStatusActive {
+		return nil, errorx.NewError(errorx.ErrHoldNotValid, "hold has already been "+hold.Status, nil)
+	}
+
+	err = s.giveBack(txCtx, hold, model.HoldStatusReleased, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return toHoldResponse(hold), nil
+}
+
+// ExpireHolds gives back the holds that expired at or before now and returns
+// how many were expired. Each hold is expired in its own transaction, so a
+// hold captured or released in the meantime is skipped without failing the
+// rest.
+func (s *holdService) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	holds, err := s.holdRepo.ListExpired(ctx, now, expireBatchSize)
+	if err != nil {
+		s.log.Error("failed to get expired limit holds", zap.Error(err))
+		return 0, err
+	}
+
+	expired := 0
+	for _, hold := range holds {
+		err = s.expire(ctx, hold, now)
+		if err != nil {
+			s.log.Warn("failed to expire limit hold", zap.Int64("hold_id", hold.HoldID), zap.Error(err))
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (s *holdService) expire(ctx context.Context, hold *model.LimitHold, now time.Time) error {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	err = s.giveBack(txCtx, hold, model.HoldStatusExpired, now)
+	if err != nil {
+		return err
+	}
+
+	return s.trx.Commit(txCtx)
+}
+
+// giveBack resolves the hold with the given status and returns its amount to
+// the limit balance.
+func (s *holdService) giveBack(ctx context.Context, hold *model.LimitHold, status string, now time.Time) error {
+	hold.Status = status
+	hold.ResolvedAt = &now
+	err := s.holdRepo.Resolve(ctx, hold)
+	if err != nil {
+		s.log.Error("failed to resolve limit hold", zap.Int64("hold_id", hold.HoldID), zap.Error(err))
+		return err
+	}
+
+	err = s.limitRepo.Post(ctx, newLedgerEntry(hold.FacilityLimitID, model.LedgerRelease, hold.Amount, model.ReferenceLimitHold, hold.HoldID))
+	if err != nil {
+		s.log.Error("failed to release limit hold", zap.Int64("facility_limit_id", hold.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// checkHoldCapturable rejects capturing a hold that is no longer active or
+// has passed its expiry but was not swept yet.
+func checkHoldCapturable(hold *model.LimitHold, now time.Time) error {
+	switch {
+	case hold.Status != model.HoldStatusActive:
+		return errorx.NewError(errorx.ErrHoldNotValid, "hold has already been "+hold.Status, nil)
+	case now.After(hold.ExpiresAt):
+		return errorx.NewError(errorx.ErrHoldNotValid, "hold has expired, place a new hold", nil)
+	}
+
+	return nil
+}
+
+func toHoldResponse(hold *model.LimitHold) *model.HoldResponse {
+	return &model.HoldResponse{
+		HoldID:          hold.HoldID,
+		UserID:          hold.UserID,
+		FacilityLimitID: hold.FacilityLimitID,
+		Amount:          hold.Amount,
+		Reference:       hold.Reference,
+		Status:          hold.Status,
+		UserFacilityID:  hold.UserFacilityID,
+		ApplicationID:   hold.ApplicationID,
+		ExpiresAt:       hold.ExpiresAt.Format(time.RFC3339),
+	}
+}
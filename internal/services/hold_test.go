@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/auth"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupHoldService() (HoldService, *MockUserRepo, *MockLimitRepo, *MockHoldRepo, *MockTrx) {
+	userRepo := new(MockUserRepo)
+	limitRepo := new(MockLimitRepo)
+	holdRepo := new(MockHoldRepo)
+	trx := new(MockTrx)
+	svc := NewHoldService(userRepo, limitRepo, holdRepo, 15*time.Minute, logger.NewNop(), trx)
+
+	return svc, userRepo, limitRepo, holdRepo, trx
+}
+
+func TestHoldService_Hold(t *testing.T) {
+	req := &model.HoldRequest{UserID: 1, FacilityLimitID: 10, Amount: 3000000, Reference: "order-1"}
+	activeLimit := &model.UserFacilityLimit{
+		FacilityLimitID: 10,
+		UserID:          1,
+		ProductID:       1,
+		LimitAmount:     decimal.NewFromInt(5000000),
+		Status:          model.LimitStatusActive,
+		ValidUntil:      time.Now().AddDate(1, 0, 0),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, userRepo, limitRepo, holdRepo, trx := setupHoldService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(activeLimit, nil).Once()
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holdRepo.On("Add", txCtx, mock.MatchedBy(func(h *model.LimitHold) bool {
+			return h.Status == model.HoldStatusActive && h.Amount.IntPart() == req.Amount &&
+				h.ExpiresAt.Sub(h.CreatedAt) == 15*time.Minute
+		})).Return(4, nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerHold && e.Amount.Equal(decimal.NewFromInt(-req.Amount)) &&
+				*e.ReferenceType == model.ReferenceLimitHold && *e.ReferenceID == 4
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Hold(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), res.HoldID)
+		assert.Equal(t, model.HoldStatusActive, res.Status)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error insufficient limit", func(t *testing.T) {
+		svc, userRepo, limitRepo, holdRepo, trx := setupHoldService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(activeLimit, nil).Once()
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holdRepo.On("Add", txCtx, mock.Anything).Return(4, nil).Once()
+		limitRepo.On("Post", txCtx, mock.Anything).Return(errorx.NewError(errorx.ErrInsufficientLimit, "limit balance is not enough", nil)).Once()
+
+		res, err := svc.Hold(ctx, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "insufficient limit amount: limit balance is not enough", err.Error())
+		trx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("error frozen limit", func(t *testing.T) {
+		svc, userRepo, limitRepo, _, trx := setupHoldService()
+
+		frozen := *activeLimit
+		frozen.Status = model.LimitStatusFrozen
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(&frozen, nil).Once()
+
+		res, err := svc.Hold(context.Background(), req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "limit not available: limit is frozen", err.Error())
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+}
+
+func TestHoldService_Release(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, _, limitRepo, holdRepo, trx := setupHoldService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		hold := &model.LimitHold{HoldID: 4, FacilityLimitID: 10, Amount: decimal.NewFromInt(3000000), Status: model.HoldStatusActive}
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holdRepo.On("Get", txCtx, 4).Return(hold, nil).Once()
+		holdRepo.On("Resolve", txCtx, mock.MatchedBy(func(h *model.LimitHold) bool {
+			return h.Status == model.HoldStatusReleased && h.ResolvedAt != nil
+		})).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerRelease && e.Amount.Equal(decimal.NewFromInt(3000000)) && *e.ReferenceID == 4
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Release(ctx, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, model.HoldStatusReleased, res.Status)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error already captured", func(t *testing.T) {
+		svc, _, limitRepo, holdRepo, trx := setupHoldService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holdRepo.On("Get", txCtx, 4).Return(&model.LimitHold{HoldID: 4, Status: model.HoldStatusCaptured}, nil).Once()
+
+		res, err := svc.Release(ctx, 4)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "limit hold not valid: hold has already been captured", err.Error())
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error hold of another user", func(t *testing.T) {
+		svc, _, limitRepo, holdRepo, trx := setupHoldService()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", UserID: 2, Role: auth.RoleCustomer})
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holdRepo.On("Get", txCtx, 4).Return(&model.LimitHold{HoldID: 4, UserID: 1, Status: model.HoldStatusActive}, nil).Once()
+
+		res, err := svc.Release(ctx, 4)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: limit hold not found", err.Error())
+		holdRepo.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHoldService_ExpireHolds(t *testing.T) {
+	svc, _, limitRepo, holdRepo, trx := setupHoldService()
+	ctx := context.Background()
+	now := time.Now()
+
+	expired := []*model.LimitHold{
+		{HoldID: 1, FacilityLimitID: 10, Amount: decimal.NewFromInt(1000000), Status: model.HoldStatusActive},
+		{HoldID: 2, FacilityLimitID: 11, Amount: decimal.NewFromInt(2000000), Status: model.HoldStatusActive},
+	}
+	holdRepo.On("ListExpired", ctx, now, expireBatchSize).Return(expired, nil).Once()
+	trx.On("Begin", mock.Anything).Return(ctx, nil)
+	trx.On("Rollback", mock.Anything).Return(nil)
+	trx.On("Commit", mock.Anything).Return(nil)
+	holdRepo.On("Resolve", mock.Anything, mock.MatchedBy(func(h *model.LimitHold) bool { return h.HoldID == 1 })).Return(nil).Once()
+	holdRepo.On("Resolve", mock.Anything, mock.MatchedBy(func(h *model.LimitHold) bool { return h.HoldID == 2 })).
+		Return(errorx.NewError(errorx.ErrTypeConflict, "limit hold is no longer active", nil)).Once()
+	limitRepo.On("Post", mock.Anything, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+		return e.FacilityLimitID == 10 && e.EntryType == model.LedgerRelease
+	})).Return(nil).Once()
+
+	count, err := svc.ExpireHolds(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, model.HoldStatusExpired, expired[0].Status)
+	trx.AssertNumberOfCalls(t, "Commit", 1)
+	limitRepo.AssertExpectations(t)
+
+	t.Run("error list", func(t *testing.T) {
+		holdRepo.On("ListExpired", ctx, now, expireBatchSize).Return(nil, errors.New("db error")).Once()
+
+		count, err := svc.ExpireHolds(ctx, now)
+		assert.Error(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"finance/internal/calendar"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type HolidayService interface {
+	List(ctx context.Context, req *model.ListHolidaysRequest) ([]*model.HolidayResponse, error)
+	Create(ctx context.Context, req *model.HolidayRequest) (*model.HolidayResponse, error)
+	Delete(ctx context.Context, id int) error
+	Import(ctx context.Context, entries []calendar.Entry) (*model.ImportHolidaysResponse, error)
+}
+
+type holidayService struct {
+	holidayRepo repository.HolidayRepository
+	log         *logger.Logger
+	trx         postgres.Trx
+}
+
+func NewHolidayService(holidayRepo repository.HolidayRepository, log *logger.Logger, trx postgres.Trx) HolidayService {
+	return &holidayService{
+		holidayRepo: holidayRepo,
+		log:         log,
+		trx:         trx,
+	}
+}
+
+func (s *holidayService) List(ctx context.Context, req *model.ListHolidaysRequest) ([]*model.HolidayResponse, error) {
+	year := req.Year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	holidays, err := s.holidayRepo.List(ctx, from, to)
+	if err != nil {
+		s.log.Error("failed to get list holidays", zap.Int("year", year), zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.HolidayResponse{}
+	for _, holiday := range holidays {
+		response = append(response, toHolidayResponse(holiday))
+	}
+
+	return response, nil
+}
+
+func (s *holidayService) Create(ctx context.Context, req *model.HolidayRequest) (*model.HolidayResponse, error) {
+	date, err := time.Parse("2006-01-02", req.HolidayDate)
+	if err != nil {
+		return nil, errorx.NewValidationError(map[string]string{"holiday_date": "invalid date format, use YYYY-MM-DD"})
+	}
+
+	holiday := &model.Holiday{
+		HolidayDate: date,
+		Name:        req.Name,
+		CreatedAt:   time.Now(),
+	}
+
+	id, err := s.holidayRepo.Add(ctx, holiday)
+	if err != nil {
+		s.log.Error("failed to insert holiday", zap.Error(err))
+		return nil, err
+	}
+	holiday.HolidayID = int64(id)
+
+	return toHolidayResponse(holiday), nil
+}
+
+func (s *holidayService) Delete(ctx context.Context, id int) error {
+	err := s.holidayRepo.Delete(ctx, id)
+	if err != nil {
+		s.log.Error("failed to delete holiday", zap.Int("holiday_id", id), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Import stores every entry of a calendar file, renaming holidays that are
+// already stored on the same date, so the same file can be loaded again.
+func (s *holidayService) Import(ctx context.Context, entries []calendar.Entry) (*model.ImportHolidaysResponse, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	now := time.Now()
+	for _, entry := range entries {
+		err = s.holidayRepo.Upsert(txCtx, &model.Holiday{
+			HolidayDate: entry.Date,
+			Name:        entry.Name,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			s.log.Error("failed to import holiday", zap.Time("holiday_date", entry.Date), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return &model.ImportHolidaysResponse{Imported: len(entries)}, nil
+}
+
+// loadCalendar builds the business day calendar for due dates between from
+// and to. The range is widened by a week on both sides, and again as long as
+// a due date on the edge rolls past it, so a long run of holidays such as
+// cuti bersama is rolled over in full.
+func loadCalendar(ctx context.Context, holidayRepo repository.HolidayRepository, from, to time.Time) (*calendar.Calendar, error) {
+	lo, hi := from.AddDate(0, 0, -7), to.AddDate(0, 0, 7)
+	dates, err := listHolidayDates(ctx, holidayRepo, lo, hi, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		cal := calendar.New(dates)
+		first := cal.Adjust(from, calendar.RulePreceding)
+		last := cal.Adjust(to, calendar.RuleFollowing)
+		if !first.Before(lo) && !last.After(hi) {
+			return cal, nil
+		}
+
+		if first.Before(lo) {
+			dates, err = listHolidayDates(ctx, holidayRepo, first.AddDate(0, 0, -7), lo.AddDate(0, 0, -1), dates)
+			if err != nil {
+				return nil, err
+			}
+			lo = first.AddDate(0, 0, -7)
+		}
+		if last.After(hi) {
+			dates, err = listHolidayDates(ctx, holidayRepo, hi.AddDate(0, 0, 1), last.AddDate(0, 0, 7), dates)
+			if err != nil {
+				return nil, err
+			}
+			hi = last.AddDate(0, 0, 7)
+		}
+	}
+}
+
+func listHolidayDates(ctx context.Context, holidayRepo repository.HolidayRepository, from, to time.Time, dates []time.Time) ([]time.Time, error) {
+	holidays, err := holidayRepo.List(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, holiday := range holidays {
+		dates = append(dates, holiday.HolidayDate)
+	}
+
+	return dates, nil
+}
+
+func toHolidayResponse(holiday *model.Holiday) *model.HolidayResponse {
+	return &model.HolidayResponse{
+		HolidayID:   holiday.HolidayID,
+		HolidayDate: holiday.HolidayDate.Format("2006-01-02"),
+		Name:        holiday.Name,
+	}
+}
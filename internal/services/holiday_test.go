@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/calendar"
+	"finance/internal/model"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupHolidayService() (HolidayService, *MockHolidayRepo, *MockTrx) {
+	holidayRepo := new(MockHolidayRepo)
+	trx := new(MockTrx)
+	return NewHolidayService(holidayRepo, logger.NewNop(), trx), holidayRepo, trx
+}
+
+func TestHolidayService_List(t *testing.T) {
+	svc, holidayRepo, _ := setupHolidayService()
+	ctx := context.Background()
+
+	from := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC)
+	holidayRepo.On("List", mock.Anything, from, to).Return([]*model.Holiday{
+		{HolidayID: 1, HolidayDate: time.Date(2027, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan RI"},
+	}, nil).Once()
+
+	res, err := svc.List(ctx, &model.ListHolidaysRequest{Year: 2027})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "2027-08-17", res[0].HolidayDate)
+}
+
+func TestHolidayService_Import(t *testing.T) {
+	entries := []calendar.Entry{
+		{Date: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), Name: "Tahun Baru Masehi"},
+		{Date: time.Date(2027, 8, 17, 0, 0, 0, 0, time.UTC), Name: "Hari Kemerdekaan RI"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, holidayRepo, trx := setupHolidayService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holidayRepo.On("Upsert", txCtx, mock.AnythingOfType("*model.Holiday")).Return(nil).Twice()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Import(ctx, entries)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, res.Imported)
+		holidayRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("error rolls back", func(t *testing.T) {
+		svc, holidayRepo, trx := setupHolidayService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		holidayRepo.On("Upsert", txCtx, mock.Anything).Return(errors.New("db error")).Once()
+
+		res, err := svc.Import(ctx, entries)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		trx.AssertNotCalled(t, "Commit", txCtx)
+	})
+}
+
+func TestLoadCalendar(t *testing.T) {
+	t.Run("widens the range until a long run of holidays is rolled over", func(t *testing.T) {
+		holidayRepo := new(MockHolidayRepo)
+		ctx := context.Background()
+
+		day := func(d int) time.Time { return time.Date(2027, 4, d, 0, 0, 0, 0, time.UTC) }
+		holidays := func(days ...int) []*model.Holiday {
+			list := []*model.Holiday{}
+			for _, d := range days {
+				list = append(list, &model.Holiday{HolidayDate: day(d), Name: "Cuti Bersama"})
+			}
+			return list
+		}
+
+		holidayRepo.On("List", ctx, time.Date(2027, 3, 25, 0, 0, 0, 0, time.UTC), day(8)).Return(holidays(1, 2, 5, 6, 7, 8), nil).Once()
+		holidayRepo.On("List", ctx, day(9), day(16)).Return(holidays(9, 12), nil).Once()
+
+		cal, err := loadCalendar(ctx, holidayRepo, day(1), day(1))
+		assert.NoError(t, err)
+		assert.Equal(t, day(13), cal.Adjust(day(1), calendar.RuleFollowing))
+		holidayRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		holidayRepo := new(MockHolidayRepo)
+		ctx := context.Background()
+
+		holidayRepo.On("List", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		cal, err := loadCalendar(ctx, holidayRepo, time.Now(), time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, cal)
+	})
+}
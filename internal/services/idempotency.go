@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLength matches the idempotency_keys.key column.
+const maxIdempotencyKeyLength = 255
+
+// applicationOperation is what an idempotency key sent to the application
+// endpoints is matched against. Naming the operation keeps a key used to
+// create an application from being replayed as the submission of one.
+type applicationOperation struct {
+	Operation     string                    `json:"operation"`
+	ApplicationID int                       `json:"application_id,omitempty"`
+	Request       *model.ApplicationRequest `json:"request,omitempty"`
+}
+
+// checkIdempotencyKey rejects a key that does not fit its column.
+func checkIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return errorx.NewValidationError(map[string]string{"Idempotency-Key": "must be at most 255 characters long"})
+	}
+
+	return nil
+}
+
+// replaySubmission returns the stored response of an earlier submission made
+// with the same idempotency key, or nil when there is none. A key reused with
+// a different body is rejected.
+func (s *service) replaySubmission(ctx context.Context, req *model.SubmitFinancingRequest) (*model.SubmitFinancingResponse, error) {
+	var response model.SubmitFinancingResponse
+	found, err := s.replayResponse(ctx, req.UserID, req.IdempotencyKey, requestHash(req), &response)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// claimIdempotencyKey locks the key for the rest of the transaction. A
+// submission still holding the lock is in flight, so the duplicate is turned
+// away; one that committed in the meantime is replayed.
+func (s *service) claimIdempotencyKey(txCtx context.Context, req *model.SubmitFinancingRequest) (*model.SubmitFinancingResponse, error) {
+	err := s.lockIdempotencyKey(txCtx, req.UserID, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replaySubmission(txCtx, req)
+}
+
+// saveSubmission stores the response under the idempotency key in the same
+// transaction as the facility, so the key is only taken once the facility is
+// booked.
+func (s *service) saveSubmission(txCtx context.Context, req *model.SubmitFinancingRequest, response *model.SubmitFinancingResponse) error {
+	return s.saveResponse(txCtx, &model.IdempotencyRecord{
+		UserID:         req.UserID,
+		Key:            req.IdempotencyKey,
+		RequestHash:    requestHash(req),
+		UserFacilityID: &response.UserFacilityID,
+	}, response)
+}
+
+// replayApplication returns the stored response of an earlier request to the
+// application endpoints made with the same idempotency key, or nil when there
+// is none.
+func (s *service) replayApplication(ctx context.Context, userID int64, key string, op applicationOperation) (*model.ApplicationResponse, error) {
+	var response model.ApplicationResponse
+	found, err := s.replayResponse(ctx, userID, key, requestHash(op), &response)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// claimApplicationKey locks the key for the rest of the transaction, the same
+// way claimIdempotencyKey does for a submission.
+func (s *service) claimApplicationKey(txCtx context.Context, userID int64, key string, op applicationOperation) (*model.ApplicationResponse, error) {
+	err := s.lockIdempotencyKey(txCtx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replayApplication(txCtx, userID, key, op)
+}
+
+// saveApplication stores the response under the idempotency key in the same
+// transaction as the change to the application.
+func (s *service) saveApplication(txCtx context.Context, key string, op applicationOperation, response *model.ApplicationResponse) error {
+	return s.saveResponse(txCtx, &model.IdempotencyRecord{
+		UserID:        response.UserID,
+		Key:           key,
+		RequestHash:   requestHash(op),
+		ApplicationID: &response.ApplicationID,
+	}, response)
+}
+
+// replayResponse decodes into response the outcome stored under the key and
+// reports whether there was one. A key reused with a different request is
+// rejected.
+func (s *service) replayResponse(ctx context.Context, userID int64, key, hash string, response any) (bool, error) {
+	record, err := s.idempotencyRepo.Get(ctx, userID, key)
+	if err != nil {
+		var appErr *errorx.AppError
+		if errors.As(err, &appErr) && appErr.Type == errorx.ErrTypeNotFound {
+			return false, nil
+		}
+		s.log.Error("failed to get idempotency key", zap.Int64("user_id", userID), zap.Error(err))
+		return false, err
+	}
+
+	if record.RequestHash != hash {
+		return false, errorx.NewError(errorx.ErrIdempotencyReused, "idempotency key was already used with a different request", nil)
+	}
+
+	err = json.Unmarshal(record.Response, response)
+	if err != nil {
+		s.log.Error("failed to decode stored response", zap.Int64("user_id", userID), zap.Error(err))
+		return false, errorx.NewError(errorx.ErrTypeInternal, "failed to replay stored response", err)
+	}
+
+	return true, nil
+}
+
+// lockIdempotencyKey takes the key for the rest of the transaction, turning
+// away a duplicate while the request holding it is in flight.
+func (s *service) lockIdempotencyKey(txCtx context.Context, userID int64, key string) error {
+	locked, err := s.idempotencyRepo.Lock(txCtx, userID, key)
+	if err != nil {
+		s.log.Error("failed to lock idempotency key", zap.Int64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	if !locked {
+		return errorx.NewError(errorx.ErrTypeConflict, "a request with this idempotency key is still in progress", nil)
+	}
+
+	return nil
+}
+
+func (s *service) saveResponse(txCtx context.Context, record *model.IdempotencyRecord, response any) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return errorx.NewError(errorx.ErrTypeInternal, "failed to store response", err)
+	}
+
+	record.Response = raw
+	record.CreatedAt = time.Now()
+	err = s.idempotencyRepo.Add(txCtx, record)
+	if err != nil {
+		s.log.Error("failed to store idempotency key", zap.Int64("user_id", record.UserID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// requestHash fingerprints the request body. The idempotency key itself is
+// not part of the JSON form.
+func requestHash(req any) string {
+	raw, _ := json.Marshal(req)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"finance/internal/model"
+	"finance/internal/pricing"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupIdempotentService() (Service, *MockUserRepo, *MockLimitRepo, *MockTenorRepo, *MockFacilityRepo, *MockDetailRepo, *MockIdempotencyRepo, *MockTrx) {
+	userRepo := new(MockUserRepo)
+	limitRepo := new(MockLimitRepo)
+	tenorRepo := new(MockTenorRepo)
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	idempotencyRepo := new(MockIdempotencyRepo)
+	trx := new(MockTrx)
+
+	svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), newProductRepo(), tenorRepo, facilityRepo, detailRepo, idempotencyRepo, nil, newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+	return svc, userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, idempotencyRepo, trx
+}
+
+func TestService_Submit_Idempotency(t *testing.T) {
+	newReq := func() *model.SubmitFinancingRequest {
+		return &model.SubmitFinancingRequest{
+			UserID:          1,
+			FacilityLimitID: 10,
+			Amount:          10000000,
+			Tenor:           12,
+			StartDate:       time.Now().Format("2006-01-02"),
+			IdempotencyKey:  "order-42",
+		}
+	}
+	notFound := errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)
+	stored := &model.SubmitFinancingResponse{UserFacilityID: 7, UserID: 1, FacilityLimitID: 10, Amount: decimal.NewFromInt(10000000), Tenor: 12}
+	storedRecord := func(req *model.SubmitFinancingRequest) *model.IdempotencyRecord {
+		raw, _ := json.Marshal(stored)
+		facilityID := int64(7)
+		return &model.IdempotencyRecord{UserID: 1, Key: "order-42", RequestHash: requestHash(req), Response: raw, UserFacilityID: &facilityID}
+	}
+
+	t.Run("first submission stores the response", func(t *testing.T) {
+		svc, userRepo, limitRepo, tenorRepo, facilityRepo, detailRepo, idempotencyRepo, trx := setupIdempotentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+		req := newReq()
+
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(nil, notFound).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(&model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000), ValidUntil: time.Now().AddDate(1, 0, 0)}, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(&model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}, nil).Once()
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		idempotencyRepo.On("Lock", txCtx, int64(1), "order-42").Return(true, nil).Once()
+		idempotencyRepo.On("Get", txCtx, int64(1), "order-42").Return(nil, notFound).Once()
+		facilityRepo.On("Add", txCtx, mock.Anything).Return(7, nil).Once()
+		detailRepo.On("Add", txCtx, mock.Anything).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.Anything).Return(nil).Once()
+		idempotencyRepo.On("Add", txCtx, mock.MatchedBy(func(r *model.IdempotencyRecord) bool {
+			var response model.SubmitFinancingResponse
+			return json.Unmarshal(r.Response, &response) == nil && response.UserFacilityID == 7 &&
+				*r.UserFacilityID == 7 && r.Key == "order-42" && r.RequestHash == requestHash(req)
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), res.UserFacilityID)
+		idempotencyRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("replay returns the stored response", func(t *testing.T) {
+		svc, userRepo, _, _, facilityRepo, _, idempotencyRepo, trx := setupIdempotentService()
+		ctx := context.Background()
+		req := newReq()
+
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(storedRecord(req), nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), res.UserFacilityID)
+		assert.Equal(t, "10000000", res.Amount.String())
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		facilityRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("error key reused with another body", func(t *testing.T) {
+		svc, _, _, _, facilityRepo, _, idempotencyRepo, trx := setupIdempotentService()
+		ctx := context.Background()
+		req := newReq()
+		record := storedRecord(req)
+		req.Amount = 5000000
+
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(record, nil).Once()
+
+		res, err := svc.Submit(ctx, req)
+		assert.Nil(t, res)
+		assert.Equal(t, "idempotency key reused: idempotency key was already used with a different request", err.Error())
+		facilityRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("in-flight and committed duplicates", func(t *testing.T) {
+		cases := map[string]struct {
+			locked  bool
+			lockErr error
+			record  bool
+			wantErr string
+		}{
+			"in flight":           {locked: false, wantErr: "resource already exists: a request with this idempotency key is still in progress"},
+			"committed meanwhile": {locked: true, record: true},
+			"lock error":          {lockErr: errors.New("db down"), wantErr: "db down"},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				svc, userRepo, limitRepo, tenorRepo, facilityRepo, _, idempotencyRepo, trx := setupIdempotentService()
+				ctx := context.Background()
+				txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+				req := newReq()
+
+				idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(nil, notFound).Once()
+				userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+				limitRepo.On("GetByID", mock.Anything, 10).Return(&model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000), ValidUntil: time.Now().AddDate(1, 0, 0)}, nil).Once()
+				tenorRepo.On("Get", mock.Anything, 1, 12, mock.Anything).Return(&model.Tenor{TenorID: 1, ProductID: 1, TenorValue: 12, Rate: decimal.RequireFromString("0.20")}, nil).Once()
+				trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+				trx.On("Rollback", mock.Anything).Return(nil).Once()
+				idempotencyRepo.On("Lock", txCtx, int64(1), "order-42").Return(c.locked, c.lockErr).Once()
+				if c.record {
+					idempotencyRepo.On("Get", txCtx, int64(1), "order-42").Return(storedRecord(req), nil).Once()
+				}
+
+				res, err := svc.Submit(ctx, req)
+				if c.wantErr != "" {
+					assert.Nil(t, res)
+					assert.Equal(t, c.wantErr, err.Error())
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, int64(7), res.UserFacilityID)
+				}
+				facilityRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+				trx.AssertNotCalled(t, "Commit", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("error key too long", func(t *testing.T) {
+		svc, _, _, _, _, _, idempotencyRepo, _ := setupIdempotentService()
+		req := newReq()
+		req.IdempotencyKey = string(make([]byte, 256))
+
+		res, err := svc.Submit(context.Background(), req)
+		assert.Nil(t, res)
+		assert.Error(t, err)
+		idempotencyRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Application_Idempotency(t *testing.T) {
+	setup := func() (Service, *MockUserRepo, *MockLimitRepo, *MockTenorRepo, *MockApplicationRepo, *MockIdempotencyRepo, *MockTrx) {
+		userRepo := new(MockUserRepo)
+		limitRepo := new(MockLimitRepo)
+		tenorRepo := new(MockTenorRepo)
+		applicationRepo := new(MockApplicationRepo)
+		idempotencyRepo := new(MockIdempotencyRepo)
+		trx := new(MockTrx)
+		svc := NewService(userRepo, newKYCRepo(), limitRepo, newHoldRepo(), newProductRepo(), tenorRepo, new(MockFacilityRepo), new(MockDetailRepo), idempotencyRepo, applicationRepo, newDisbursementRepo(), newOutboxRepo(), newHolidayRepo(), pricing.NewFlat(), pricing.Rounding{Strategy: pricing.RemainderLast}, logger.NewNop(), trx)
+
+		return svc, userRepo, limitRepo, tenorRepo, applicationRepo, idempotencyRepo, trx
+	}
+	notFound := errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)
+	newReq := func() *model.ApplicationRequest {
+		return &model.ApplicationRequest{UserID: 1, FacilityLimitID: 10, Amount: 5000000, Tenor: 6, IdempotencyKey: "order-42"}
+	}
+	limit := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(20000000),
+		Status: model.LimitStatusActive, ValidUntil: time.Now().AddDate(1, 0, 0)}
+	storedRecord := func(op applicationOperation, response *model.ApplicationResponse) *model.IdempotencyRecord {
+		raw, _ := json.Marshal(response)
+		return &model.IdempotencyRecord{UserID: 1, Key: "order-42", RequestHash: requestHash(op), Response: raw, ApplicationID: &response.ApplicationID}
+	}
+
+	t.Run("first create stores the draft", func(t *testing.T) {
+		svc, userRepo, limitRepo, tenorRepo, applicationRepo, idempotencyRepo, trx := setup()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(nil, notFound).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(limit, nil).Once()
+		tenorRepo.On("Get", mock.Anything, 1, 6, mock.Anything).Return(&model.Tenor{ProductID: 1, TenorValue: 6}, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		idempotencyRepo.On("Lock", txCtx, int64(1), "order-42").Return(true, nil).Once()
+		idempotencyRepo.On("Get", txCtx, int64(1), "order-42").Return(nil, notFound).Once()
+		applicationRepo.On("Add", txCtx, mock.Anything).Return(3, nil).Once()
+		idempotencyRepo.On("Add", txCtx, mock.MatchedBy(func(r *model.IdempotencyRecord) bool {
+			var response model.ApplicationResponse
+			return json.Unmarshal(r.Response, &response) == nil && response.ApplicationID == 3 &&
+				*r.ApplicationID == 3 && r.UserFacilityID == nil && r.RequestHash == requestHash(applicationOperation{Operation: "create", Request: newReq()})
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.CreateApplication(ctx, newReq())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), res.ApplicationID)
+		idempotencyRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("retried create returns the same draft", func(t *testing.T) {
+		svc, userRepo, _, _, applicationRepo, idempotencyRepo, trx := setup()
+		ctx := context.Background()
+		op := applicationOperation{Operation: "create", Request: newReq()}
+
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(storedRecord(op, &model.ApplicationResponse{ApplicationID: 3, UserID: 1, Status: model.ApplicationStatusDraft}), nil).Once()
+
+		res, err := svc.CreateApplication(ctx, newReq())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), res.ApplicationID)
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		applicationRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("retried submit returns the original response without holding again", func(t *testing.T) {
+		svc, _, limitRepo, _, applicationRepo, idempotencyRepo, trx := setup()
+		ctx := context.Background()
+		op := applicationOperation{Operation: "submit", ApplicationID: 3}
+
+		applicationRepo.On("Get", ctx, 3).Return(&model.FinancingApplication{ApplicationID: 3, UserID: 1, FacilityLimitID: 10,
+			Amount: decimal.NewFromInt(5000000), Status: model.ApplicationStatusSubmitted}, nil).Once()
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(storedRecord(op, &model.ApplicationResponse{ApplicationID: 3, UserID: 1, Status: model.ApplicationStatusSubmitted}), nil).Once()
+
+		res, err := svc.SubmitApplication(ctx, 3, "order-42")
+		assert.NoError(t, err)
+		assert.Equal(t, model.ApplicationStatusSubmitted, res.Status)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("first submit stores the response", func(t *testing.T) {
+		svc, userRepo, limitRepo, _, applicationRepo, idempotencyRepo, trx := setup()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		applicationRepo.On("Get", ctx, 3).Return(&model.FinancingApplication{ApplicationID: 3, UserID: 1, FacilityLimitID: 10,
+			Amount: decimal.NewFromInt(5000000), Status: model.ApplicationStatusDraft}, nil).Once()
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(nil, notFound).Once()
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		limitRepo.On("GetByID", mock.Anything, 10).Return(limit, nil).Once()
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+		idempotencyRepo.On("Lock", txCtx, int64(1), "order-42").Return(true, nil).Once()
+		idempotencyRepo.On("Get", txCtx, int64(1), "order-42").Return(nil, notFound).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool { return e.EntryType == model.LedgerHold })).Return(nil).Once()
+		applicationRepo.On("Transition", txCtx, mock.Anything, model.ApplicationStatusDraft).Return(nil).Once()
+		idempotencyRepo.On("Add", txCtx, mock.MatchedBy(func(r *model.IdempotencyRecord) bool {
+			var response model.ApplicationResponse
+			return json.Unmarshal(r.Response, &response) == nil && response.Status == model.ApplicationStatusSubmitted && *r.ApplicationID == 3
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.SubmitApplication(ctx, 3, "order-42")
+		assert.NoError(t, err)
+		assert.Equal(t, model.ApplicationStatusSubmitted, res.Status)
+		idempotencyRepo.AssertExpectations(t)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error create key reused to submit", func(t *testing.T) {
+		svc, _, limitRepo, _, applicationRepo, idempotencyRepo, _ := setup()
+		ctx := context.Background()
+		op := applicationOperation{Operation: "create", Request: newReq()}
+
+		applicationRepo.On("Get", ctx, 3).Return(&model.FinancingApplication{ApplicationID: 3, UserID: 1, Status: model.ApplicationStatusDraft}, nil).Once()
+		idempotencyRepo.On("Get", ctx, int64(1), "order-42").Return(storedRecord(op, &model.ApplicationResponse{ApplicationID: 3, UserID: 1}), nil).Once()
+
+		res, err := svc.SubmitApplication(ctx, 3, "order-42")
+		assert.Nil(t, res)
+		assert.Equal(t, "idempotency key reused: idempotency key was already used with a different request", err.Error())
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/internal/nik"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type KYCService interface {
+	Get(ctx context.Context, userID int) (*model.KYCResponse, error)
+	List(ctx context.Context, req *model.ListKYCRequest) ([]*model.KYCResponse, error)
+	Submit(ctx context.Context, userID int, req *model.KYCRequest) (*model.KYCResponse, error)
+	Verify(ctx context.Context, userID int, req *model.ReviewKYCRequest) (*model.KYCResponse, error)
+	Reject(ctx context.Context, userID int, req *model.ReviewKYCRequest) (*model.KYCResponse, error)
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+type kycService struct {
+	userRepo       repository.UserRepository
+	kycRepo        repository.KYCRepository
+	validityMonths int
+	log            *logger.Logger
+}
+
+func NewKYCService(userRepo repository.UserRepository, kycRepo repository.KYCRepository, validityMonths int, log *logger.Logger) KYCService {
+	return &kycService{
+		userRepo:       userRepo,
+		kycRepo:        kycRepo,
+		validityMonths: validityMonths,
+		log:            log,
+	}
+}
+
+func (s *kycService) Get(ctx context.Context, userID int) (*model.KYCResponse, error) {
+	kyc, err := s.kycRepo.Get(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get kyc", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return toKYCResponse(kyc), nil
+}
+
+func (s *kycService) List(ctx context.Context, req *model.ListKYCRequest) ([]*model.KYCResponse, error) {
+	kycs, err := s.kycRepo.List(ctx, req.Status)
+	if err != nil {
+		s.log.Error("failed to get list kyc", zap.String("status", req.Status), zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.KYCResponse{}
+	for _, kyc := range kycs {
+		response = append(response, toKYCResponse(kyc))
+	}
+
+	return response, nil
+}
+
+// Submit records the identity of the user for review. A rejected or expired
+// verification can be submitted again; a pending or verified one cannot.
+func (s *kycService) Submit(ctx context.Context, userID int, req *model.KYCRequest) (*model.KYCResponse, error) {
+	now := time.Now()
+	id, err := nik.Parse(req.NIK, now)
+	if err != nil {
+		return nil, errorx.NewValidationError(map[string]string{"nik": nik.Hint})
+	}
+
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkUserActive(user)
+	if err != nil {
+		return nil, err
+	}
+
+	kyc := &model.KYC{
+		UserID:      user.UserID,
+		NIK:         id.Number,
+		FullName:    req.FullName,
+		BirthDate:   id.BirthDate,
+		Status:      model.KYCStatusPending,
+		SubmittedAt: now,
+	}
+
+	kycID, err := s.kycRepo.Submit(ctx, kyc)
+	if err != nil {
+		s.log.Error("failed to submit kyc", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	kyc.KYCID = int64(kycID)
+
+	return toKYCResponse(kyc), nil
+}
+
+// Verify approves a pending verification. It stays valid for the configured
+// number of months, after which the user has to submit it again.
+func (s *kycService) Verify(ctx context.Context, userID int, req *model.ReviewKYCRequest) (*model.KYCResponse, error) {
+	return s.review(ctx, userID, req, model.KYCStatusVerified)
+}
+
+func (s *kycService) Reject(ctx context.Context, userID int, req *model.ReviewKYCRequest) (*model.KYCResponse, error) {
+	if req.Reason == "" {
+		return nil, errorx.NewValidationError(map[string]string{"reason": "is required to reject a kyc"})
+	}
+
+	return s.review(ctx, userID, req, model.KYCStatusRejected)
+}
+
+// Expire marks the verifications that expired at or before now.
+func (s *kycService) Expire(ctx context.Context, now time.Time) (int64, error) {
+	expired, err := s.kycRepo.Expire(ctx, now)
+	if err != nil {
+		s.log.Error("failed to expire kyc", zap.Error(err))
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+func (s *kycService) review(ctx context.Context, userID int, req *model.ReviewKYCRequest, status string) (*model.KYCResponse, error) {
+	kyc, err := s.kycRepo.Get(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get kyc", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	if kyc.Status != model.KYCStatusPending {
+		return nil, errorx.NewError(errorx.ErrTypeConflict, "kyc has already been reviewed", nil)
+	}
+
+	now := time.Now()
+	reviewer := actor(ctx)
+	kyc.Status = status
+	kyc.ReviewedBy = &reviewer
+	kyc.ReviewedAt = &now
+	if status == model.KYCStatusVerified {
+		expiresAt := now.AddDate(0, s.validityMonths, 0)
+		kyc.ExpiresAt = &expiresAt
+	} else {
+		kyc.RejectReason = req.Reason
+	}
+
+	err = s.kycRepo.MarkReviewed(ctx, kyc)
+	if err != nil {
+		s.log.Error("failed to review kyc", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	return toKYCResponse(kyc), nil
+}
+
+// checkKYCVerified rejects lending to a user whose identity has not been
+// verified, including a verification past its expiry that was not marked
+// expired yet.
+func checkKYCVerified(ctx context.Context, kycRepo repository.KYCRepository, userID int64, now time.Time) error {
+	kyc, err := kycRepo.Get(ctx, int(userID))
+	if err != nil {
+		var appErr *errorx.AppError
+		if errors.As(err, &appErr) && appErr.Type == errorx.ErrTypeNotFound {
+			return errorx.NewError(errorx.ErrKYCNotVerified, "user has not submitted kyc", nil)
+		}
+		return err
+	}
+
+	switch {
+	case kyc.Status != model.KYCStatusVerified:
+		return errorx.NewError(errorx.ErrKYCNotVerified, "kyc is "+kyc.Status, nil)
+	case kyc.ExpiresAt != nil && now.After(*kyc.ExpiresAt):
+		return errorx.NewError(errorx.ErrKYCNotVerified, "kyc is "+model.KYCStatusExpired, nil)
+	}
+
+	return nil
+}
+
+func toKYCResponse(kyc *model.KYC) *model.KYCResponse {
+	response := &model.KYCResponse{
+		UserID:       kyc.UserID,
+		NIK:          nik.Mask(kyc.NIK),
+		FullName:     kyc.FullName,
+		Status:       kyc.Status,
+		RejectReason: kyc.RejectReason,
+		SubmittedAt:  kyc.SubmittedAt.Format(time.RFC3339),
+	}
+	if kyc.ReviewedBy != nil {
+		response.ReviewedBy = *kyc.ReviewedBy
+	}
+	if kyc.ReviewedAt != nil {
+		response.ReviewedAt = kyc.ReviewedAt.Format(time.RFC3339)
+	}
+	if kyc.ExpiresAt != nil {
+		response.ExpiresAt = kyc.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return response
+}
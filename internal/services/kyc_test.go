@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"finance/internal/auth"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupKYCService() (KYCService, *MockUserRepo, *MockKYCRepo) {
+	userRepo := new(MockUserRepo)
+	kycRepo := new(MockKYCRepo)
+	svc := NewKYCService(userRepo, kycRepo, 24, logger.NewNop())
+
+	return svc, userRepo, kycRepo
+}
+
+func TestKYCService_Submit(t *testing.T) {
+	req := &model.KYCRequest{NIK: "3201011708900001", FullName: "Khabib Nurmagomedov"}
+
+	t.Run("success", func(t *testing.T) {
+		svc, userRepo, kycRepo := setupKYCService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		kycRepo.On("Submit", mock.Anything, mock.MatchedBy(func(k *model.KYC) bool {
+			return k.UserID == 1 && k.NIK == req.NIK && k.Status == model.KYCStatusPending &&
+				k.BirthDate.Format("2006-01-02") == "1990-08-17"
+		})).Return(3, nil).Once()
+
+		res, err := svc.Submit(context.Background(), 1, req)
+		assert.NoError(t, err)
+		assert.Equal(t, model.KYCStatusPending, res.Status)
+		assert.Equal(t, "320101********01", res.NIK)
+		kycRepo.AssertExpectations(t)
+	})
+
+	t.Run("error invalid nik", func(t *testing.T) {
+		svc, userRepo, kycRepo := setupKYCService()
+
+		res, err := svc.Submit(context.Background(), 1, &model.KYCRequest{NIK: "3201013002900001", FullName: "Khabib Nurmagomedov"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+
+		var appErr *errorx.AppError
+		assert.ErrorAs(t, err, &appErr)
+		assert.Contains(t, appErr.Fields, "nik")
+		userRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		kycRepo.AssertNotCalled(t, "Submit", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error already verified", func(t *testing.T) {
+		svc, userRepo, kycRepo := setupKYCService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive}, nil).Once()
+		kycRepo.On("Submit", mock.Anything, mock.Anything).
+			Return(0, errorx.NewError(errorx.ErrTypeConflict, "kyc is already pending or verified", nil)).Once()
+
+		res, err := svc.Submit(context.Background(), 1, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: kyc is already pending or verified", err.Error())
+	})
+}
+
+func TestKYCService_Review(t *testing.T) {
+	checker := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "checker", Role: auth.RoleAdmin})
+	pending := func() *model.KYC {
+		return &model.KYC{UserID: 1, NIK: "3201011708900001", Status: model.KYCStatusPending, SubmittedAt: time.Now()}
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		svc, _, kycRepo := setupKYCService()
+
+		kycRepo.On("Get", mock.Anything, 1).Return(pending(), nil).Once()
+		kycRepo.On("MarkReviewed", mock.Anything, mock.MatchedBy(func(k *model.KYC) bool {
+			return k.Status == model.KYCStatusVerified && *k.ReviewedBy == "checker" &&
+				k.ExpiresAt.Sub(*k.ReviewedAt) > 365*24*time.Hour
+		})).Return(nil).Once()
+
+		res, err := svc.Verify(checker, 1, &model.ReviewKYCRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, model.KYCStatusVerified, res.Status)
+		assert.NotEmpty(t, res.ExpiresAt)
+		kycRepo.AssertExpectations(t)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		svc, _, kycRepo := setupKYCService()
+
+		kycRepo.On("Get", mock.Anything, 1).Return(pending(), nil).Once()
+		kycRepo.On("MarkReviewed", mock.Anything, mock.MatchedBy(func(k *model.KYC) bool {
+			return k.Status == model.KYCStatusRejected && k.RejectReason == "blurry photo" && *k.ReviewedBy == "checker" && k.ExpiresAt == nil
+		})).Return(nil).Once()
+
+		res, err := svc.Reject(checker, 1, &model.ReviewKYCRequest{Reason: "blurry photo"})
+		assert.NoError(t, err)
+		assert.Equal(t, model.KYCStatusRejected, res.Status)
+	})
+
+	t.Run("error reject without reason", func(t *testing.T) {
+		svc, _, kycRepo := setupKYCService()
+
+		res, err := svc.Reject(checker, 1, &model.ReviewKYCRequest{})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		kycRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error already reviewed", func(t *testing.T) {
+		svc, _, kycRepo := setupKYCService()
+
+		verified := pending()
+		verified.Status = model.KYCStatusVerified
+		kycRepo.On("Get", mock.Anything, 1).Return(verified, nil).Once()
+
+		res, err := svc.Verify(checker, 1, &model.ReviewKYCRequest{})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: kyc has already been reviewed", err.Error())
+		kycRepo.AssertNotCalled(t, "MarkReviewed", mock.Anything, mock.Anything)
+	})
+}
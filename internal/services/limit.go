@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const defaultHistorySize = 20
+
+type LimitService interface {
+	History(ctx context.Context, userID int, req *model.LimitHistoryRequest) (*model.LimitHistoryResponse, error)
+	DueForReview(ctx context.Context, req *model.LimitReviewRequest) ([]*model.LimitReviewResponse, error)
+}
+
+// LimitTerms sets how long a new or renewed limit stays valid and how many
+// days before it expires it comes up for review.
+type LimitTerms struct {
+	ValidityMonths int
+	ReviewLeadDays int
+}
+
+// period returns the end of validity and the review date of a limit that is
+// valid from the given day.
+func (t LimitTerms) period(from time.Time) (time.Time, time.Time) {
+	validUntil := from.AddDate(0, t.ValidityMonths, 0)
+	return validUntil, validUntil.AddDate(0, 0, -t.ReviewLeadDays)
+}
+
+type limitService struct {
+	userRepo  repository.UserRepository
+	limitRepo repository.LimitRepository
+	log       *logger.Logger
+}
+
+func NewLimitService(userRepo repository.UserRepository, limitRepo repository.LimitRepository, log *logger.Logger) LimitService {
+	return &limitService{
+		userRepo:  userRepo,
+		limitRepo: limitRepo,
+		log:       log,
+	}
+}
+
+// History lists the ledger of one of the user's limits, newest first, together with
+// the cached balance and the balance derived from the whole ledger so any
+// difference between the two stands out during a dispute.
+func (s *limitService) History(ctx context.Context, userID int, req *model.LimitHistoryRequest) (*model.LimitHistoryResponse, error) {
+	size := req.Limit
+	if size == 0 {
+		size = defaultHistorySize
+	}
+
+	_, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	limit, err := s.userLimit(ctx, userID, req.FacilityLimitID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.limitRepo.ListLedger(ctx, int(limit.FacilityLimitID), req.Before, size+1)
+	if err != nil {
+		s.log.Error("failed to get limit ledger", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	ledgerBalance, err := s.limitRepo.LedgerBalance(ctx, int(limit.FacilityLimitID))
+	if err != nil {
+		s.log.Error("failed to get limit ledger balance", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return nil, err
+	}
+
+	if !ledgerBalance.Equal(limit.LimitAmount) {
+		s.log.Warn("limit balance does not match ledger",
+			zap.Int64("facility_limit_id", limit.FacilityLimitID),
+			zap.String("balance", limit.LimitAmount.String()),
+			zap.String("ledger_balance", ledgerBalance.String()))
+	}
+
+	response := &model.LimitHistoryResponse{
+		FacilityLimitID: limit.FacilityLimitID,
+		UserID:          limit.UserID,
+		Balance:         limit.LimitAmount,
+		LedgerBalance:   ledgerBalance,
+		Entries:         []model.LimitLedgerResponse{},
+	}
+
+	if len(entries) > size {
+		entries = entries[:size]
+		response.NextBefore = entries[size-1].EntryID
+	}
+
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, toLimitLedgerResponse(entry))
+	}
+
+	return response, nil
+}
+
+// DueForReview lists the limits whose review date has been reached on the
+// requested day, today by default, including the ones that already expired.
+func (s *limitService) DueForReview(ctx context.Context, req *model.LimitReviewRequest) ([]*model.LimitReviewResponse, error) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.Date != "" {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, errorx.NewValidationError(map[string]string{"date": "invalid date format, use YYYY-MM-DD"})
+		}
+		day = date
+	}
+
+	limits, err := s.limitRepo.ListDueForReview(ctx, day)
+	if err != nil {
+		s.log.Error("failed to get limits due for review", zap.Time("date", day), zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.LimitReviewResponse{}
+	for _, limit := range limits {
+		response = append(response, &model.LimitReviewResponse{
+			FacilityLimitID: limit.FacilityLimitID,
+			UserID:          limit.UserID,
+			ProductID:       limit.ProductID,
+			LimitAmount:     limit.LimitAmount,
+			Status:          limit.Status,
+			ValidUntil:      limit.ValidUntil.Format("2006-01-02"),
+			NextReviewDate:  limit.NextReviewDate.Format("2006-01-02"),
+			Expired:         day.After(limit.ValidUntil),
+		})
+	}
+
+	return response, nil
+}
+
+// userLimit picks the requested limit of the user. The limit may only be
+// left out when the user holds a single one.
+func (s *limitService) userLimit(ctx context.Context, userID int, limitID int64) (*model.UserFacilityLimit, error) {
+	limits, err := s.limitRepo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get user limits", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+
+	if limitID == 0 {
+		switch len(limits) {
+		case 0:
+			return nil, errorx.NewError(errorx.ErrTypeNotFound, "user has no facility limit", nil)
+		case 1:
+			return limits[0], nil
+		default:
+			return nil, errorx.NewValidationError(map[string]string{
+				"facility_limit_id": "is required when the user has more than one limit",
+			})
+		}
+	}
+
+	for _, limit := range limits {
+		if limit.FacilityLimitID == limitID {
+			return limit, nil
+		}
+	}
+
+	return nil, errorx.NewError(errorx.ErrTypeNotFound, "facility limit not found for this user", nil)
+}
+
+// checkLimitAvailable rejects drawdowns on a limit that is frozen, closed or
+// outside its validity. The validity dates are inclusive.
+func checkLimitAvailable(limit *model.UserFacilityLimit, now time.Time) error {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	var msg string
+	switch {
+	case limit.Status == model.LimitStatusFrozen:
+		msg = "limit is frozen"
+	case limit.Status == model.LimitStatusClosed:
+		msg = "limit is closed"
+	case today.Before(limit.ValidFrom):
+		msg = "limit is not valid yet"
+	case today.After(limit.ValidUntil):
+		msg = "limit has expired, it must be renewed before drawing on it"
+	}
+
+	if msg != "" {
+		return errorx.NewError(errorx.ErrLimitNotAvail, msg, nil)
+	}
+
+	return nil
+}
+
+func newLedgerEntry(limitID int64, entryType string, amount decimal.Decimal, referenceType string, referenceID int64) *model.LimitLedgerEntry {
+	return &model.LimitLedgerEntry{
+		FacilityLimitID: limitID,
+		EntryType:       entryType,
+		Amount:          amount,
+		ReferenceType:   &referenceType,
+		ReferenceID:     &referenceID,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func toLimitLedgerResponse(entry *model.LimitLedgerEntry) model.LimitLedgerResponse {
+	response := model.LimitLedgerResponse{
+		EntryID:      entry.EntryID,
+		EntryType:    entry.EntryType,
+		Amount:       entry.Amount,
+		BalanceAfter: entry.BalanceAfter,
+		ReferenceID:  entry.ReferenceID,
+		Note:         entry.Note,
+		CreatedAt:    entry.CreatedAt.Format(time.RFC3339),
+	}
+	if entry.ReferenceType != nil {
+		response.ReferenceType = *entry.ReferenceType
+	}
+
+	return response
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type LimitChangeService interface {
+	Propose(ctx context.Context, req *model.LimitChangeRequest) (*model.LimitChangeResponse, error)
+	List(ctx context.Context, req *model.ListLimitChangesRequest) ([]*model.LimitChangeResponse, error)
+	Approve(ctx context.Context, id int, req *model.ReviewLimitChangeRequest) (*model.LimitChangeResponse, error)
+	Reject(ctx context.Context, id int, req *model.ReviewLimitChangeRequest) (*model.LimitChangeResponse, error)
+}
+
+type limitChangeService struct {
+	userRepo    repository.UserRepository
+	limitRepo   repository.LimitRepository
+	productRepo repository.ProductRepository
+	changeRepo  repository.LimitChangeRepository
+	terms       LimitTerms
+	log         *logger.Logger
+	trx         postgres.Trx
+}
+
+func NewLimitChangeService(
+	userRepo repository.UserRepository,
+	limitRepo repository.LimitRepository,
+	productRepo repository.ProductRepository,
+	changeRepo repository.LimitChangeRepository,
+	terms LimitTerms,
+	log *logger.Logger,
+	trx postgres.Trx,
+) LimitChangeService {
+	return &limitChangeService{
+		userRepo:    userRepo,
+		limitRepo:   limitRepo,
+		productRepo: productRepo,
+		changeRepo:  changeRepo,
+		terms:       terms,
+		log:         log,
+		trx:         trx,
+	}
+}
+
+// Propose records a pending lifecycle change of a limit. Nothing is applied
+// until another operator approves it.
+func (s *limitChangeService) Propose(ctx context.Context, req *model.LimitChangeRequest) (*model.LimitChangeResponse, error) {
+	fields := map[string]string{}
+	needsAmount := req.Action == model.LimitActionCreate || req.Action == model.LimitActionRaise || req.Action == model.LimitActionLower
+	if needsAmount && (req.Amount == nil || !req.Amount.IsPositive()) {
+		fields["amount"] = "must be greater than 0"
+	}
+	if !needsAmount && req.Amount != nil {
+		fields["amount"] = "is not allowed for this action"
+	}
+	if req.Action == model.LimitActionCreate {
+		if req.UserID == 0 {
+			fields["user_id"] = "is required to create a limit"
+		}
+		if req.ProductID == 0 {
+			fields["product_id"] = "is required to create a limit"
+		}
+	} else if req.FacilityLimitID == 0 {
+		fields["facility_limit_id"] = "is required for this action"
+	}
+	if len(fields) > 0 {
+		return nil, errorx.NewValidationError(fields)
+	}
+
+	change := &model.LimitChange{
+		Action:      req.Action,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		Status:      model.ChangeStatusPending,
+		RequestedBy: actor(ctx),
+		CreatedAt:   time.Now(),
+	}
+
+	if req.Action == model.LimitActionCreate {
+		err := s.checkNewLimit(ctx, req.UserID, req.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		change.UserID = req.UserID
+		change.ProductID = req.ProductID
+	} else {
+		limit, err := s.limitRepo.GetByID(ctx, int(req.FacilityLimitID))
+		if err != nil {
+			s.log.Error("failed to get facility limit", zap.Int64("facility_limit_id", req.FacilityLimitID), zap.Error(err))
+			return nil, err
+		}
+
+		err = checkLimitAction(req.Action, limit)
+		if err != nil {
+			return nil, err
+		}
+		change.UserID = limit.UserID
+		change.ProductID = limit.ProductID
+		change.FacilityLimitID = &limit.FacilityLimitID
+	}
+
+	id, err := s.changeRepo.Add(ctx, change)
+	if err != nil {
+		s.log.Error("failed to insert limit change", zap.Error(err))
+		return nil, err
+	}
+	change.ChangeID = int64(id)
+
+	return toLimitChangeResponse(change), nil
+}
+
+func (s *limitChangeService) List(ctx context.Context, req *model.ListLimitChangesRequest) ([]*model.LimitChangeResponse, error) {
+	changes, err := s.changeRepo.List(ctx, req.Status)
+	if err != nil {
+		s.log.Error("failed to get list limit changes", zap.String("status", req.Status), zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.LimitChangeResponse{}
+	for _, change := range changes {
+		response = append(response, toLimitChangeResponse(change))
+	}
+
+	return response, nil
+}
+
+// Approve applies a pending change and records its approval in a single
+// transaction, so the limit never moves without an approved change behind it.
+func (s *limitChangeService) Approve(ctx context.Context, id int, req *model.ReviewLimitChangeRequest) (*model.LimitChangeResponse, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	change, err := s.pendingChange(txCtx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.apply(txCtx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	markReviewed(ctx, change, model.ChangeStatusApproved, req)
+	err = s.changeRepo.MarkReviewed(txCtx, change)
+	if err != nil {
+		s.log.Error("failed to approve limit change", zap.Int("change_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return toLimitChangeResponse(change), nil
+}
+
+func (s *limitChangeService) Reject(ctx context.Context, id int, req *model.ReviewLimitChangeRequest) (*model.LimitChangeResponse, error) {
+	change, err := s.pendingChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	markReviewed(ctx, change, model.ChangeStatusRejected, req)
+	err = s.changeRepo.MarkReviewed(ctx, change)
+	if err != nil {
+		s.log.Error("failed to reject limit change", zap.Int("change_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toLimitChangeResponse(change), nil
+}
+
+// pendingChange loads a change that is still waiting for review by an
+// operator other than the one who proposed it. Both operators are the
+// subjects of their tokens, so one token cannot propose and review a change.
+func (s *limitChangeService) pendingChange(ctx context.Context, id int) (*model.LimitChange, error) {
+	change, err := s.changeRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get limit change", zap.Int("change_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	if change.Status != model.ChangeStatusPending {
+		return nil, errorx.NewError(errorx.ErrTypeConflict, "limit change has already been reviewed", nil)
+	}
+
+	if change.RequestedBy == actor(ctx) {
+		return nil, errorx.NewError(errorx.ErrTypeForbidden, "limit change must be reviewed by an operator other than the one who proposed it", nil)
+	}
+
+	return change, nil
+}
+
+func (s *limitChangeService) apply(ctx context.Context, change *model.LimitChange) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if change.Action == model.LimitActionCreate {
+		validUntil, nextReview := s.terms.period(today)
+		id, err := s.limitRepo.Add(ctx, &model.UserFacilityLimit{
+			UserID:         change.UserID,
+			ProductID:      change.ProductID,
+			LimitAmount:    decimal.Zero,
+			Status:         model.LimitStatusActive,
+			ValidFrom:      today,
+			ValidUntil:     validUntil,
+			NextReviewDate: nextReview,
+		})
+		if err != nil {
+			s.log.Error("failed to insert facility limit", zap.Int64("change_id", change.ChangeID), zap.Error(err))
+			return err
+		}
+
+		limitID := int64(id)
+		change.FacilityLimitID = &limitID
+		return s.post(ctx, change, *change.Amount)
+	}
+
+	limit, err := s.limitRepo.GetByID(ctx, int(*change.FacilityLimitID))
+	if err != nil {
+		s.log.Error("failed to get facility limit", zap.Int64("facility_limit_id", *change.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	// The limit may have moved on since the change was proposed.
+	err = checkLimitAction(change.Action, limit)
+	if err != nil {
+		return err
+	}
+
+	switch change.Action {
+	case model.LimitActionRaise:
+		return s.post(ctx, change, *change.Amount)
+	case model.LimitActionLower:
+		return s.post(ctx, change, change.Amount.Neg())
+	case model.LimitActionRenew:
+		return s.renew(ctx, limit, today)
+	}
+
+	status := model.LimitStatusActive
+	switch change.Action {
+	case model.LimitActionFreeze:
+		status = model.LimitStatusFrozen
+	case model.LimitActionClose:
+		status = model.LimitStatusClosed
+	}
+
+	err = s.limitRepo.UpdateStatus(ctx, int(limit.FacilityLimitID), status)
+	if err != nil {
+		s.log.Error("failed to update facility limit status", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// renew extends the validity of a limit by another term, counted from its
+// current end or from today when it already expired.
+func (s *limitChangeService) renew(ctx context.Context, limit *model.UserFacilityLimit, today time.Time) error {
+	from := limit.ValidUntil
+	if today.After(from) {
+		from = today
+	}
+	validUntil, nextReview := s.terms.period(from)
+
+	err := s.limitRepo.UpdateValidity(ctx, int(limit.FacilityLimitID), validUntil, nextReview)
+	if err != nil {
+		s.log.Error("failed to renew facility limit", zap.Int64("facility_limit_id", limit.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *limitChangeService) post(ctx context.Context, change *model.LimitChange, amount decimal.Decimal) error {
+	entry := newLedgerEntry(*change.FacilityLimitID, model.LedgerAdjustment, amount, model.ReferenceLimitChange, change.ChangeID)
+	entry.Note = change.Reason
+
+	err := s.limitRepo.Post(ctx, entry)
+	if err != nil {
+		s.log.Error("failed to adjust facility limit", zap.Int64("facility_limit_id", *change.FacilityLimitID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *limitChangeService) checkNewLimit(ctx context.Context, userID, productID int64) error {
+	_, err := s.userRepo.Get(ctx, int(userID))
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	_, err = s.productRepo.Get(ctx, int(productID))
+	if err != nil {
+		s.log.Error("failed to get limit product", zap.Int64("product_id", productID), zap.Error(err))
+		return err
+	}
+
+	limits, err := s.limitRepo.ListByUser(ctx, int(userID))
+	if err != nil {
+		s.log.Error("failed to get user limits", zap.Int64("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	for _, limit := range limits {
+		if limit.ProductID == productID {
+			return errorx.NewError(errorx.ErrTypeConflict, "user already has a limit for this product", nil)
+		}
+	}
+
+	return nil
+}
+
+// checkLimitAction reports whether the action can be applied to a limit in
+// its current status.
+func checkLimitAction(action string, limit *model.UserFacilityLimit) error {
+	var msg string
+
+	switch {
+	case limit.Status == model.LimitStatusClosed:
+		msg = "limit is closed"
+	case action == model.LimitActionFreeze && limit.Status == model.LimitStatusFrozen:
+		msg = "limit is already frozen"
+	case action == model.LimitActionUnfreeze && limit.Status != model.LimitStatusFrozen:
+		msg = "limit is not frozen"
+	}
+
+	if msg != "" {
+		return errorx.NewError(errorx.ErrTypeValidation, msg, nil)
+	}
+
+	return nil
+}
+
+func markReviewed(ctx context.Context, change *model.LimitChange, status string, req *model.ReviewLimitChangeRequest) {
+	now := time.Now()
+	reviewer := actor(ctx)
+
+	change.Status = status
+	change.ReviewedBy = &reviewer
+	change.ReviewNote = req.Note
+	change.ReviewedAt = &now
+}
+
+func toLimitChangeResponse(change *model.LimitChange) *model.LimitChangeResponse {
+	response := &model.LimitChangeResponse{
+		ChangeID:        change.ChangeID,
+		Action:          change.Action,
+		UserID:          change.UserID,
+		ProductID:       change.ProductID,
+		FacilityLimitID: change.FacilityLimitID,
+		Amount:          change.Amount,
+		Reason:          change.Reason,
+		Status:          change.Status,
+		RequestedBy:     change.RequestedBy,
+		ReviewNote:      change.ReviewNote,
+		CreatedAt:       change.CreatedAt.Format(time.RFC3339),
+	}
+	if change.ReviewedBy != nil {
+		response.ReviewedBy = *change.ReviewedBy
+	}
+	if change.ReviewedAt != nil {
+		response.ReviewedAt = change.ReviewedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"finance/internal/auth"
+	"finance/internal/model"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLimitChangeService() (LimitChangeService, *MockUserRepo, *MockLimitRepo, *MockLimitChangeRepo, *MockTrx) {
+	userRepo := new(MockUserRepo)
+	limitRepo := new(MockLimitRepo)
+	changeRepo := new(MockLimitChangeRepo)
+	trx := new(MockTrx)
+	svc := NewLimitChangeService(userRepo, limitRepo, newProductRepo(), changeRepo, LimitTerms{ValidityMonths: 12, ReviewLeadDays: 30}, logger.NewNop(), trx)
+
+	return svc, userRepo, limitRepo, changeRepo, trx
+}
+
+// operator returns a context carrying the token of an admin operator.
+func operator(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Role: auth.RoleAdmin})
+}
+
+func TestLimitChangeService_Propose(t *testing.T) {
+	amount := decimal.NewFromInt(5000000)
+	activeLimit := &model.UserFacilityLimit{FacilityLimitID: 4, UserID: 1, ProductID: 1, LimitAmount: decimal.NewFromInt(2000000), Status: model.LimitStatusActive}
+
+	t.Run("success raise", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, _ := setupLimitChangeService()
+		ctx := operator("maker")
+
+		limitRepo.On("GetByID", mock.Anything, 4).Return(activeLimit, nil).Once()
+		changeRepo.On("Add", mock.Anything, mock.MatchedBy(func(c *model.LimitChange) bool {
+			return c.Status == model.ChangeStatusPending && c.UserID == 1 && *c.FacilityLimitID == 4 && c.RequestedBy == "maker"
+		})).Return(7, nil).Once()
+
+		res, err := svc.Propose(ctx, &model.LimitChangeRequest{
+			Action: model.LimitActionRaise, FacilityLimitID: 4, Amount: &amount, Reason: "salary increase",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), res.ChangeID)
+		assert.Equal(t, model.ChangeStatusPending, res.Status)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error missing amount", func(t *testing.T) {
+		svc, _, limitRepo, _, _ := setupLimitChangeService()
+
+		res, err := svc.Propose(operator("maker"), &model.LimitChangeRequest{
+			Action: model.LimitActionLower, FacilityLimitID: 4, Reason: "risk review",
+		})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		limitRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error user already has limit for product", func(t *testing.T) {
+		svc, userRepo, limitRepo, changeRepo, _ := setupLimitChangeService()
+		ctx := operator("maker")
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{activeLimit}, nil).Once()
+
+		res, err := svc.Propose(ctx, &model.LimitChangeRequest{
+			Action: model.LimitActionCreate, UserID: 1, ProductID: 1, Amount: &amount, Reason: "onboarding",
+		})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: user already has a limit for this product", err.Error())
+		changeRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error freeze closed limit", func(t *testing.T) {
+		svc, _, limitRepo, _, _ := setupLimitChangeService()
+
+		closed := *activeLimit
+		closed.Status = model.LimitStatusClosed
+		limitRepo.On("GetByID", mock.Anything, 4).Return(&closed, nil).Once()
+
+		res, err := svc.Propose(operator("maker"), &model.LimitChangeRequest{
+			Action: model.LimitActionFreeze, FacilityLimitID: 4, Reason: "fraud alert",
+		})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "invalid validation: limit is closed", err.Error())
+	})
+}
+
+func TestLimitChangeService_Approve(t *testing.T) {
+	amount := decimal.NewFromInt(5000000)
+	limitID := int64(4)
+	pending := func(action string) *model.LimitChange {
+		change := &model.LimitChange{
+			ChangeID: 7, Action: action, UserID: 1, ProductID: 2, Reason: "reason", Status: model.ChangeStatusPending,
+			RequestedBy: "maker", CreatedAt: time.Now(),
+		}
+		if action == model.LimitActionCreate || action == model.LimitActionRaise || action == model.LimitActionLower {
+			change.Amount = &amount
+		}
+		if action != model.LimitActionCreate {
+			change.FacilityLimitID = &limitID
+		}
+		return change
+	}
+	review := &model.ReviewLimitChangeRequest{Note: "ok"}
+
+	t.Run("success create", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("checker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(pending(model.LimitActionCreate), nil).Once()
+		limitRepo.On("Add", txCtx, mock.MatchedBy(func(l *model.UserFacilityLimit) bool {
+			return l.UserID == 1 && l.ProductID == 2 && l.LimitAmount.IsZero() && l.Status == model.LimitStatusActive &&
+				l.ValidUntil.Equal(l.ValidFrom.AddDate(1, 0, 0)) && l.NextReviewDate.Equal(l.ValidUntil.AddDate(0, 0, -30))
+		})).Return(12, nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.FacilityLimitID == 12 && e.EntryType == model.LedgerAdjustment && e.Amount.Equal(amount) &&
+				*e.ReferenceType == model.ReferenceLimitChange && *e.ReferenceID == 7 && e.Note == "reason"
+		})).Return(nil).Once()
+		changeRepo.On("MarkReviewed", txCtx, mock.MatchedBy(func(c *model.LimitChange) bool {
+			return c.Status == model.ChangeStatusApproved && *c.ReviewedBy == "checker" && *c.FacilityLimitID == 12
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Approve(ctx, 7, review)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ChangeStatusApproved, res.Status)
+		assert.Equal(t, int64(12), *res.FacilityLimitID)
+		limitRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("success lower", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("checker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(pending(model.LimitActionLower), nil).Once()
+		limitRepo.On("GetByID", txCtx, 4).Return(&model.UserFacilityLimit{FacilityLimitID: 4, Status: model.LimitStatusActive}, nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.FacilityLimitID == 4 && e.Amount.Equal(amount.Neg())
+		})).Return(nil).Once()
+		changeRepo.On("MarkReviewed", txCtx, mock.Anything).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		_, err := svc.Approve(ctx, 7, review)
+		assert.NoError(t, err)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("success freeze", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("checker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(pending(model.LimitActionFreeze), nil).Once()
+		limitRepo.On("GetByID", txCtx, 4).Return(&model.UserFacilityLimit{FacilityLimitID: 4, Status: model.LimitStatusActive}, nil).Once()
+		limitRepo.On("UpdateStatus", txCtx, 4, model.LimitStatusFrozen).Return(nil).Once()
+		changeRepo.On("MarkReviewed", txCtx, mock.Anything).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		_, err := svc.Approve(ctx, 7, review)
+		assert.NoError(t, err)
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("success renew expired limit", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("checker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		expired := &model.UserFacilityLimit{FacilityLimitID: 4, Status: model.LimitStatusActive, ValidUntil: today.AddDate(0, -2, 0)}
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(pending(model.LimitActionRenew), nil).Once()
+		limitRepo.On("GetByID", txCtx, 4).Return(expired, nil).Once()
+		limitRepo.On("UpdateValidity", txCtx, 4, today.AddDate(1, 0, 0), today.AddDate(1, 0, -30)).Return(nil).Once()
+		changeRepo.On("MarkReviewed", txCtx, mock.Anything).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		_, err := svc.Approve(ctx, 7, review)
+		assert.NoError(t, err)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error same token proposes and approves", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("maker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		var proposed *model.LimitChange
+		limitRepo.On("GetByID", ctx, 4).Return(&model.UserFacilityLimit{FacilityLimitID: 4, UserID: 1, Status: model.LimitStatusActive}, nil).Once()
+		changeRepo.On("Add", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			proposed = args.Get(1).(*model.LimitChange)
+		}).Return(7, nil).Once()
+
+		_, err := svc.Propose(ctx, &model.LimitChangeRequest{
+			Action: model.LimitActionRaise, FacilityLimitID: 4, Amount: &amount, Reason: "salary increase",
+		})
+		assert.NoError(t, err)
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(proposed, nil).Once()
+
+		res, err := svc.Approve(ctx, 7, review)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "forbidden: limit change must be reviewed by an operator other than the one who proposed it", err.Error())
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		changeRepo.AssertNotCalled(t, "MarkReviewed", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Commit", txCtx)
+	})
+
+	t.Run("error already reviewed", func(t *testing.T) {
+		svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+		ctx := operator("checker")
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		approved := pending(model.LimitActionRaise)
+		approved.Status = model.ChangeStatusApproved
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		changeRepo.On("Get", txCtx, 7).Return(approved, nil).Once()
+
+		res, err := svc.Approve(ctx, 7, review)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: limit change has already been reviewed", err.Error())
+		limitRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLimitChangeService_Reject(t *testing.T) {
+	svc, _, limitRepo, changeRepo, trx := setupLimitChangeService()
+	ctx := operator("checker")
+
+	limitID := int64(4)
+	changeRepo.On("Get", mock.Anything, 7).Return(&model.LimitChange{
+		ChangeID: 7, Action: model.LimitActionClose, FacilityLimitID: &limitID, Status: model.ChangeStatusPending, RequestedBy: "maker",
+	}, nil).Once()
+	changeRepo.On("MarkReviewed", mock.Anything, mock.MatchedBy(func(c *model.LimitChange) bool {
+		return c.Status == model.ChangeStatusRejected && c.ReviewNote == "not enough evidence"
+	})).Return(nil).Once()
+
+	res, err := svc.Reject(ctx, 7, &model.ReviewLimitChangeRequest{Note: "not enough evidence"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.ChangeStatusRejected, res.Status)
+	limitRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	trx.AssertNotCalled(t, "Begin", mock.Anything)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLimitService() (LimitService, *MockUserRepo, *MockLimitRepo) {
+	userRepo := new(MockUserRepo)
+	limitRepo := new(MockLimitRepo)
+	return NewLimitService(userRepo, limitRepo, logger.NewNop()), userRepo, limitRepo
+}
+
+func TestLimitService_History(t *testing.T) {
+	mockLimit := &model.UserFacilityLimit{FacilityLimitID: 10, UserID: 1, LimitAmount: decimal.NewFromInt(8000000)}
+	refType := model.ReferenceFacility
+	refID := int64(7)
+	entries := func() []*model.LimitLedgerEntry {
+		return []*model.LimitLedgerEntry{
+			{EntryID: 3, EntryType: model.LedgerDrawdown, Amount: decimal.NewFromInt(-2000000), BalanceAfter: decimal.NewFromInt(8000000), ReferenceType: &refType, ReferenceID: &refID, CreatedAt: time.Now()},
+			{EntryID: 2, EntryType: model.LedgerRepayment, Amount: decimal.NewFromInt(1000000), BalanceAfter: decimal.NewFromInt(10000000), CreatedAt: time.Now()},
+			{EntryID: 1, EntryType: model.LedgerAdjustment, Amount: decimal.NewFromInt(9000000), BalanceAfter: decimal.NewFromInt(9000000), CreatedAt: time.Now()},
+		}
+	}
+
+	t.Run("success with next page", func(t *testing.T) {
+		svc, userRepo, limitRepo := setupLimitService()
+		ctx := context.Background()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{mockLimit}, nil).Once()
+		limitRepo.On("ListLedger", mock.Anything, 10, int64(0), 3).Return(entries(), nil).Once()
+		limitRepo.On("LedgerBalance", mock.Anything, 10).Return(decimal.NewFromInt(8000000), nil).Once()
+
+		res, err := svc.History(ctx, 1, &model.LimitHistoryRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, res.Entries, 2)
+		assert.Equal(t, int64(2), res.NextBefore)
+		assert.Equal(t, model.ReferenceFacility, res.Entries[0].ReferenceType)
+		assert.True(t, res.LedgerBalance.Equal(res.Balance))
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		svc, userRepo, limitRepo := setupLimitService()
+		ctx := context.Background()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{mockLimit}, nil).Once()
+		limitRepo.On("ListLedger", mock.Anything, 10, int64(2), 21).Return(entries()[2:], nil).Once()
+		limitRepo.On("LedgerBalance", mock.Anything, 10).Return(decimal.NewFromInt(8000000), nil).Once()
+
+		res, err := svc.History(ctx, 1, &model.LimitHistoryRequest{Before: 2})
+		assert.NoError(t, err)
+		assert.Len(t, res.Entries, 1)
+		assert.Zero(t, res.NextBefore)
+	})
+
+	t.Run("pick limit of a user with several products", func(t *testing.T) {
+		svc, userRepo, limitRepo := setupLimitService()
+		ctx := context.Background()
+
+		cashLoan := &model.UserFacilityLimit{FacilityLimitID: 14, UserID: 1, ProductID: 2, LimitAmount: decimal.NewFromInt(3000000)}
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{mockLimit, cashLoan}, nil).Once()
+		limitRepo.On("ListLedger", mock.Anything, 14, int64(0), 21).Return([]*model.LimitLedgerEntry{}, nil).Once()
+		limitRepo.On("LedgerBalance", mock.Anything, 14).Return(decimal.NewFromInt(3000000), nil).Once()
+
+		res, err := svc.History(ctx, 1, &model.LimitHistoryRequest{FacilityLimitID: 14})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(14), res.FacilityLimitID)
+	})
+
+	t.Run("error limit required with several products", func(t *testing.T) {
+		svc, userRepo, limitRepo := setupLimitService()
+		ctx := context.Background()
+
+		cashLoan := &model.UserFacilityLimit{FacilityLimitID: 14, UserID: 1, ProductID: 2}
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{mockLimit, cashLoan}, nil).Once()
+
+		res, err := svc.History(ctx, 1, &model.LimitHistoryRequest{})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		limitRepo.AssertNotCalled(t, "ListLedger", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error limit of another user", func(t *testing.T) {
+		svc, userRepo, limitRepo := setupLimitService()
+		ctx := context.Background()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1}, nil).Once()
+		limitRepo.On("ListByUser", mock.Anything, 1).Return([]*model.UserFacilityLimit{mockLimit}, nil).Once()
+
+		res, err := svc.History(ctx, 1, &model.LimitHistoryRequest{FacilityLimitID: 99})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource not found: facility limit not found for this user", err.Error())
+	})
+}
+
+func TestLimitService_DueForReview(t *testing.T) {
+	svc, _, limitRepo := setupLimitService()
+	ctx := context.Background()
+
+	day := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	limitRepo.On("ListDueForReview", mock.Anything, day).Return([]*model.UserFacilityLimit{
+		{FacilityLimitID: 1, UserID: 1, Status: model.LimitStatusActive, ValidUntil: time.Date(2027, 2, 28, 0, 0, 0, 0, time.UTC), NextReviewDate: time.Date(2027, 1, 29, 0, 0, 0, 0, time.UTC)},
+		{FacilityLimitID: 2, UserID: 2, Status: model.LimitStatusFrozen, ValidUntil: time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC), NextReviewDate: day},
+	}, nil).Once()
+
+	res, err := svc.DueForReview(ctx, &model.LimitReviewRequest{Date: "2027-03-01"})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.True(t, res[0].Expired)
+	assert.False(t, res[1].Expired)
+	assert.Equal(t, "2027-03-01", res[1].NextReviewDate)
+}
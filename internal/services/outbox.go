@@ -0,0 +1,209 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"finance/internal/disbursement"
+	"finance/internal/model"
+	"finance/internal/outbox"
+	"finance/internal/repository"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// relayBatchSize caps how many events one relay run delivers.
+	relayBatchSize = 100
+	// relayLease is how long a claimed event is left to the relay that
+	// claimed it before it is delivered again.
+	relayLease = time.Minute
+)
+
+// OutboxConfig sets how often an event is offered to the publisher before it
+// is given up on, and how long to wait between tries.
+type OutboxConfig struct {
+	MaxAttempts int
+	Backoff     disbursement.Backoff
+}
+
+type OutboxService interface {
+	Relay(ctx context.Context, now time.Time) (int, error)
+}
+
+type outboxService struct {
+	outboxRepo repository.OutboxRepository
+	publisher  outbox.Publisher
+	cfg        OutboxConfig
+	log        *logger.Logger
+	trx        postgres.Trx
+}
+
+func NewOutboxService(outboxRepo repository.OutboxRepository, publisher outbox.Publisher, cfg OutboxConfig, log *logger.Logger, trx postgres.Trx) OutboxService {
+	return &outboxService{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		cfg:        cfg,
+		log:        log,
+		trx:        trx,
+	}
+}
+
+// aggregateKey names the aggregate an event belongs to.
+type aggregateKey struct {
+	aggregateType string
+	aggregateID   int64
+}
+
+// Relay delivers the events that are due at or before now and returns how
+// many were published. An event is marked published only after the publisher
+// accepted it, so a crash in between sends it again: delivery is at least
+// once. The events of one aggregate are delivered in the order they were
+// written: once one fails, the rest of its aggregate are released unsent and
+// are not claimed again before it is delivered. One given up on after
+// MaxAttempts holds its aggregate back until an operator publishes or
+// requeues it. Events of other aggregates carry on.
+func (s *outboxService) Relay(ctx context.Context, now time.Time) (int, error) {
+	events, err := s.claim(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	slices.SortFunc(events, func(a, b *model.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+
+	failed := map[aggregateKey]bool{}
+	var unsent []int64
+	published := 0
+	for i, event := range events {
+		key := aggregateKey{aggregateType: event.AggregateType, aggregateID: event.AggregateID}
+		if failed[key] {
+			unsent = append(unsent, event.ID)
+			continue
+		}
+
+		err = s.publisher.Publish(ctx, outbox.Message{
+			ID:            event.EventID,
+			Type:          event.EventType,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			Payload:       event.Payload,
+			OccurredAt:    event.CreatedAt,
+		})
+		if err != nil {
+			failed[key] = true
+			s.fail(ctx, event, err, now)
+			continue
+		}
+
+		err = s.outboxRepo.MarkPublished(ctx, event.ID, now)
+		if err != nil {
+			s.log.Error("failed to mark outbox event published", zap.String("event_id", event.EventID), zap.Error(err))
+			for _, rest := range events[i+1:] {
+				unsent = append(unsent, rest.ID)
+			}
+			s.release(ctx, unsent, now)
+			return published, err
+		}
+		published++
+	}
+
+	s.release(ctx, unsent, now)
+	return published, nil
+}
+
+// release hands the claimed events that were not sent back to the next claim.
+// Should that fail they are sent once their lease runs out.
+func (s *outboxService) release(ctx context.Context, ids []int64, now time.Time) {
+	if len(ids) == 0 {
+		return
+	}
+
+	err := s.outboxRepo.Release(ctx, ids, now)
+	if err != nil {
+		s.log.Error("failed to release unsent outbox events", zap.Int64s("ids", ids), zap.Error(err))
+	}
+}
+
+// claim leases the due events in a transaction of its own, so the claim lock
+// is released before anything is published.
+func (s *outboxService) claim(ctx context.Context, now time.Time) ([]*model.OutboxEvent, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	events, err := s.outboxRepo.ClaimDue(txCtx, now, now.Add(relayLease), relayBatchSize)
+	if err != nil {
+		s.log.Error("failed to claim outbox events", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit outbox claim", zap.Error(err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// fail records a delivery the publisher refused. The event is tried again
+// with backoff, or given up on once it has had MaxAttempts.
+func (s *outboxService) fail(ctx context.Context, event *model.OutboxEvent, cause error, now time.Time) {
+	event.LastError = lastError(cause)
+	if event.Attempts >= s.cfg.MaxAttempts {
+		s.log.Error("giving up on outbox event, its aggregate is held back",
+			zap.String("event_id", event.EventID),
+			zap.String("event_type", event.EventType),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(cause))
+		event.FailedAt = &now
+	} else {
+		s.log.Warn("failed to publish outbox event",
+			zap.String("event_id", event.EventID),
+			zap.String("event_type", event.EventType),
+			zap.Int("attempts", event.Attempts),
+			zap.Error(cause))
+		event.NextAttemptAt = now.Add(s.cfg.Backoff.Delay(event.Attempts))
+	}
+
+	err := s.outboxRepo.MarkFailed(ctx, event)
+	if err != nil {
+		s.log.Error("failed to record outbox event failure", zap.String("event_id", event.EventID), zap.Error(err))
+	}
+}
+
+// addFacilityEvent writes an event about a facility to the outbox. It must
+// run in the transaction of the change, so the event is relayed if and only
+// if the change commits.
+func addFacilityEvent(ctx context.Context, outboxRepo repository.OutboxRepository, log *logger.Logger, eventType string, facilityID int64, payload any, now time.Time) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	err = outboxRepo.Add(ctx, &model.OutboxEvent{
+		EventID:       outbox.NewEventID(),
+		EventType:     eventType,
+		AggregateType: model.AggregateFacility,
+		AggregateID:   facilityID,
+		Payload:       raw,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		log.Error("failed to write outbox event",
+			zap.String("event_type", eventType),
+			zap.Int64("user_facility_id", facilityID),
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"finance/internal/disbursement"
+	"finance/internal/model"
+	"finance/internal/outbox"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOutboxService_Relay(t *testing.T) {
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+	now := time.Now()
+	cfg := OutboxConfig{MaxAttempts: 3, Backoff: disbursement.Backoff{Base: 5 * time.Second, Max: time.Hour}}
+	events := func() []*model.OutboxEvent {
+		return []*model.OutboxEvent{
+			{ID: 2, EventID: "event-2", EventType: model.EventFacilityPaid, AggregateType: model.AggregateFacility, AggregateID: 7, Payload: []byte(`{}`), Attempts: 1},
+			{ID: 1, EventID: "event-1", EventType: model.EventFacilityCreated, AggregateType: model.AggregateFacility, AggregateID: 7, Payload: []byte(`{}`), Attempts: 1},
+			{ID: 4, EventID: "event-4", EventType: model.EventFacilityCreated, AggregateType: model.AggregateFacility, AggregateID: 8, Payload: []byte(`{}`), Attempts: 1},
+			{ID: 3, EventID: "event-3", EventType: model.EventFacilityClosed, AggregateType: model.AggregateFacility, AggregateID: 7, Payload: []byte(`{}`), Attempts: 1},
+		}
+	}
+	message := func(id string) any {
+		return mock.MatchedBy(func(msg outbox.Message) bool { return msg.ID == id })
+	}
+	claim := func(outboxRepo *MockOutboxRepo, trx *MockTrx, claimed []*model.OutboxEvent) {
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		outboxRepo.On("ClaimDue", txCtx, now, now.Add(relayLease), relayBatchSize).Return(claimed, nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+	}
+
+	t.Run("publishes in the order the events were written", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		publisher := new(MockPublisher)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, publisher, cfg, logger.NewNop(), trx)
+
+		var published []string
+		claim(outboxRepo, trx, events())
+		publisher.On("Publish", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			published = append(published, args.Get(1).(outbox.Message).ID)
+		}).Return(nil).Times(4)
+		outboxRepo.On("MarkPublished", ctx, mock.Anything, now).Return(nil).Times(4)
+
+		n, err := svc.Relay(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		assert.Equal(t, []string{"event-1", "event-2", "event-3", "event-4"}, published)
+		outboxRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+	})
+
+	t.Run("failed event holds back its aggregate unsent and is retried with backoff", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		publisher := new(MockPublisher)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, publisher, cfg, logger.NewNop(), trx)
+
+		claim(outboxRepo, trx, events())
+		publisher.On("Publish", ctx, message("event-1")).Return(nil).Once()
+		outboxRepo.On("MarkPublished", ctx, int64(1), now).Return(nil).Once()
+		publisher.On("Publish", ctx, message("event-2")).Return(errors.New("broker unavailable")).Once()
+		outboxRepo.On("MarkFailed", ctx, mock.MatchedBy(func(e *model.OutboxEvent) bool {
+			return e.ID == 2 && e.LastError == "broker unavailable" && e.NextAttemptAt.Equal(now.Add(5*time.Second)) && e.FailedAt == nil
+		})).Return(nil).Once()
+		publisher.On("Publish", ctx, message("event-4")).Return(nil).Once()
+		outboxRepo.On("MarkPublished", ctx, int64(4), now).Return(nil).Once()
+		outboxRepo.On("Release", ctx, []int64{3}, now).Return(nil).Once()
+
+		n, err := svc.Relay(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		outboxRepo.AssertExpectations(t)
+		publisher.AssertExpectations(t)
+		publisher.AssertNotCalled(t, "Publish", ctx, message("event-3"))
+		outboxRepo.AssertNotCalled(t, "MarkPublished", ctx, int64(2), now)
+	})
+
+	t.Run("event out of attempts is given up on", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		publisher := new(MockPublisher)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, publisher, cfg, logger.NewNop(), trx)
+
+		event := events()[1]
+		event.Attempts = 3
+		claim(outboxRepo, trx, []*model.OutboxEvent{event})
+		publisher.On("Publish", ctx, message("event-1")).Return(errors.New("broker unavailable")).Once()
+		outboxRepo.On("MarkFailed", ctx, mock.MatchedBy(func(e *model.OutboxEvent) bool {
+			return e.ID == 1 && e.FailedAt != nil && e.FailedAt.Equal(now)
+		})).Return(nil).Once()
+
+		n, err := svc.Relay(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		outboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("event published but not marked is sent again", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		publisher := new(MockPublisher)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, publisher, cfg, logger.NewNop(), trx)
+
+		claim(outboxRepo, trx, events()[1:2])
+		publisher.On("Publish", ctx, mock.Anything).Return(nil).Once()
+		outboxRepo.On("MarkPublished", ctx, int64(1), now).Return(errors.New("db error")).Once()
+
+		n, err := svc.Relay(ctx, now)
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	t.Run("events after one published but not marked are released", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		publisher := new(MockPublisher)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, publisher, cfg, logger.NewNop(), trx)
+
+		claim(outboxRepo, trx, events())
+		publisher.On("Publish", ctx, message("event-1")).Return(nil).Once()
+		outboxRepo.On("MarkPublished", ctx, int64(1), now).Return(errors.New("db error")).Once()
+		outboxRepo.On("Release", ctx, []int64{2, 3, 4}, now).Return(nil).Once()
+
+		n, err := svc.Relay(ctx, now)
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+		outboxRepo.AssertExpectations(t)
+		publisher.AssertNumberOfCalls(t, "Publish", 1)
+	})
+
+	t.Run("error claim", func(t *testing.T) {
+		outboxRepo := new(MockOutboxRepo)
+		trx := new(MockTrx)
+		svc := NewOutboxService(outboxRepo, new(MockPublisher), cfg, logger.NewNop(), trx)
+
+		trx.On("Begin", ctx).Return(txCtx, nil).Once()
+		outboxRepo.On("ClaimDue", txCtx, now, now.Add(relayLease), relayBatchSize).Return(nil, errors.New("db error")).Once()
+		trx.On("Rollback", txCtx).Return(nil).Once()
+
+		n, err := svc.Relay(ctx, now)
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+		trx.AssertExpectations(t)
+	})
+}
+
+func TestPaymentService_Pay_OutboxEvents(t *testing.T) {
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	paymentRepo := new(MockPaymentRepo)
+	limitRepo := new(MockLimitRepo)
+	outboxRepo := new(MockOutboxRepo)
+	trx := new(MockTrx)
+	svc := NewPaymentService(facilityRepo, detailRepo, paymentRepo, new(MockPayoffRepo), limitRepo, outboxRepo, newDisbursementRepo(), PayoffConfig{}, logger.NewNop(), trx)
+
+	ctx := context.Background()
+	txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+	trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+	trx.On("Rollback", mock.Anything).Return(nil).Once()
+	facilityRepo.On("Get", txCtx, 7).Return(&model.UserFacility{UserFacilityID: 7, UserID: 1, FacilityLimitID: 10}, nil).Once()
+	detailRepo.On("ListUnpaid", txCtx, 7).Return([]*model.UserFacilityDetail{
+		{DetailID: 3, UserFacilityID: 7, InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000)},
+	}, nil).Once()
+	paymentRepo.On("Add", txCtx, mock.Anything).Return(5, nil).Once()
+	detailRepo.On("MarkPaid", txCtx, []int64{3}, int64(5), mock.Anything).Return(nil).Once()
+	facilityRepo.On("UpdateStatus", txCtx, 7, model.FacilityStatusPaidOff).Return(nil).Once()
+	limitRepo.On("Post", txCtx, mock.Anything).Return(nil).Once()
+
+	var events []*model.OutboxEvent
+	outboxRepo.On("Add", txCtx, mock.Anything).Run(func(args mock.Arguments) {
+		events = append(events, args.Get(1).(*model.OutboxEvent))
+	}).Return(nil).Twice()
+	trx.On("Commit", txCtx).Return(nil).Once()
+
+	_, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1200000)})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	assert.Equal(t, model.EventFacilityPaid, events[0].EventType)
+	assert.Equal(t, int64(7), events[0].AggregateID)
+	var paid model.FacilityPaidEvent
+	assert.NoError(t, json.Unmarshal(events[0].Payload, &paid))
+	assert.Equal(t, int64(5), paid.PaymentID)
+	assert.Equal(t, "1000000", paid.PrincipalAmount.String())
+
+	assert.Equal(t, model.EventFacilityClosed, events[1].EventType)
+	var closed model.FacilityClosedEvent
+	assert.NoError(t, json.Unmarshal(events[1].Payload, &closed))
+	assert.Equal(t, model.FacilityStatusPaidOff, closed.Status)
+
+	assert.NotEqual(t, events[0].EventID, events[1].EventID)
+	trx.AssertExpectations(t)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"finance/pkg/postgres"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type PaymentService interface {
+	Pay(ctx context.Context, facilityID int, req *model.PaymentRequest) (*model.PaymentResponse, error)
+	QuotePayoff(ctx context.Context, facilityID int) (*model.PayoffQuoteResponse, error)
+	Payoff(ctx context.Context, facilityID int, req *model.PayoffRequest) (*model.PaymentResponse, error)
+}
+
+type PayoffConfig struct {
+	RebatePolicy string
+	FeeRate      decimal.Decimal
+	QuoteTTL     time.Duration
+}
+
+type paymentService struct {
+	facilityRepo     repository.FacilityRepository
+	detailRepo       repository.DetailRepository
+	paymentRepo      repository.PaymentRepository
+	payoffRepo       repository.PayoffRepository
+	limitRepo        repository.LimitRepository
+	outboxRepo       repository.OutboxRepository
+	disbursementRepo repository.DisbursementRepository
+	payoffCfg        PayoffConfig
+	log              *logger.Logger
+	trx              postgres.Trx
+}
+
+func NewPaymentService(
+	facilityRepo repository.FacilityRepository,
+	detailRepo repository.DetailRepository,
+	paymentRepo repository.PaymentRepository,
+	payoffRepo repository.PayoffRepository,
+	limitRepo repository.LimitRepository,
+	outboxRepo repository.OutboxRepository,
+	disbursementRepo repository.DisbursementRepository,
+	payoffCfg PayoffConfig,
+	log *logger.Logger,
+	trx postgres.Trx,
+) PaymentService {
+	return &paymentService{
+		facilityRepo:     facilityRepo,
+		detailRepo:       detailRepo,
+		paymentRepo:      paymentRepo,
+		payoffRepo:       payoffRepo,
+		limitRepo:        limitRepo,
+		outboxRepo:       outboxRepo,
+		disbursementRepo: disbursementRepo,
+		payoffCfg:        payoffCfg,
+		log:              log,
+		trx:              trx,
+	}
+}
+
+func (s *paymentService) Pay(ctx context.Context, facilityID int, req *model.PaymentRequest) (*model.PaymentResponse, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errorx.NewValidationError(map[string]string{"amount": "must be greater than 0"})
+	}
+
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	facility, err := s.facilityRepo.Get(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, facility.UserID, "facility")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFacilityOpen(txCtx, facility)
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid, err := s.detailRepo.ListUnpaid(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get unpaid installments", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	if len(unpaid) == 0 {
+		return nil, errorx.NewError(errorx.ErrNoOutstanding, "facility has been fully paid", nil)
+	}
+
+	remaining := req.Amount
+	principal := decimal.Zero
+	settled := []*model.UserFacilityDetail{}
+	for _, detail := range unpaid {
+		if remaining.LessThan(detail.InstallmentAmount) {
+			break
+		}
+
+		remaining = remaining.Sub(detail.InstallmentAmount)
+		principal = principal.Add(detail.PrincipalAmount)
+		settled = append(settled, detail)
+	}
+
+	if len(settled) == 0 || !remaining.IsZero() {
+		s.log.Warn("payment amount does not match installments",
+			zap.Int("facility_id", facilityID),
+			zap.String("amount", req.Amount.String()))
+		return nil, errorx.NewError(
+			errorx.ErrPaymentMismatch,
+			fmt.Sprintf("amount must cover whole installments, next installment is %s", unpaid[0].InstallmentAmount.StringFixed(2)),
+			nil,
+		)
+	}
+
+	now := time.Now()
+	payment := model.Payment{
+		UserFacilityID:  facility.UserFacilityID,
+		Amount:          req.Amount,
+		PrincipalAmount: principal,
+		PaidAt:          now,
+		CreatedAt:       now,
+	}
+
+	paymentID, err := s.paymentRepo.Add(txCtx, &payment)
+	if err != nil {
+		s.log.Error("failed to insert payment", zap.Error(err))
+		return nil, err
+	}
+
+	ids := []int64{}
+	paidSchedule := []model.ScheduleDetail{}
+	for _, detail := range settled {
+		detail.Status = model.DetailStatusPaid
+		detail.PaidAt = &now
+		ids = append(ids, detail.DetailID)
+		paidSchedule = append(paidSchedule, toScheduleDetail(detail))
+	}
+
+	err = s.detailRepo.MarkPaid(txCtx, ids, int64(paymentID), now)
+	if err != nil {
+		s.log.Error("failed to mark installments as paid", zap.Error(err))
+		return nil, err
+	}
+
+	if len(settled) == len(unpaid) {
+		err = s.facilityRepo.UpdateStatus(txCtx, facilityID, model.FacilityStatusPaidOff)
+		if err != nil {
+			s.log.Error("failed to close facility", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	err = s.limitRepo.Post(txCtx, newLedgerEntry(facility.FacilityLimitID, model.LedgerRepayment, principal, model.ReferencePayment, int64(paymentID)))
+	if err != nil {
+		s.log.Error("failed to replenish limit user", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.addPaymentEvents(txCtx, &payment, int64(paymentID), len(settled) == len(unpaid))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return &model.PaymentResponse{
+		PaymentID:        int64(paymentID),
+		UserFacilityID:   facility.UserFacilityID,
+		Amount:           req.Amount,
+		PrincipalAmount:  principal,
+		PaidAt:           now.Format(time.RFC3339),
+		PaidInstallments: paidSchedule,
+	}, nil
+}
+
+// checkFacilityOpen rejects paying a facility whose money has not reached
+// the customer: one still waiting for its disbursement, which may yet be
+// cancelled, and one cancelled because its money could never be disbursed.
+// Nothing is owed on either. Facilities booked before disbursements were
+// tracked have none and are open.
+func (s *paymentService) checkFacilityOpen(ctx context.Context, facility *model.UserFacility) error {
+	if facility.Status == model.FacilityStatusCancelled {
+		return errorx.NewError(errorx.ErrNoOutstanding, "facility has been cancelled", nil)
+	}
+
+	d, err := s.disbursementRepo.GetByFacility(ctx, facility.UserFacilityID)
+	if err != nil {
+		var appErr *errorx.AppError
+		if errors.As(err, &appErr) && appErr.Type == errorx.ErrTypeNotFound {
+			return nil
+		}
+		s.log.Error("failed to get disbursement", zap.Int64("user_facility_id", facility.UserFacilityID), zap.Error(err))
+		return err
+	}
+
+	if d.Status != model.DisbursementStatusSucceeded {
+		return errorx.NewError(errorx.ErrNoOutstanding, "facility has not been disbursed yet", nil)
+	}
+
+	return nil
+}
+
+// addPaymentEvents writes the events of a payment to the outbox: the payment
+// itself and, when it paid the facility off, the facility closing.
+func (s *paymentService) addPaymentEvents(ctx context.Context, payment *model.Payment, paymentID int64, closed bool) error {
+	err := addFacilityEvent(ctx, s.outboxRepo, s.log, model.EventFacilityPaid, payment.UserFacilityID, model.FacilityPaidEvent{
+		UserFacilityID:  payment.UserFacilityID,
+		PaymentID:       paymentID,
+		Amount:          payment.Amount,
+		PrincipalAmount: payment.PrincipalAmount,
+		PaidAt:          payment.PaidAt.Format(time.RFC3339),
+	}, payment.PaidAt)
+	if err != nil || !closed {
+		return err
+	}
+
+	return addFacilityEvent(ctx, s.outboxRepo, s.log, model.EventFacilityClosed, payment.UserFacilityID, model.FacilityClosedEvent{
+		UserFacilityID: payment.UserFacilityID,
+		Status:         model.FacilityStatusPaidOff,
+		ClosedAt:       payment.PaidAt.Format(time.RFC3339),
+	}, payment.PaidAt)
+}
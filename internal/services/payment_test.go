@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupPaymentService() (
+	PaymentService,
+	*MockFacilityRepo,
+	*MockDetailRepo,
+	*MockPaymentRepo,
+	*MockLimitRepo,
+	*MockTrx,
+) {
+	svc, facilityRepo, detailRepo, paymentRepo, _, limitRepo, trx := setupPayoffService()
+	return svc, facilityRepo, detailRepo, paymentRepo, limitRepo, trx
+}
+
+func setupPayoffService() (
+	PaymentService,
+	*MockFacilityRepo,
+	*MockDetailRepo,
+	*MockPaymentRepo,
+	*MockPayoffRepo,
+	*MockLimitRepo,
+	*MockTrx,
+) {
+	facilityRepo := new(MockFacilityRepo)
+	detailRepo := new(MockDetailRepo)
+	paymentRepo := new(MockPaymentRepo)
+	payoffRepo := new(MockPayoffRepo)
+	limitRepo := new(MockLimitRepo)
+	trx := new(MockTrx)
+	payoffCfg := PayoffConfig{
+		RebatePolicy: model.RebatePolicyProRata,
+		FeeRate:      decimal.RequireFromString("0.01"),
+		QuoteTTL:     time.Hour,
+	}
+
+	svc := NewPaymentService(facilityRepo, detailRepo, paymentRepo, payoffRepo, limitRepo, newOutboxRepo(), newDisbursementRepo(), payoffCfg, logger.NewNop(), trx)
+
+	return svc, facilityRepo, detailRepo, paymentRepo, payoffRepo, limitRepo, trx
+}
+
+func TestPaymentService_Pay(t *testing.T) {
+	mockFacility := &model.UserFacility{
+		UserFacilityID:     7,
+		UserID:             1,
+		FacilityLimitID:    10,
+		Amount:             decimal.NewFromInt(6000000),
+		Tenor:              6,
+		MonthlyInstallment: decimal.NewFromInt(1200000),
+	}
+
+	unpaid := func() []*model.UserFacilityDetail {
+		return []*model.UserFacilityDetail{
+			{DetailID: 3, UserFacilityID: 7, DueDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000), Status: model.DetailStatusUnpaid},
+			{DetailID: 4, UserFacilityID: 7, DueDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000), Status: model.DetailStatusUnpaid},
+		}
+	}
+
+	t.Run("success pay off remaining installments", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, paymentRepo, limitRepo, trx := setupPaymentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", txCtx, 7).Return(unpaid(), nil).Once()
+		paymentRepo.On("Add", txCtx, mock.MatchedBy(func(p *model.Payment) bool {
+			return p.Amount.Equal(decimal.NewFromInt(2400000)) && p.PrincipalAmount.Equal(decimal.NewFromInt(2000000))
+		})).Return(5, nil).Once()
+		detailRepo.On("MarkPaid", txCtx, []int64{3, 4}, int64(5), mock.Anything).Return(nil).Once()
+		facilityRepo.On("UpdateStatus", txCtx, 7, model.FacilityStatusPaidOff).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.FacilityLimitID == 10 && e.EntryType == model.LedgerRepayment &&
+				e.Amount.Equal(decimal.NewFromInt(2000000)) && *e.ReferenceID == 5
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(2400000)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), res.PaymentID)
+		assert.Len(t, res.PaidInstallments, 2)
+		assert.Equal(t, "2026-03-01", res.PaidInstallments[0].DueDate)
+		assert.Equal(t, model.DetailStatusPaid, res.PaidInstallments[0].Status)
+		facilityRepo.AssertExpectations(t)
+		trx.AssertExpectations(t)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error amount not matching installments", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, _, limitRepo, trx := setupPaymentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", txCtx, 7).Return(unpaid(), nil).Once()
+
+		res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1500000)})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "payment amount mismatch: amount must cover whole installments, next installment is 1200000.00", err.Error())
+		limitRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Commit", txCtx)
+	})
+
+	t.Run("error fully paid", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, _, _, trx := setupPaymentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", txCtx, 7).Return([]*model.UserFacilityDetail{}, nil).Once()
+
+		res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1200000)})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "no outstanding installment: facility has been fully paid", err.Error())
+	})
+
+	t.Run("error facility cancelled", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, _, _, trx := setupPaymentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		cancelled := *mockFacility
+		cancelled.Status = model.FacilityStatusCancelled
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(&cancelled, nil).Once()
+
+		res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1200000)})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "no outstanding installment: facility has been cancelled", err.Error())
+		detailRepo.AssertNotCalled(t, "ListUnpaid", mock.Anything, mock.Anything)
+	})
+
+	t.Run("disbursement state", func(t *testing.T) {
+		cases := map[string]struct {
+			disbursement *model.Disbursement
+			err          error
+			want         string
+		}{
+			"error still pending": {&model.Disbursement{Status: model.DisbursementStatusPending}, nil,
+				"no outstanding installment: facility has not been disbursed yet"},
+			"error waiting for manual review": {&model.Disbursement{Status: model.DisbursementStatusManualReview}, nil,
+				"no outstanding installment: facility has not been disbursed yet"},
+			"booked before disbursements were tracked": {nil, errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil),
+				"no outstanding installment: facility has been fully paid"},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				facilityRepo := new(MockFacilityRepo)
+				detailRepo := new(MockDetailRepo)
+				disbursementRepo := new(MockDisbursementRepo)
+				trx := new(MockTrx)
+				svc := NewPaymentService(facilityRepo, detailRepo, new(MockPaymentRepo), new(MockPayoffRepo), new(MockLimitRepo), newOutboxRepo(), disbursementRepo, PayoffConfig{}, logger.NewNop(), trx)
+				ctx := context.Background()
+				txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+				trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+				trx.On("Rollback", mock.Anything).Return(nil).Once()
+				facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+				disbursementRepo.On("GetByFacility", txCtx, int64(7)).Return(c.disbursement, c.err).Once()
+				detailRepo.On("ListUnpaid", txCtx, 7).Return([]*model.UserFacilityDetail{}, nil).Maybe()
+
+				res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1200000)})
+				assert.Nil(t, res)
+				assert.Equal(t, c.want, err.Error())
+			})
+		}
+	})
+
+	t.Run("error replenish limit", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, paymentRepo, limitRepo, trx := setupPaymentService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil)
+		trx.On("Rollback", mock.Anything).Return(nil)
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil)
+		detailRepo.On("ListUnpaid", txCtx, 7).Return(unpaid(), nil)
+		paymentRepo.On("Add", txCtx, mock.Anything).Return(5, nil)
+		detailRepo.On("MarkPaid", txCtx, []int64{3}, int64(5), mock.Anything).Return(nil)
+		limitRepo.On("Post", txCtx, mock.Anything).Return(errors.New("replenish failed"))
+
+		res, err := svc.Pay(ctx, 7, &model.PaymentRequest{Amount: decimal.NewFromInt(1200000)})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "replenish failed", err.Error())
+		facilityRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Commit", txCtx)
+		trx.AssertCalled(t, "Rollback", mock.Anything)
+	})
+}
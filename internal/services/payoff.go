@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// unearnedRebate returns the part of the total margin that is given back when
+// the facility is settled with remaining installments still not yet due.
+func unearnedRebate(policy string, totalMargin decimal.Decimal, tenor, remaining int) decimal.Decimal {
+	if remaining <= 0 || tenor <= 0 {
+		return decimal.Zero
+	}
+
+	one := decimal.NewFromInt(1)
+	n := decimal.NewFromInt(int64(tenor))
+	k := decimal.NewFromInt(int64(remaining))
+
+	switch policy {
+	case model.RebatePolicyProRata:
+		return totalMargin.Mul(k).Div(n).Round(2)
+	case model.RebatePolicyRuleOf78:
+		return totalMargin.Mul(k.Mul(k.Add(one))).Div(n.Mul(n.Add(one))).Round(2)
+	default:
+		return decimal.Zero
+	}
+}
+
+func (s *paymentService) buildPayoffQuote(facility *model.UserFacility, unpaid []*model.UserFacilityDetail, now time.Time) *model.PayoffQuote {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	outstanding := decimal.Zero
+	principal := decimal.Zero
+	notYetDue := 0
+	for _, detail := range unpaid {
+		outstanding = outstanding.Add(detail.InstallmentAmount)
+		principal = principal.Add(detail.PrincipalAmount)
+		if detail.DueDate.After(today) {
+			notYetDue++
+		}
+	}
+
+	rebate := unearnedRebate(s.payoffCfg.RebatePolicy, facility.TotalMargin, facility.Tenor, notYetDue)
+	unpaidMargin := outstanding.Sub(principal)
+	if rebate.GreaterThan(unpaidMargin) {
+		rebate = unpaidMargin
+	}
+	fee := principal.Mul(s.payoffCfg.FeeRate).Round(2)
+
+	return &model.PayoffQuote{
+		UserFacilityID:     facility.UserFacilityID,
+		RemainingPrincipal: principal,
+		OutstandingAmount:  outstanding,
+		RebatePolicy:       s.payoffCfg.RebatePolicy,
+		Rebate:             rebate,
+		Fee:                fee,
+		PayoffAmount:       outstanding.Sub(rebate).Add(fee),
+		Status:             model.QuoteStatusOpen,
+		ExpiresAt:          now.Add(s.payoffCfg.QuoteTTL),
+		CreatedAt:          now,
+	}
+}
+
+func (s *paymentService) QuotePayoff(ctx context.Context, facilityID int) (*model.PayoffQuoteResponse, error) {
+	facility, err := s.facilityRepo.Get(ctx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, facility.UserID, "facility")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFacilityOpen(ctx, facility)
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid, err := s.detailRepo.ListUnpaid(ctx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get unpaid installments", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	if len(unpaid) == 0 {
+		return nil, errorx.NewError(errorx.ErrNoOutstanding, "facility has been fully paid", nil)
+	}
+
+	quote := s.buildPayoffQuote(facility, unpaid, time.Now())
+	quoteID, err := s.payoffRepo.Add(ctx, quote)
+	if err != nil {
+		s.log.Error("failed to insert payoff quote", zap.Error(err))
+		return nil, err
+	}
+
+	return &model.PayoffQuoteResponse{
+		QuoteID:            int64(quoteID),
+		UserFacilityID:     quote.UserFacilityID,
+		RemainingPrincipal: quote.RemainingPrincipal,
+		OutstandingAmount:  quote.OutstandingAmount,
+		RebatePolicy:       quote.RebatePolicy,
+		Rebate:             quote.Rebate,
+		Fee:                quote.Fee,
+		PayoffAmount:       quote.PayoffAmount,
+		ExpiresAt:          quote.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *paymentService) Payoff(ctx context.Context, facilityID int, req *model.PayoffRequest) (*model.PaymentResponse, error) {
+	txCtx, err := s.trx.Begin(ctx)
+	if err != nil {
+		s.log.Error("failed start transaction", zap.Error(err))
+		return nil, err
+	}
+	defer s.trx.Rollback(txCtx)
+
+	quote, err := s.payoffRepo.Get(txCtx, int(req.QuoteID))
+	if err != nil {
+		s.log.Error("failed to get payoff quote", zap.Int64("quote_id", req.QuoteID), zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case quote.UserFacilityID != int64(facilityID):
+		return nil, errorx.NewError(errorx.ErrQuoteNotValid, "quote does not belong to this facility", nil)
+	case quote.Status != model.QuoteStatusOpen:
+		return nil, errorx.NewError(errorx.ErrQuoteNotValid, "quote has already been used", nil)
+	case now.After(quote.ExpiresAt):
+		return nil, errorx.NewError(errorx.ErrQuoteNotValid, "quote has expired, request a new quote", nil)
+	}
+
+	facility, err := s.facilityRepo.Get(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get facility", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	err = checkOwner(ctx, facility.UserID, "facility")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkFacilityOpen(txCtx, facility)
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid, err := s.detailRepo.ListUnpaid(txCtx, facilityID)
+	if err != nil {
+		s.log.Error("failed to get unpaid installments", zap.Int("facility_id", facilityID), zap.Error(err))
+		return nil, err
+	}
+
+	if len(unpaid) == 0 {
+		return nil, errorx.NewError(errorx.ErrNoOutstanding, "facility has been fully paid", nil)
+	}
+
+	outstanding := decimal.Zero
+	for _, detail := range unpaid {
+		outstanding = outstanding.Add(detail.InstallmentAmount)
+	}
+	if !outstanding.Equal(quote.OutstandingAmount) {
+		return nil, errorx.NewError(errorx.ErrQuoteNotValid, "facility has changed since the quote was issued, request a new quote", nil)
+	}
+
+	payment := model.Payment{
+		UserFacilityID:  facility.UserFacilityID,
+		Amount:          quote.PayoffAmount,
+		PrincipalAmount: quote.RemainingPrincipal,
+		PaidAt:          now,
+		CreatedAt:       now,
+	}
+
+	paymentID, err := s.paymentRepo.Add(txCtx, &payment)
+	if err != nil {
+		s.log.Error("failed to insert payment", zap.Error(err))
+		return nil, err
+	}
+
+	ids := []int64{}
+	paidSchedule := []model.ScheduleDetail{}
+	for _, detail := range unpaid {
+		detail.Status = model.DetailStatusPaid
+		detail.PaidAt = &now
+		ids = append(ids, detail.DetailID)
+		paidSchedule = append(paidSchedule, toScheduleDetail(detail))
+	}
+
+	err = s.detailRepo.MarkPaid(txCtx, ids, int64(paymentID), now)
+	if err != nil {
+		s.log.Error("failed to mark installments as paid", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.payoffRepo.MarkExecuted(txCtx, int(quote.QuoteID), int64(paymentID))
+	if err != nil {
+		s.log.Error("failed to mark payoff quote as executed", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.facilityRepo.UpdateStatus(txCtx, facilityID, model.FacilityStatusPaidOff)
+	if err != nil {
+		s.log.Error("failed to close facility", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.limitRepo.Post(txCtx, newLedgerEntry(facility.FacilityLimitID, model.LedgerRepayment, quote.RemainingPrincipal, model.ReferencePayment, int64(paymentID)))
+	if err != nil {
+		s.log.Error("failed to replenish limit user", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.addPaymentEvents(txCtx, &payment, int64(paymentID), true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.trx.Commit(txCtx)
+	if err != nil {
+		s.log.Error("failed to commit query", zap.Error(err))
+		return nil, err
+	}
+
+	return &model.PaymentResponse{
+		PaymentID:        int64(paymentID),
+		UserFacilityID:   facility.UserFacilityID,
+		Amount:           quote.PayoffAmount,
+		PrincipalAmount:  quote.RemainingPrincipal,
+		PaidAt:           now.Format(time.RFC3339),
+		PaidInstallments: paidSchedule,
+	}, nil
+}
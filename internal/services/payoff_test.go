@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUnearnedRebate(t *testing.T) {
+	margin := decimal.NewFromInt(1200000)
+
+	assert.Equal(t, "0", unearnedRebate(model.RebatePolicyNone, margin, 12, 6).String())
+	assert.Equal(t, "600000", unearnedRebate(model.RebatePolicyProRata, margin, 12, 6).String())
+	// rule of 78: 1200000 * (6*7) / (12*13)
+	assert.Equal(t, "323076.92", unearnedRebate(model.RebatePolicyRuleOf78, margin, 12, 6).String())
+	assert.Equal(t, "0", unearnedRebate(model.RebatePolicyProRata, margin, 12, 0).String())
+}
+
+func TestPaymentService_QuotePayoff(t *testing.T) {
+	now := time.Now()
+	mockFacility := &model.UserFacility{
+		UserFacilityID:  7,
+		FacilityLimitID: 10,
+		Amount:          decimal.NewFromInt(6000000),
+		Tenor:           6,
+		TotalMargin:     decimal.NewFromInt(1200000),
+	}
+
+	t.Run("success pro rata", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, _, payoffRepo, _, _ := setupPayoffService()
+		ctx := context.Background()
+
+		unpaid := []*model.UserFacilityDetail{
+			{DetailID: 4, DueDate: now.AddDate(0, -1, 0), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000)},
+			{DetailID: 5, DueDate: now.AddDate(0, 1, 0), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000)},
+			{DetailID: 6, DueDate: now.AddDate(0, 2, 0), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000)},
+		}
+
+		facilityRepo.On("Get", mock.Anything, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", mock.Anything, 7).Return(unpaid, nil).Once()
+		payoffRepo.On("Add", mock.Anything, mock.AnythingOfType("*model.PayoffQuote")).Return(3, nil).Once()
+
+		res, err := svc.QuotePayoff(ctx, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), res.QuoteID)
+		assert.Equal(t, "3000000", res.RemainingPrincipal.String())
+		assert.Equal(t, "3600000", res.OutstandingAmount.String())
+		// two installments not yet due out of six
+		assert.Equal(t, "400000", res.Rebate.String())
+		assert.Equal(t, "30000", res.Fee.String())
+		assert.Equal(t, "3230000", res.PayoffAmount.String())
+	})
+
+	t.Run("error fully paid", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, _, payoffRepo, _, _ := setupPayoffService()
+		ctx := context.Background()
+
+		facilityRepo.On("Get", mock.Anything, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", mock.Anything, 7).Return([]*model.UserFacilityDetail{}, nil).Once()
+
+		res, err := svc.QuotePayoff(ctx, 7)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		payoffRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPaymentService_Payoff(t *testing.T) {
+	now := time.Now()
+	mockFacility := &model.UserFacility{
+		UserFacilityID:  7,
+		FacilityLimitID: 10,
+		Amount:          decimal.NewFromInt(6000000),
+		Tenor:           6,
+		TotalMargin:     decimal.NewFromInt(1200000),
+	}
+	unpaid := func() []*model.UserFacilityDetail {
+		return []*model.UserFacilityDetail{
+			{DetailID: 5, DueDate: now.AddDate(0, 1, 0), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000)},
+			{DetailID: 6, DueDate: now.AddDate(0, 2, 0), InstallmentAmount: decimal.NewFromInt(1200000), PrincipalAmount: decimal.NewFromInt(1000000), MarginAmount: decimal.NewFromInt(200000)},
+		}
+	}
+	quote := func() *model.PayoffQuote {
+		return &model.PayoffQuote{
+			QuoteID:            3,
+			UserFacilityID:     7,
+			RemainingPrincipal: decimal.NewFromInt(2000000),
+			OutstandingAmount:  decimal.NewFromInt(2400000),
+			Rebate:             decimal.NewFromInt(400000),
+			Fee:                decimal.NewFromInt(20000),
+			PayoffAmount:       decimal.NewFromInt(2020000),
+			Status:             model.QuoteStatusOpen,
+			ExpiresAt:          now.Add(time.Hour),
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, paymentRepo, payoffRepo, limitRepo, trx := setupPayoffService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		payoffRepo.On("Get", txCtx, 3).Return(quote(), nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", txCtx, 7).Return(unpaid(), nil).Once()
+		paymentRepo.On("Add", txCtx, mock.MatchedBy(func(p *model.Payment) bool {
+			return p.Amount.Equal(decimal.NewFromInt(2020000)) && p.PrincipalAmount.Equal(decimal.NewFromInt(2000000))
+		})).Return(11, nil).Once()
+		detailRepo.On("MarkPaid", txCtx, []int64{5, 6}, int64(11), mock.Anything).Return(nil).Once()
+		payoffRepo.On("MarkExecuted", txCtx, 3, int64(11)).Return(nil).Once()
+		facilityRepo.On("UpdateStatus", txCtx, 7, model.FacilityStatusPaidOff).Return(nil).Once()
+		limitRepo.On("Post", txCtx, mock.MatchedBy(func(e *model.LimitLedgerEntry) bool {
+			return e.EntryType == model.LedgerRepayment && e.Amount.Equal(decimal.NewFromInt(2000000)) && *e.ReferenceID == 11
+		})).Return(nil).Once()
+		trx.On("Commit", txCtx).Return(nil).Once()
+
+		res, err := svc.Payoff(ctx, 7, &model.PayoffRequest{QuoteID: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), res.PaymentID)
+		assert.Len(t, res.PaidInstallments, 2)
+		trx.AssertExpectations(t)
+		payoffRepo.AssertExpectations(t)
+		limitRepo.AssertExpectations(t)
+	})
+
+	t.Run("error quote expired", func(t *testing.T) {
+		svc, facilityRepo, _, _, payoffRepo, _, trx := setupPayoffService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		expired := quote()
+		expired.ExpiresAt = now.Add(-time.Minute)
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		payoffRepo.On("Get", txCtx, 3).Return(expired, nil).Once()
+
+		res, err := svc.Payoff(ctx, 7, &model.PayoffRequest{QuoteID: 3})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "payoff quote not valid: quote has expired, request a new quote", err.Error())
+		facilityRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error facility changed after quote", func(t *testing.T) {
+		svc, facilityRepo, detailRepo, paymentRepo, payoffRepo, _, trx := setupPayoffService()
+		ctx := context.Background()
+		txCtx := context.WithValue(ctx, "tx", "mock_transaction")
+
+		trx.On("Begin", mock.Anything).Return(txCtx, nil).Once()
+		trx.On("Rollback", mock.Anything).Return(nil).Once()
+		payoffRepo.On("Get", txCtx, 3).Return(quote(), nil).Once()
+		facilityRepo.On("Get", txCtx, 7).Return(mockFacility, nil).Once()
+		detailRepo.On("ListUnpaid", txCtx, 7).Return(unpaid()[1:], nil).Once()
+
+		res, err := svc.Payoff(ctx, 7, &model.PayoffRequest{QuoteID: 3})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		paymentRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		trx.AssertNotCalled(t, "Commit", txCtx)
+	})
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ProductService interface {
+	List(ctx context.Context) ([]*model.ProductResponse, error)
+	Create(ctx context.Context, req *model.ProductRequest) (*model.ProductResponse, error)
+	SetPricingMethod(ctx context.Context, id int, req *model.ProductPricingRequest) (*model.ProductResponse, error)
+}
+
+type productService struct {
+	productRepo repository.ProductRepository
+	log         *logger.Logger
+}
+
+func NewProductService(productRepo repository.ProductRepository, log *logger.Logger) ProductService {
+	return &productService{
+		productRepo: productRepo,
+		log:         log,
+	}
+}
+
+func (s *productService) List(ctx context.Context) ([]*model.ProductResponse, error) {
+	products, err := s.productRepo.List(ctx)
+	if err != nil {
+		s.log.Error("failed to get list limit products", zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.ProductResponse{}
+	for _, product := range products {
+		response = append(response, toProductResponse(product))
+	}
+
+	return response, nil
+}
+
+// Create registers a limit product. Leaving pricing_method empty prices the
+// product with the configured default method.
+func (s *productService) Create(ctx context.Context, req *model.ProductRequest) (*model.ProductResponse, error) {
+	product := &model.LimitProduct{
+		Code:      req.Code,
+		Name:      req.Name,
+		CreatedAt: time.Now(),
+	}
+	if req.PricingMethod != "" {
+		product.PricingMethod = &req.PricingMethod
+	}
+
+	id, err := s.productRepo.Add(ctx, product)
+	if err != nil {
+		s.log.Error("failed to insert limit product", zap.String("code", req.Code), zap.Error(err))
+		return nil, err
+	}
+	product.ProductID = int64(id)
+
+	return toProductResponse(product), nil
+}
+
+// SetPricingMethod selects the pricing method new quotes and bookings of the
+// product are priced with, an empty method falling back to the configured
+// default. Booked facilities keep the method stored on them.
+func (s *productService) SetPricingMethod(ctx context.Context, id int, req *model.ProductPricingRequest) (*model.ProductResponse, error) {
+	var method *string
+	if req.PricingMethod != "" {
+		method = &req.PricingMethod
+	}
+
+	err := s.productRepo.UpdatePricingMethod(ctx, id, method)
+	if err != nil {
+		s.log.Error("failed to update pricing method", zap.Int("product_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	product, err := s.productRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get limit product", zap.Int("product_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toProductResponse(product), nil
+}
+
+func toProductResponse(product *model.LimitProduct) *model.ProductResponse {
+	response := &model.ProductResponse{
+		ProductID: product.ProductID,
+		Code:      product.Code,
+		Name:      product.Name,
+	}
+	if product.PricingMethod != nil {
+		response.PricingMethod = *product.PricingMethod
+	}
+
+	return response
+}
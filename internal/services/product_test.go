@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/pricing"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupProductService() (ProductService, *MockProductRepo) {
+	productRepo := new(MockProductRepo)
+	return NewProductService(productRepo, logger.NewNop()), productRepo
+}
+
+func TestProductService_Create(t *testing.T) {
+	t.Run("success with own pricing method", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		productRepo.On("Add", mock.Anything, mock.MatchedBy(func(p *model.LimitProduct) bool {
+			return p.Code == "cash_loan" && p.PricingMethod != nil && *p.PricingMethod == pricing.MethodAnnuity
+		})).Return(2, nil).Once()
+
+		res, err := svc.Create(ctx, &model.ProductRequest{Code: "cash_loan", Name: "Cash Loan", PricingMethod: pricing.MethodAnnuity})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), res.ProductID)
+		assert.Equal(t, pricing.MethodAnnuity, res.PricingMethod)
+	})
+
+	t.Run("default pricing method", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		productRepo.On("Add", mock.Anything, mock.MatchedBy(func(p *model.LimitProduct) bool {
+			return p.PricingMethod == nil
+		})).Return(3, nil).Once()
+
+		res, err := svc.Create(ctx, &model.ProductRequest{Code: "bnpl", Name: "Buy Now Pay Later"})
+		assert.NoError(t, err)
+		assert.Empty(t, res.PricingMethod)
+	})
+
+	t.Run("error duplicate code", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		productRepo.On("Add", mock.Anything, mock.Anything).Return(0, errorx.NewError(errorx.ErrTypeConflict, "duplicate data", nil)).Once()
+
+		res, err := svc.Create(ctx, &model.ProductRequest{Code: "bnpl", Name: "Buy Now Pay Later"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestProductService_SetPricingMethod(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		method := pricing.MethodMurabahah
+		productRepo.On("UpdatePricingMethod", ctx, 2, mock.MatchedBy(func(m *string) bool {
+			return m != nil && *m == pricing.MethodMurabahah
+		})).Return(nil).Once()
+		productRepo.On("Get", ctx, 2).Return(&model.LimitProduct{ProductID: 2, Code: "cash_loan", PricingMethod: &method}, nil).Once()
+
+		res, err := svc.SetPricingMethod(ctx, 2, &model.ProductPricingRequest{PricingMethod: pricing.MethodMurabahah})
+		assert.NoError(t, err)
+		assert.Equal(t, pricing.MethodMurabahah, res.PricingMethod)
+	})
+
+	t.Run("empty method falls back to the default", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		productRepo.On("UpdatePricingMethod", ctx, 2, (*string)(nil)).Return(nil).Once()
+		productRepo.On("Get", ctx, 2).Return(&model.LimitProduct{ProductID: 2, Code: "cash_loan"}, nil).Once()
+
+		res, err := svc.SetPricingMethod(ctx, 2, &model.ProductPricingRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, res.PricingMethod)
+	})
+
+	t.Run("error unknown product", func(t *testing.T) {
+		svc, productRepo := setupProductService()
+		ctx := context.Background()
+
+		productRepo.On("UpdatePricingMethod", ctx, 9, mock.Anything).Return(errorx.NewError(errorx.ErrTypeNotFound, "resource not found in database", nil)).Once()
+
+		res, err := svc.SetPricingMethod(ctx, 9, &model.ProductPricingRequest{PricingMethod: pricing.MethodFlat})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		productRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"finance/internal/calendar"
+	"finance/internal/model"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type TenorService interface {
+	List(ctx context.Context) ([]*model.TenorResponse, error)
+	Create(ctx context.Context, req *model.TenorRequest) (*model.TenorResponse, error)
+	Update(ctx context.Context, id int, req *model.TenorRequest) (*model.TenorResponse, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type tenorService struct {
+	tenorRepo repository.TenorRepository
+	log       *logger.Logger
+}
+
+func NewTenorService(tenorRepo repository.TenorRepository, log *logger.Logger) TenorService {
+	return &tenorService{
+		tenorRepo: tenorRepo,
+		log:       log,
+	}
+}
+
+func (s *tenorService) List(ctx context.Context) ([]*model.TenorResponse, error) {
+	tenors, err := s.tenorRepo.ListAll(ctx)
+	if err != nil {
+		s.log.Error("failed to get list tenors", zap.Error(err))
+		return nil, err
+	}
+
+	response := []*model.TenorResponse{}
+	for _, tenor := range tenors {
+		response = append(response, toTenorResponse(tenor))
+	}
+
+	return response, nil
+}
+
+func (s *tenorService) Create(ctx context.Context, req *model.TenorRequest) (*model.TenorResponse, error) {
+	tenor, err := newTenor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkOverlap(ctx, tenor)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.tenorRepo.Add(ctx, tenor)
+	if err != nil {
+		s.log.Error("failed to insert tenor", zap.Error(err))
+		return nil, err
+	}
+	tenor.TenorID = int64(id)
+
+	return toTenorResponse(tenor), nil
+}
+
+func (s *tenorService) Update(ctx context.Context, id int, req *model.TenorRequest) (*model.TenorResponse, error) {
+	existing, err := s.tenorRepo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get tenor", zap.Int("tenor_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	tenor, err := newTenor(req)
+	if err != nil {
+		return nil, err
+	}
+	tenor.TenorID = existing.TenorID
+
+	// A rate that is already in effect may have priced booked facilities, so
+	// only its end date can still be moved.
+	if !existing.EffectiveFrom.After(time.Now()) && !sameTerms(existing, tenor) {
+		return nil, errorx.NewValidationError(map[string]string{
+			"effective_from": "rate is already in effect, only effective_to can be changed",
+		})
+	}
+
+	err = s.checkOverlap(ctx, tenor)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tenorRepo.Update(ctx, tenor)
+	if err != nil {
+		s.log.Error("failed to update tenor", zap.Int("tenor_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toTenorResponse(tenor), nil
+}
+
+func (s *tenorService) Delete(ctx context.Context, id int) error {
+	existing, err := s.tenorRepo.GetByID(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get tenor", zap.Int("tenor_id", id), zap.Error(err))
+		return err
+	}
+
+	if !existing.EffectiveFrom.After(time.Now()) {
+		return errorx.NewValidationError(map[string]string{
+			"effective_from": "rate is already in effect, set effective_to instead",
+		})
+	}
+
+	err = s.tenorRepo.Delete(ctx, id)
+	if err != nil {
+		s.log.Error("failed to delete tenor", zap.Int("tenor_id", id), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *tenorService) checkOverlap(ctx context.Context, tenor *model.Tenor) error {
+	overlap, err := s.tenorRepo.HasOverlap(ctx, tenor)
+	if err != nil {
+		s.log.Error("failed to check tenor overlap", zap.Error(err))
+		return err
+	}
+
+	if overlap {
+		return errorx.NewError(errorx.ErrTypeConflict, "effective period overlaps another rate of this tenor", nil)
+	}
+
+	return nil
+}
+
+func newTenor(req *model.TenorRequest) (*model.Tenor, error) {
+	fields := map[string]string{}
+
+	tenor := &model.Tenor{
+		ProductID:       req.ProductID,
+		TenorValue:      req.TenorValue,
+		Rate:            req.Rate,
+		MinAmount:       req.MinAmount,
+		MaxAmount:       req.MaxAmount,
+		BusinessDayRule: req.BusinessDayRule,
+	}
+	if tenor.BusinessDayRule == "" {
+		tenor.BusinessDayRule = calendar.RuleFollowing
+	}
+
+	if req.Rate.IsNegative() {
+		fields["rate"] = "cannot be negative"
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && req.MinAmount.GreaterThan(*req.MaxAmount) {
+		fields["min_amount"] = "cannot be greater than max_amount"
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		fields["effective_from"] = "invalid date format, use YYYY-MM-DD"
+	}
+	tenor.EffectiveFrom = effectiveFrom
+
+	if req.EffectiveTo != "" {
+		effectiveTo, err := time.Parse("2006-01-02", req.EffectiveTo)
+		if err != nil {
+			fields["effective_to"] = "invalid date format, use YYYY-MM-DD"
+		} else if effectiveTo.Before(effectiveFrom) {
+			fields["effective_to"] = "cannot be before effective_from"
+		}
+		tenor.EffectiveTo = &effectiveTo
+	}
+
+	if len(fields) > 0 {
+		return nil, errorx.NewValidationError(fields)
+	}
+
+	return tenor, nil
+}
+
+// sameTerms reports whether both rates only differ in their end date.
+func sameTerms(a, b *model.Tenor) bool {
+	sameAmount := func(x, y *decimal.Decimal) bool {
+		if x == nil || y == nil {
+			return x == nil && y == nil
+		}
+		return x.Equal(*y)
+	}
+
+	return a.ProductID == b.ProductID &&
+		a.TenorValue == b.TenorValue &&
+		a.Rate.Equal(b.Rate) &&
+		sameAmount(a.MinAmount, b.MinAmount) &&
+		sameAmount(a.MaxAmount, b.MaxAmount) &&
+		a.EffectiveFrom.Equal(b.EffectiveFrom) &&
+		a.BusinessDayRule == b.BusinessDayRule
+}
+
+func toTenorResponse(tenor *model.Tenor) *model.TenorResponse {
+	response := &model.TenorResponse{
+		TenorID:         tenor.TenorID,
+		ProductID:       tenor.ProductID,
+		TenorValue:      tenor.TenorValue,
+		Rate:            tenor.Rate,
+		MinAmount:       tenor.MinAmount,
+		MaxAmount:       tenor.MaxAmount,
+		EffectiveFrom:   tenor.EffectiveFrom.Format("2006-01-02"),
+		BusinessDayRule: tenor.BusinessDayRule,
+	}
+	if tenor.EffectiveTo != nil {
+		response.EffectiveTo = tenor.EffectiveTo.Format("2006-01-02")
+	}
+
+	return response
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"finance/internal/calendar"
+	"finance/internal/model"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTenorService() (TenorService, *MockTenorRepo) {
+	tenorRepo := new(MockTenorRepo)
+	return NewTenorService(tenorRepo, logger.NewNop()), tenorRepo
+}
+
+func TestTenorService_Create(t *testing.T) {
+	req := &model.TenorRequest{
+		ProductID:     2,
+		TenorValue:    12,
+		Rate:          decimal.RequireFromString("0.18"),
+		EffectiveFrom: "2027-01-01",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		tenorRepo.On("HasOverlap", mock.Anything, mock.Anything).Return(false, nil).Once()
+		tenorRepo.On("Add", mock.Anything, mock.MatchedBy(func(tenor *model.Tenor) bool {
+			return tenor.ProductID == 2 && tenor.TenorValue == 12 && tenor.EffectiveTo == nil && tenor.Rate.Equal(req.Rate)
+		})).Return(8, nil).Once()
+
+		res, err := svc.Create(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), res.TenorID)
+		assert.Equal(t, int64(2), res.ProductID)
+		assert.Equal(t, "2027-01-01", res.EffectiveFrom)
+		assert.Empty(t, res.EffectiveTo)
+	})
+
+	t.Run("error overlapping period", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		tenorRepo.On("HasOverlap", mock.Anything, mock.Anything).Return(true, nil).Once()
+
+		res, err := svc.Create(ctx, req)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: effective period overlaps another rate of this tenor", err.Error())
+		tenorRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error invalid range", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		minAmount := decimal.NewFromInt(5000000)
+		maxAmount := decimal.NewFromInt(1000000)
+		invalid := &model.TenorRequest{
+			TenorValue:    12,
+			Rate:          decimal.RequireFromString("-0.1"),
+			MinAmount:     &minAmount,
+			MaxAmount:     &maxAmount,
+			EffectiveFrom: "2027-01-01",
+			EffectiveTo:   "2026-12-31",
+		}
+
+		res, err := svc.Create(ctx, invalid)
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		tenorRepo.AssertNotCalled(t, "HasOverlap", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTenorService_Update(t *testing.T) {
+	inEffect := &model.Tenor{
+		TenorID:         3,
+		TenorValue:      12,
+		Rate:            decimal.RequireFromString("0.20"),
+		EffectiveFrom:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		BusinessDayRule: calendar.RuleFollowing,
+	}
+
+	t.Run("success end date of rate in effect", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		tenorRepo.On("GetByID", mock.Anything, 3).Return(inEffect, nil).Once()
+		tenorRepo.On("HasOverlap", mock.Anything, mock.Anything).Return(false, nil).Once()
+		tenorRepo.On("Update", mock.Anything, mock.MatchedBy(func(tenor *model.Tenor) bool {
+			return tenor.TenorID == 3 && tenor.EffectiveTo != nil
+		})).Return(nil).Once()
+
+		res, err := svc.Update(ctx, 3, &model.TenorRequest{
+			TenorValue:    12,
+			Rate:          decimal.RequireFromString("0.20"),
+			EffectiveFrom: "2026-01-01",
+			EffectiveTo:   "2026-12-31",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-12-31", res.EffectiveTo)
+	})
+
+	t.Run("error change rate in effect", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		tenorRepo.On("GetByID", mock.Anything, 3).Return(inEffect, nil).Once()
+
+		res, err := svc.Update(ctx, 3, &model.TenorRequest{
+			TenorValue:    12,
+			Rate:          decimal.RequireFromString("0.25"),
+			EffectiveFrom: "2026-01-01",
+		})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		tenorRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTenorService_Delete(t *testing.T) {
+	t.Run("success future rate", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		future := &model.Tenor{TenorID: 4, TenorValue: 12, EffectiveFrom: time.Now().AddDate(0, 1, 0)}
+		tenorRepo.On("GetByID", mock.Anything, 4).Return(future, nil).Once()
+		tenorRepo.On("Delete", mock.Anything, 4).Return(nil).Once()
+
+		err := svc.Delete(ctx, 4)
+		assert.NoError(t, err)
+		tenorRepo.AssertExpectations(t)
+	})
+
+	t.Run("error rate in effect", func(t *testing.T) {
+		svc, tenorRepo := setupTenorService()
+		ctx := context.Background()
+
+		current := &model.Tenor{TenorID: 3, TenorValue: 12, EffectiveFrom: time.Now().AddDate(0, -1, 0)}
+		tenorRepo.On("GetByID", mock.Anything, 3).Return(current, nil).Once()
+
+		err := svc.Delete(ctx, 3)
+		assert.Error(t, err)
+		tenorRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
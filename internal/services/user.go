@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/internal/phone"
+	"finance/internal/repository"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type UserService interface {
+	Get(ctx context.Context, id int) (*model.UserResponse, error)
+	Create(ctx context.Context, req *model.UserRequest) (*model.UserResponse, error)
+	Update(ctx context.Context, id int, req *model.UserRequest) (*model.UserResponse, error)
+	Deactivate(ctx context.Context, id int) (*model.UserResponse, error)
+}
+
+type userService struct {
+	userRepo repository.UserRepository
+	log      *logger.Logger
+}
+
+func NewUserService(userRepo repository.UserRepository, log *logger.Logger) UserService {
+	return &userService{
+		userRepo: userRepo,
+		log:      log,
+	}
+}
+
+func (s *userService) Get(ctx context.Context, id int) (*model.UserResponse, error) {
+	user, err := s.userRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toUserResponse(user), nil
+}
+
+// Create registers an active user. The phone number is stored in E.164 form,
+// so the same number written as 08xx or +628xx is rejected as a duplicate.
+func (s *userService) Create(ctx context.Context, req *model.UserRequest) (*model.UserResponse, error) {
+	number, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Name:      req.Name,
+		Phone:     number,
+		Status:    model.UserStatusActive,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := s.userRepo.Add(ctx, user)
+	if err != nil {
+		s.log.Error("failed to insert user", zap.Error(err))
+		return nil, err
+	}
+	user.UserID = int64(id)
+
+	return toUserResponse(user), nil
+}
+
+func (s *userService) Update(ctx context.Context, id int, req *model.UserRequest) (*model.UserResponse, error) {
+	number, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	user.Name = req.Name
+	user.Phone = number
+	user.UpdatedAt = &now
+
+	err = s.userRepo.Update(ctx, user)
+	if err != nil {
+		s.log.Error("failed to update user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	return toUserResponse(user), nil
+}
+
+// Deactivate stops the user from drawing on their limits. Existing
+// facilities keep running and deactivating twice is not an error.
+func (s *userService) Deactivate(ctx context.Context, id int) (*model.UserResponse, error) {
+	user, err := s.userRepo.Get(ctx, id)
+	if err != nil {
+		s.log.Error("failed to get user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+
+	if user.Status == model.UserStatusInactive {
+		return toUserResponse(user), nil
+	}
+
+	now := time.Now()
+	err = s.userRepo.UpdateStatus(ctx, id, model.UserStatusInactive, now)
+	if err != nil {
+		s.log.Error("failed to deactivate user", zap.Int("user_id", id), zap.Error(err))
+		return nil, err
+	}
+	user.Status = model.UserStatusInactive
+	user.UpdatedAt = &now
+
+	return toUserResponse(user), nil
+}
+
+func normalizePhone(raw string) (string, error) {
+	number, err := phone.Normalize(raw)
+	if err != nil {
+		return "", errorx.NewValidationError(map[string]string{"phone": phone.Hint})
+	}
+
+	return number, nil
+}
+
+// checkUserActive rejects new drawdowns and holds of a deactivated user.
+func checkUserActive(user *model.User) error {
+	if user.Status == model.UserStatusInactive {
+		return errorx.NewError(errorx.ErrUserNotActive, "user has been deactivated", nil)
+	}
+
+	return nil
+}
+
+func toUserResponse(user *model.User) *model.UserResponse {
+	response := &model.UserResponse{
+		UserID:    user.UserID,
+		Name:      user.Name,
+		Phone:     user.Phone,
+		Status:    user.Status,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+	}
+	if user.UpdatedAt != nil {
+		response.UpdatedAt = user.UpdatedAt.Format(time.RFC3339)
+	}
+
+	return response
+}
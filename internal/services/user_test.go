@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"finance/internal/model"
+	"finance/pkg/errorx"
+	"finance/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupUserService() (UserService, *MockUserRepo) {
+	userRepo := new(MockUserRepo)
+	svc := NewUserService(userRepo, logger.NewNop())
+
+	return svc, userRepo
+}
+
+func TestUserService_Create(t *testing.T) {
+	t.Run("success normalizes phone", func(t *testing.T) {
+		svc, userRepo := setupUserService()
+
+		userRepo.On("Add", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.Phone == "+628123456789" && u.Status == model.UserStatusActive
+		})).Return(4, nil).Once()
+
+		res, err := svc.Create(context.Background(), &model.UserRequest{Name: "user 4", Phone: "0812-3456-789"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), res.UserID)
+		assert.Equal(t, "+628123456789", res.Phone)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("error invalid phone", func(t *testing.T) {
+		svc, userRepo := setupUserService()
+
+		res, err := svc.Create(context.Background(), &model.UserRequest{Name: "user 4", Phone: "0212345678"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+
+		var appErr *errorx.AppError
+		assert.ErrorAs(t, err, &appErr)
+		assert.Contains(t, appErr.Fields, "phone")
+		userRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error duplicate phone", func(t *testing.T) {
+		svc, userRepo := setupUserService()
+
+		userRepo.On("Add", mock.Anything, mock.Anything).
+			Return(0, errorx.NewError(errorx.ErrTypeConflict, "duplicate data: Key (phone)=(+628123456789) already exists.", nil)).Once()
+
+		res, err := svc.Create(context.Background(), &model.UserRequest{Name: "user 4", Phone: "628123456789"})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		assert.Equal(t, "resource already exists: duplicate data: Key (phone)=(+628123456789) already exists.", err.Error())
+	})
+}
+
+func TestUserService_Update(t *testing.T) {
+	svc, userRepo := setupUserService()
+
+	userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Name: "user 1", Phone: "+628123456789", Status: model.UserStatusActive}, nil).Once()
+	userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.UserID == 1 && u.Name == "user one" && u.Phone == "+628987654321" && u.UpdatedAt != nil
+	})).Return(nil).Once()
+
+	res, err := svc.Update(context.Background(), 1, &model.UserRequest{Name: "user one", Phone: "+62 898 7654 321"})
+	assert.NoError(t, err)
+	assert.Equal(t, "+628987654321", res.Phone)
+	assert.NotEmpty(t, res.UpdatedAt)
+	userRepo.AssertExpectations(t)
+}
+
+func TestUserService_Deactivate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		svc, userRepo := setupUserService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusActive, CreatedAt: time.Now()}, nil).Once()
+		userRepo.On("UpdateStatus", mock.Anything, 1, model.UserStatusInactive, mock.Anything).Return(nil).Once()
+
+		res, err := svc.Deactivate(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusInactive, res.Status)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("already inactive", func(t *testing.T) {
+		svc, userRepo := setupUserService()
+
+		userRepo.On("Get", mock.Anything, 1).Return(&model.User{UserID: 1, Status: model.UserStatusInactive}, nil).Once()
+
+		res, err := svc.Deactivate(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusInactive, res.Status)
+		userRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
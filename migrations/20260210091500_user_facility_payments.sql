@@ -0,0 +1,31 @@
+-- +goose Up
+create table payments (
+    id serial primary key,
+    user_facility_id int not null references user_facilities(id),
+    amount decimal(15,2) not null,
+    principal_amount decimal(15,2) not null,
+    paid_at timestamp not null,
+    created_at timestamp default current_timestamp
+);
+
+alter table user_facility_details
+add column status varchar(10) not null default 'unpaid',
+add column payment_id int references payments(id),
+add column paid_at timestamp;
+
+create index idx_user_facility_details_status on user_facility_details (user_facility_id, status, due_date);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop index if exists idx_user_facility_details_status;
+alter table user_facility_details
+drop column if exists paid_at,
+drop column if exists payment_id,
+drop column if exists status;
+drop table if exists payments;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+alter table user_facilities
+add column status varchar(20) not null default 'active';
+
+create index idx_user_facilities_user_created on user_facilities (user_id, created_at, id);
+create index idx_user_facilities_created on user_facilities (created_at, id);
+create index idx_user_facilities_status on user_facilities (status);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop index if exists idx_user_facilities_status;
+drop index if exists idx_user_facilities_created;
+drop index if exists idx_user_facilities_user_created;
+alter table user_facilities drop column if exists status;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
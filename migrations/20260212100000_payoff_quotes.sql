@@ -0,0 +1,27 @@
+-- +goose Up
+create table payoff_quotes (
+    id serial primary key,
+    user_facility_id int not null references user_facilities(id),
+    remaining_principal decimal(15,2) not null,
+    outstanding_amount decimal(15,2) not null,
+    rebate_policy varchar(20) not null,
+    rebate decimal(15,2) not null,
+    fee decimal(15,2) not null,
+    payoff_amount decimal(15,2) not null,
+    status varchar(20) not null default 'open',
+    payment_id int references payments(id),
+    expires_at timestamp not null,
+    created_at timestamp default current_timestamp
+);
+
+create index idx_payoff_quotes_facility on payoff_quotes (user_facility_id);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists payoff_quotes;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+alter table tenors drop constraint if exists tenors_tenor_value_key;
+
+alter table tenors
+add column rate decimal(7,4) not null default 0.20,
+add column min_amount decimal(15,2),
+add column max_amount decimal(15,2),
+add column effective_from date not null default '2026-01-01',
+add column effective_to date;
+
+alter table tenors alter column rate drop default;
+alter table tenors alter column effective_from drop default;
+
+alter table tenors
+add constraint unique_tenor_effective unique (tenor_value, effective_from);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table tenors drop constraint if exists unique_tenor_effective;
+alter table tenors
+drop column if exists effective_to,
+drop column if exists effective_from,
+drop column if exists max_amount,
+drop column if exists min_amount,
+drop column if exists rate;
+alter table tenors add constraint tenors_tenor_value_key unique (tenor_value);
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
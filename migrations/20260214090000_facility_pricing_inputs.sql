@@ -0,0 +1,22 @@
+-- +goose Up
+alter table user_facilities
+add column rate decimal(7,4) not null default 0.20,
+add column pricing_method varchar(20) not null default 'flat',
+add column engine_version varchar(20) not null default '1.0.0';
+
+alter table user_facilities alter column rate drop default;
+alter table user_facilities alter column pricing_method drop default;
+alter table user_facilities alter column engine_version drop default;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table user_facilities
+drop column if exists engine_version,
+drop column if exists pricing_method,
+drop column if exists rate;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
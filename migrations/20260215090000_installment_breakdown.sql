@@ -0,0 +1,36 @@
+-- +goose Up
+alter table user_facility_details
+add column principal_amount decimal(15,2) not null default 0,
+add column margin_amount decimal(15,2) not null default 0,
+add column outstanding_principal decimal(15,2) not null default 0;
+
+with schedule as (
+    select d.id, d.installment_amount, f.amount, f.tenor,
+        row_number() over (partition by d.user_facility_id order by d.due_date) as seq,
+        round(f.amount / f.tenor, 2) as base
+    from user_facility_details d
+    join user_facilities f on f.id = d.user_facility_id
+)
+update user_facility_details d
+set principal_amount = case when s.seq < s.tenor then s.base else s.amount - s.base * (s.tenor - 1) end,
+    margin_amount = d.installment_amount - case when s.seq < s.tenor then s.base else s.amount - s.base * (s.tenor - 1) end,
+    outstanding_principal = case when s.seq < s.tenor then s.amount - s.base * s.seq else 0 end
+from schedule s
+where d.id = s.id;
+
+alter table user_facility_details alter column principal_amount drop default;
+alter table user_facility_details alter column margin_amount drop default;
+alter table user_facility_details alter column outstanding_principal drop default;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table user_facility_details
+drop column if exists outstanding_principal,
+drop column if exists margin_amount,
+drop column if exists principal_amount;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
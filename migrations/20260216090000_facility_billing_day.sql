@@ -0,0 +1,16 @@
+-- +goose Up
+alter table user_facilities
+add column billing_day smallint not null default 0,
+add column grace_period smallint not null default 0;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table user_facilities
+drop column if exists grace_period,
+drop column if exists billing_day;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
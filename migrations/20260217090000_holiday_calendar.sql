@@ -0,0 +1,21 @@
+-- +goose Up
+create table holidays (
+    id serial primary key,
+    holiday_date date not null unique,
+    name varchar(100) not null,
+    created_at timestamp default current_timestamp
+);
+
+alter table tenors
+add column business_day_rule varchar(20) not null default 'following';
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table tenors drop column if exists business_day_rule;
+drop table if exists holidays;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
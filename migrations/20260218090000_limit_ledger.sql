@@ -0,0 +1,28 @@
+-- +goose Up
+create table limit_ledger (
+    id bigserial primary key,
+    facility_limit_id int not null references user_facility_limits(id),
+    entry_type varchar(20) not null,
+    amount decimal(15,2) not null,
+    balance_after decimal(15,2) not null,
+    reference_type varchar(30),
+    reference_id bigint,
+    note varchar(255) not null default '',
+    created_at timestamp default current_timestamp
+);
+
+create index idx_limit_ledger_limit on limit_ledger (facility_limit_id, id);
+
+insert into limit_ledger (facility_limit_id, entry_type, amount, balance_after, note)
+select id, 'adjustment', limit_amount, limit_amount, 'opening balance'
+from user_facility_limits;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists limit_ledger;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
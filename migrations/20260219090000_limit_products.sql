@@ -0,0 +1,46 @@
+-- +goose Up
+create table limit_products (
+    id serial primary key,
+    code varchar(30) not null unique,
+    name varchar(100) not null,
+    pricing_method varchar(20),
+    created_at timestamp default current_timestamp
+);
+
+insert into limit_products (code, name) values ('general', 'General Financing');
+
+alter table user_facility_limits add column product_id int references limit_products(id);
+update user_facility_limits set product_id = (select id from limit_products where code = 'general');
+alter table user_facility_limits alter column product_id set not null;
+
+alter table user_facility_limits drop constraint if exists unique_user_limit;
+alter table user_facility_limits
+add constraint unique_user_product_limit unique (user_id, product_id);
+
+alter table tenors add column product_id int references limit_products(id);
+update tenors set product_id = (select id from limit_products where code = 'general');
+alter table tenors alter column product_id set not null;
+
+alter table tenors drop constraint if exists unique_tenor_effective;
+alter table tenors
+add constraint unique_tenor_effective unique (product_id, tenor_value, effective_from);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table tenors drop constraint if exists unique_tenor_effective;
+alter table tenors drop column if exists product_id;
+alter table tenors
+add constraint unique_tenor_effective unique (tenor_value, effective_from);
+
+alter table user_facility_limits drop constraint if exists unique_user_product_limit;
+alter table user_facility_limits drop column if exists product_id;
+alter table user_facility_limits
+add constraint unique_user_limit unique (user_id);
+
+drop table if exists limit_products;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
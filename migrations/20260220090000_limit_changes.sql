@@ -0,0 +1,32 @@
+-- +goose Up
+alter table user_facility_limits
+add column status varchar(20) not null default 'active';
+
+create table limit_changes (
+    id bigserial primary key,
+    action varchar(20) not null,
+    user_id int not null references users(id),
+    product_id int not null references limit_products(id),
+    facility_limit_id int references user_facility_limits(id),
+    amount decimal(15,2),
+    reason varchar(255) not null,
+    status varchar(20) not null default 'pending',
+    requested_by varchar(50) not null,
+    reviewed_by varchar(50),
+    review_note varchar(255) not null default '',
+    created_at timestamp default current_timestamp,
+    reviewed_at timestamp
+);
+
+create index idx_limit_changes_status on limit_changes (status, id);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists limit_changes;
+alter table user_facility_limits drop column if exists status;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+alter table user_facility_limits
+add column valid_from date not null default current_date,
+add column valid_until date,
+add column next_review_date date;
+
+update user_facility_limits
+set valid_until = (valid_from + interval '12 months')::date,
+    next_review_date = (valid_from + interval '12 months' - interval '30 days')::date;
+
+alter table user_facility_limits alter column valid_from drop default;
+alter table user_facility_limits alter column valid_until set not null;
+alter table user_facility_limits alter column next_review_date set not null;
+
+create index idx_user_facility_limits_review on user_facility_limits (next_review_date);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop index if exists idx_user_facility_limits_review;
+alter table user_facility_limits
+drop column if exists next_review_date,
+drop column if exists valid_until,
+drop column if exists valid_from;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+create table limit_holds (
+    id bigserial primary key,
+    facility_limit_id int not null references user_facility_limits(id),
+    user_id int not null references users(id),
+    amount decimal(15,2) not null,
+    reference varchar(100) not null default '',
+    status varchar(20) not null default 'active',
+    user_facility_id int references user_facilities(id),
+    expires_at timestamp not null,
+    created_at timestamp default current_timestamp,
+    resolved_at timestamp
+);
+
+create index idx_limit_holds_active on limit_holds (status, expires_at);
+create index idx_limit_holds_limit on limit_holds (facility_limit_id, status);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists limit_holds;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+alter table users
+add column status varchar(20) not null default 'active',
+add column created_at timestamp not null default current_timestamp,
+add column updated_at timestamp;
+
+update users set phone = regexp_replace(phone, '[^0-9+]', '', 'g');
+update users set phone = '+62' || substr(phone, 2) where phone like '0%';
+update users set phone = '+' || phone where phone like '62%';
+
+alter table users drop constraint if exists unique_user;
+alter table users add constraint unique_user_phone unique (phone);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table users drop constraint if exists unique_user_phone;
+alter table users add constraint unique_user unique (phone, name);
+alter table users
+drop column if exists updated_at,
+drop column if exists created_at,
+drop column if exists status;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+create table user_kyc (
+    id serial primary key,
+    user_id int not null references users(id),
+    nik char(16) not null,
+    full_name varchar(100) not null,
+    birth_date date not null,
+    status varchar(20) not null default 'pending',
+    reject_reason varchar(255) not null default '',
+    submitted_at timestamp not null,
+    reviewed_by varchar(50),
+    reviewed_at timestamp,
+    expires_at timestamp,
+    constraint unique_kyc_user unique (user_id),
+    constraint unique_kyc_nik unique (nik)
+);
+
+create index idx_user_kyc_status on user_kyc (status, expires_at);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists user_kyc;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+create table idempotency_keys (
+    id bigserial primary key,
+    user_id int not null references users(id),
+    key varchar(255) not null,
+    request_hash char(64) not null,
+    response jsonb not null,
+    user_facility_id int not null references user_facilities(id),
+    created_at timestamp default current_timestamp,
+    constraint unique_idempotency_key unique (user_id, key)
+);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists idempotency_keys;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+create table financing_applications (
+    id bigserial primary key,
+    user_id int not null references users(id),
+    facility_limit_id int not null references user_facility_limits(id),
+    amount decimal(15,2) not null,
+    tenor int not null,
+    start_date date,
+    billing_day int not null default 0,
+    grace_period int not null default 0,
+    status varchar(20) not null default 'draft',
+    user_facility_id int references user_facilities(id),
+    reviewed_by varchar(50),
+    review_note varchar(255) not null default '',
+    created_at timestamp default current_timestamp,
+    submitted_at timestamp,
+    review_started_at timestamp,
+    approved_at timestamp,
+    rejected_at timestamp,
+    cancelled_at timestamp,
+    disbursed_at timestamp
+);
+
+create index idx_financing_applications_status on financing_applications (status, id);
+create index idx_financing_applications_user on financing_applications (user_id, id);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists financing_applications;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
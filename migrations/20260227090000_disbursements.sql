@@ -0,0 +1,27 @@
+-- +goose Up
+create table disbursements (
+    id bigserial primary key,
+    user_facility_id int not null references user_facilities(id),
+    user_id int not null references users(id),
+    amount decimal(15,2) not null,
+    status varchar(20) not null default 'pending',
+    attempts int not null default 0,
+    next_attempt_at timestamp not null,
+    last_error varchar(255) not null default '',
+    bank_reference varchar(100),
+    created_at timestamp default current_timestamp,
+    completed_at timestamp,
+    constraint unique_disbursement_facility unique (user_facility_id)
+);
+
+create index idx_disbursements_due on disbursements (status, next_attempt_at);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists disbursements;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+create table outbox_events (
+    id bigserial primary key,
+    event_id varchar(36) not null,
+    event_type varchar(50) not null,
+    aggregate_type varchar(50) not null,
+    aggregate_id bigint not null,
+    payload jsonb not null,
+    attempts int not null default 0,
+    next_attempt_at timestamp not null,
+    last_error varchar(255) not null default '',
+    created_at timestamp default current_timestamp,
+    published_at timestamp,
+    constraint unique_outbox_event unique (event_id)
+);
+
+create index idx_outbox_events_unpublished on outbox_events (next_attempt_at, id) where published_at is null;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop table if exists outbox_events;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+alter table user_kyc
+alter column reviewed_by type varchar(255);
+
+alter table limit_changes
+alter column requested_by type varchar(255),
+alter column reviewed_by type varchar(255);
+
+alter table financing_applications
+alter column reviewed_by type varchar(255);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table financing_applications
+alter column reviewed_by type varchar(50);
+
+alter table limit_changes
+alter column reviewed_by type varchar(50),
+alter column requested_by type varchar(50);
+
+alter table user_kyc
+alter column reviewed_by type varchar(50);
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+alter table user_facilities
+add column remainder_strategy varchar(10) not null default 'last',
+add column rounding_unit decimal(15,2) not null default 100;
+
+alter table user_facilities alter column remainder_strategy drop default;
+alter table user_facilities alter column rounding_unit drop default;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table user_facilities
+drop column if exists rounding_unit,
+drop column if exists remainder_strategy;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+alter table outbox_events add column failed_at timestamp;
+
+drop index if exists idx_outbox_events_unpublished;
+create index idx_outbox_events_unpublished on outbox_events (next_attempt_at, id) where published_at is null and failed_at is null;
+create index idx_outbox_events_aggregate on outbox_events (aggregate_type, aggregate_id, id) where published_at is null;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+drop index if exists idx_outbox_events_aggregate;
+drop index if exists idx_outbox_events_unpublished;
+create index idx_outbox_events_unpublished on outbox_events (next_attempt_at, id) where published_at is null;
+alter table outbox_events drop column if exists failed_at;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+alter table limit_holds add column application_id bigint references financing_applications(id);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+alter table limit_holds drop column if exists application_id;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+alter table idempotency_keys alter column user_facility_id drop not null;
+alter table idempotency_keys add column application_id bigint references financing_applications(id);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+delete from idempotency_keys where user_facility_id is null;
+alter table idempotency_keys drop column if exists application_id;
+alter table idempotency_keys alter column user_facility_id set not null;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -9,8 +9,18 @@ const (
 	ErrTypeConflict      ErrorType = "resource already exists"
 	ErrTypeInternal      ErrorType = "internal server error"
 	ErrTypeValidation    ErrorType = "invalid validation"
+	ErrTypeUnauthorized  ErrorType = "unauthorized"
+	ErrTypeForbidden     ErrorType = "forbidden"
 	ErrInsufficientLimit ErrorType = "insufficient limit amount"
 	ErrTenorNotAvail     ErrorType = "tenor option not available"
+	ErrNoOutstanding     ErrorType = "no outstanding installment"
+	ErrPaymentMismatch   ErrorType = "payment amount mismatch"
+	ErrQuoteNotValid     ErrorType = "payoff quote not valid"
+	ErrLimitNotAvail     ErrorType = "limit not available"
+	ErrHoldNotValid      ErrorType = "limit hold not valid"
+	ErrUserNotActive     ErrorType = "user not active"
+	ErrKYCNotVerified    ErrorType = "kyc not verified"
+	ErrIdempotencyReused ErrorType = "idempotency key reused"
 )
 
 type AppError struct {
@@ -20,8 +20,14 @@ func MapErrorToStatusCode(errType ErrorType) int {
 		return http.StatusNotFound
 	case ErrTypeConflict:
 		return http.StatusConflict
-	case ErrTypeValidation, ErrInsufficientLimit, ErrTenorNotAvail:
+	case ErrTypeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrTypeForbidden:
+		return http.StatusForbidden
+	case ErrTypeValidation, ErrInsufficientLimit, ErrTenorNotAvail, ErrNoOutstanding, ErrPaymentMismatch, ErrQuoteNotValid, ErrLimitNotAvail, ErrHoldNotValid, ErrUserNotActive, ErrKYCNotVerified:
 		return http.StatusBadRequest
+	case ErrIdempotencyReused:
+		return http.StatusUnprocessableEntity
 	case ErrTypeInternal:
 		return http.StatusInternalServerError
 	default: